@@ -0,0 +1,390 @@
+package analytics
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+type CampaignMetrics struct {
+	CampaignId int64  `json:"campaignid"`
+	Name       string `json:"name"`
+	Subject    string `json:"subject"`
+	SendAt     string `json:"sendat"`
+
+	Sent                   int     `json:"sent"`
+	Delivered              int     `json:"delivered"`
+	Opens                  int     `json:"opens"`
+	UniqueOpens            int     `json:"uniqueOpens"`
+	UniqueOpensPercentage  float32 `json:"uniqueOpensPercentage"`
+	Clicks                 int     `json:"clicks"`
+	UniqueClicks           int     `json:"uniqueClicks"`
+	UniqueClicksPercentage float32 `json:"uniqueClicksPercentage"`
+	Bounces                int     `json:"bounces"`
+	BouncesPercentage      float32 `json:"bouncesPercentage"`
+}
+
+type TimeToOpenBucket struct {
+	Label      string  `json:"label"`
+	Count      int     `json:"count"`
+	Percentage float32 `json:"percentage"`
+}
+
+type PublicationMetrics struct {
+	PublicationId   int64  `json:"publicationid"`
+	PublicationName string `json:"publicationname"`
+
+	Sent                  int     `json:"sent"`
+	UniqueOpens           int     `json:"uniqueOpens"`
+	UniqueOpensPercentage float32 `json:"uniqueOpensPercentage"`
+	UniqueClicks          int     `json:"uniqueClicks"`
+	Bounces               int     `json:"bounces"`
+}
+
+type ContactEngagement struct {
+	ContactId int64  `json:"contactid"`
+	EmailId   int64  `json:"emailid"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+
+	Opens   int  `json:"opens"`
+	Clicks  int  `json:"clicks"`
+	Bounced bool `json:"bounced"`
+
+	FirstOpened time.Time `json:"firstopened"`
+
+	// Hours between the email going out and the first open, -1 if never opened
+	HoursToOpen float64 `json:"hourstoopen"`
+}
+
+// Upper bound of each time to open bucket
+var timeToOpenBuckets = []struct {
+	Label string
+	Upper time.Duration
+}{
+	{"Under 1 hour", time.Hour},
+	{"1 to 3 hours", 3 * time.Hour},
+	{"3 to 6 hours", 6 * time.Hour},
+	{"6 to 12 hours", 12 * time.Hour},
+	{"12 to 24 hours", 24 * time.Hour},
+	{"1 to 2 days", 48 * time.Hour},
+	{"2 to 7 days", 7 * 24 * time.Hour},
+	{"Over 7 days", 0},
+}
+
+/*
+* Private methods
+ */
+
+// When the email actually went out to the contact
+func emailSentTime(email models.Email) time.Time {
+	if !email.SendAt.IsZero() {
+		return email.SendAt
+	}
+	return email.Created
+}
+
+func percentage(part int, whole int) float32 {
+	if whole <= 0 {
+		return 0
+	}
+
+	// More unique opens than delivered emails can happen with image
+	// proxies, set a ceiling of 100%
+	if part > whole {
+		part = whole
+	}
+
+	return 100 * float32(part) / float32(whole)
+}
+
+// Only the emails that made it to a provider count towards a campaign
+func sentCampaignEmails(campaignId int64) ([]models.Email, error) {
+	emails := []models.Email{}
+	err := db.DB.Model(&emails).Where("campaign_id = ?", campaignId).Where("is_sent = ?", true).Where("cancel = ?", false).Where("archived = ?", false).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Email{}, err
+	}
+	return emails, nil
+}
+
+// First open of each email in the campaign
+func firstOpens(campaignId int64) (map[int64]time.Time, error) {
+	events := []models.EmailEvent{}
+	err := db.DB.Model(&events).Where("campaign_id = ?", campaignId).Where("event = ?", models.EmailEventOpen).Order("created ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return map[int64]time.Time{}, err
+	}
+
+	opens := map[int64]time.Time{}
+	for i := 0; i < len(events); i++ {
+		if _, ok := opens[events[i].EmailId]; !ok {
+			opens[events[i].EmailId] = events[i].Created
+		}
+	}
+
+	return opens, nil
+}
+
+func contactsByIds(contactIds []int64) (map[int64]models.Contact, error) {
+	contacts := []models.Contact{}
+	if len(contactIds) == 0 {
+		return map[int64]models.Contact{}, nil
+	}
+
+	err := db.DB.Model(&contacts).Where("id IN (?)", pg.In(contactIds)).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return map[int64]models.Contact{}, err
+	}
+
+	contactIdToContact := map[int64]models.Contact{}
+	for i := 0; i < len(contacts); i++ {
+		contactIdToContact[contacts[i].Id] = contacts[i]
+	}
+	return contactIdToContact, nil
+}
+
+func publicationsByIds(publicationIds []int64) (map[int64]models.Publication, error) {
+	publications := []models.Publication{}
+	if len(publicationIds) == 0 {
+		return map[int64]models.Publication{}, nil
+	}
+
+	err := db.DB.Model(&publications).Where("id IN (?)", pg.In(publicationIds)).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return map[int64]models.Publication{}, err
+	}
+
+	publicationIdToPublication := map[int64]models.Publication{}
+	for i := 0; i < len(publications); i++ {
+		publicationIdToPublication[publications[i].Id] = publications[i]
+	}
+	return publicationIdToPublication, nil
+}
+
+/*
+* Public methods
+ */
+
+func GetCampaignMetrics(campaign models.Campaign) (CampaignMetrics, error) {
+	metrics := CampaignMetrics{}
+	metrics.CampaignId = campaign.Id
+	metrics.Name = campaign.Name
+	metrics.Subject = campaign.Subject
+	if !campaign.SendAt.IsZero() {
+		metrics.SendAt = campaign.SendAt.Format(time.RFC3339)
+	}
+
+	emails, err := sentCampaignEmails(campaign.Id)
+	if err != nil {
+		return CampaignMetrics{}, err
+	}
+
+	for i := 0; i < len(emails); i++ {
+		metrics.Sent += 1
+		metrics.Opens += emails[i].Opened
+		metrics.Clicks += emails[i].Clicked
+
+		if !emails[i].Bounced && !emails[i].Dropped {
+			metrics.Delivered += 1
+		}
+
+		if emails[i].Opened > 0 {
+			metrics.UniqueOpens += 1
+		}
+
+		if emails[i].Clicked > 0 {
+			metrics.UniqueClicks += 1
+		}
+
+		if emails[i].Bounced {
+			metrics.Bounces += 1
+		}
+	}
+
+	// Delivered is everything that didn't bounce or get dropped, since not
+	// every provider reports deliveries
+	metrics.UniqueOpensPercentage = percentage(metrics.UniqueOpens, metrics.Delivered)
+	metrics.UniqueClicksPercentage = percentage(metrics.UniqueClicks, metrics.Delivered)
+	metrics.BouncesPercentage = percentage(metrics.Bounces, metrics.Sent)
+
+	return metrics, nil
+}
+
+func GetCampaignTimeToOpen(campaign models.Campaign) ([]TimeToOpenBucket, error) {
+	emails, err := sentCampaignEmails(campaign.Id)
+	if err != nil {
+		return []TimeToOpenBucket{}, err
+	}
+
+	opens, err := firstOpens(campaign.Id)
+	if err != nil {
+		return []TimeToOpenBucket{}, err
+	}
+
+	buckets := make([]TimeToOpenBucket, len(timeToOpenBuckets))
+	for i := 0; i < len(timeToOpenBuckets); i++ {
+		buckets[i].Label = timeToOpenBuckets[i].Label
+	}
+
+	totalOpened := 0
+	for i := 0; i < len(emails); i++ {
+		firstOpen, ok := opens[emails[i].Id]
+		if !ok {
+			continue
+		}
+
+		timeToOpen := firstOpen.Sub(emailSentTime(emails[i]))
+		for x := 0; x < len(timeToOpenBuckets); x++ {
+			if timeToOpenBuckets[x].Upper == 0 || timeToOpen < timeToOpenBuckets[x].Upper {
+				buckets[x].Count += 1
+				break
+			}
+		}
+		totalOpened += 1
+	}
+
+	for i := 0; i < len(buckets); i++ {
+		buckets[i].Percentage = percentage(buckets[i].Count, totalOpened)
+	}
+
+	return buckets, nil
+}
+
+// Breaks a campaign down by the current employers of the contacts it was sent
+// to. Contacts with several employers count towards each of them.
+func GetCampaignPublicationBreakdown(campaign models.Campaign) ([]PublicationMetrics, error) {
+	emails, err := sentCampaignEmails(campaign.Id)
+	if err != nil {
+		return []PublicationMetrics{}, err
+	}
+
+	contactIds := []int64{}
+	for i := 0; i < len(emails); i++ {
+		if emails[i].ContactId != 0 {
+			contactIds = append(contactIds, emails[i].ContactId)
+		}
+	}
+
+	contacts, err := contactsByIds(contactIds)
+	if err != nil {
+		return []PublicationMetrics{}, err
+	}
+
+	publicationIdToMetrics := map[int64]*PublicationMetrics{}
+	publicationIds := []int64{}
+	for i := 0; i < len(emails); i++ {
+		contact, ok := contacts[emails[i].ContactId]
+		if !ok {
+			continue
+		}
+
+		for x := 0; x < len(contact.Employers); x++ {
+			publicationId := contact.Employers[x]
+			metrics, ok := publicationIdToMetrics[publicationId]
+			if !ok {
+				metrics = &PublicationMetrics{PublicationId: publicationId}
+				publicationIdToMetrics[publicationId] = metrics
+				publicationIds = append(publicationIds, publicationId)
+			}
+
+			metrics.Sent += 1
+			if emails[i].Opened > 0 {
+				metrics.UniqueOpens += 1
+			}
+			if emails[i].Clicked > 0 {
+				metrics.UniqueClicks += 1
+			}
+			if emails[i].Bounced {
+				metrics.Bounces += 1
+			}
+		}
+	}
+
+	publications, err := publicationsByIds(publicationIds)
+	if err != nil {
+		return []PublicationMetrics{}, err
+	}
+
+	breakdown := []PublicationMetrics{}
+	for i := 0; i < len(publicationIds); i++ {
+		metrics := publicationIdToMetrics[publicationIds[i]]
+		metrics.PublicationName = publications[publicationIds[i]].Name
+		metrics.UniqueOpensPercentage = percentage(metrics.UniqueOpens, metrics.Sent-metrics.Bounces)
+		breakdown = append(breakdown, *metrics)
+	}
+
+	sort.Slice(breakdown, func(i, j int) bool {
+		return breakdown[i].Sent > breakdown[j].Sent
+	})
+
+	return breakdown, nil
+}
+
+func GetCampaignContactEngagement(campaign models.Campaign) ([]ContactEngagement, error) {
+	emails, err := sentCampaignEmails(campaign.Id)
+	if err != nil {
+		return []ContactEngagement{}, err
+	}
+
+	opens, err := firstOpens(campaign.Id)
+	if err != nil {
+		return []ContactEngagement{}, err
+	}
+
+	engagement := []ContactEngagement{}
+	for i := 0; i < len(emails); i++ {
+		contactEngagement := ContactEngagement{
+			ContactId:   emails[i].ContactId,
+			EmailId:     emails[i].Id,
+			Email:       emails[i].To,
+			FirstName:   emails[i].FirstName,
+			LastName:    emails[i].LastName,
+			Opens:       emails[i].Opened,
+			Clicks:      emails[i].Clicked,
+			Bounced:     emails[i].Bounced,
+			HoursToOpen: -1,
+		}
+
+		if firstOpen, ok := opens[emails[i].Id]; ok {
+			contactEngagement.FirstOpened = firstOpen
+			contactEngagement.HoursToOpen = firstOpen.Sub(emailSentTime(emails[i])).Hours()
+		}
+
+		engagement = append(engagement, contactEngagement)
+	}
+
+	// Most engaged contacts first
+	sort.SliceStable(engagement, func(i, j int) bool {
+		if engagement[i].Clicks != engagement[j].Clicks {
+			return engagement[i].Clicks > engagement[j].Clicks
+		}
+		return engagement[i].Opens > engagement[j].Opens
+	})
+
+	return engagement, nil
+}
+
+func CompareCampaigns(campaigns []models.Campaign) ([]CampaignMetrics, error) {
+	comparison := []CampaignMetrics{}
+	for i := 0; i < len(campaigns); i++ {
+		metrics, err := GetCampaignMetrics(campaigns[i])
+		if err != nil {
+			log.Printf("%v", err)
+			return []CampaignMetrics{}, err
+		}
+		comparison = append(comparison, metrics)
+	}
+	return comparison, nil
+}
@@ -0,0 +1,204 @@
+package analytics
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// How much each tracking event moves the engagement score of a contact
+var engagementEventWeights = map[string]float64{
+	models.EmailEventOpen:        1,
+	models.EmailEventClick:       3,
+	models.EmailEventBounce:      -3,
+	models.EmailEventDropped:     -1,
+	models.EmailEventSpam:        -5,
+	models.EmailEventUnsubscribe: -5,
+}
+
+// Events that count as the contact engaging with an email
+var engagedEvents = map[string]bool{
+	models.EmailEventOpen:  true,
+	models.EmailEventClick: true,
+}
+
+const (
+	// Time it takes for an engagement score to halve
+	engagementHalfLife = 90 * 24 * time.Hour
+
+	// Opens and clicks past these on a single email do not add to the score.
+	// Image proxies and link scanners can fire the same event many times.
+	maxScoredOpensPerEmail  = 3
+	maxScoredClicksPerEmail = 3
+)
+
+/*
+* Private methods
+ */
+
+func eventCountsTowardsScore(event string, opens int, clicks int) bool {
+	switch event {
+	case models.EmailEventOpen:
+		return opens <= maxScoredOpensPerEmail
+	case models.EmailEventClick:
+		return clicks <= maxScoredClicksPerEmail
+	}
+	return true
+}
+
+func addEngagementEvent(contact *models.Contact, event string, at time.Time) {
+	contact.EngagementScore = DecayEngagementScore(contact.EngagementScore, contact.EngagementUpdated, at)
+	contact.EngagementScore = math.Max(0, contact.EngagementScore+engagementEventWeights[event])
+	if at.After(contact.EngagementUpdated) {
+		contact.EngagementUpdated = at
+	}
+
+	if engagedEvents[event] && at.After(contact.LastEngaged) {
+		contact.LastEngaged = at
+	}
+}
+
+func contactEmails(contactId int64) ([]models.Email, error) {
+	emails := []models.Email{}
+	err := db.DB.Model(&emails).Where("contact_id = ?", contactId).Where("is_sent = ?", true).Where("cancel = ?", false).Where("archived = ?", false).Order("created ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Email{}, err
+	}
+	return emails, nil
+}
+
+func contactEmailEvents(contactId int64) ([]models.EmailEvent, error) {
+	events := []models.EmailEvent{}
+	err := db.DB.Model(&events).Where("contact_id = ?", contactId).Order("created ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.EmailEvent{}, err
+	}
+	return events, nil
+}
+
+/*
+* Public methods
+ */
+
+// Brings a score last updated at updated forward to now
+func DecayEngagementScore(score float64, updated time.Time, now time.Time) float64 {
+	if updated.IsZero() || !now.After(updated) {
+		return score
+	}
+	return score * math.Pow(0.5, float64(now.Sub(updated))/float64(engagementHalfLife))
+}
+
+// Score of a contact as of now. Stored scores are only decayed when they are
+// updated, so they have to go through this before being compared.
+func CurrentEngagementScore(contact models.Contact, now time.Time) float64 {
+	return DecayEngagementScore(contact.EngagementScore, contact.EngagementUpdated, now)
+}
+
+// Applies a single tracking event for email to the contact it was sent to.
+// The counters on email need to already include the event.
+func ApplyEngagementEvent(contact *models.Contact, email models.Email, event models.EmailEvent) {
+	if event.Event == models.EmailEventSent {
+		sentAt := emailSentTime(email)
+		if sentAt.After(contact.LastContacted) {
+			contact.LastContacted = sentAt
+		}
+		return
+	}
+
+	if !eventCountsTowardsScore(event.Event, email.Opened+email.SendGridOpened, email.Clicked) {
+		return
+	}
+
+	addEngagementEvent(contact, event.Event, event.Created)
+}
+
+// Recomputes the engagement of a contact from all the emails sent to it.
+// Emails sent before tracking events were recorded are scored from their
+// counters as if everything happened when they were sent.
+func CalculateContactEngagement(contact *models.Contact) error {
+	emails, err := contactEmails(contact.Id)
+	if err != nil {
+		return err
+	}
+
+	events, err := contactEmailEvents(contact.Id)
+	if err != nil {
+		return err
+	}
+
+	contact.EngagementScore = 0
+	contact.EngagementUpdated = time.Time{}
+	contact.LastContacted = time.Time{}
+	contact.LastEngaged = time.Time{}
+
+	emailIdToEvents := map[int64][]models.EmailEvent{}
+	for i := 0; i < len(events); i++ {
+		emailIdToEvents[events[i].EmailId] = append(emailIdToEvents[events[i].EmailId], events[i])
+	}
+
+	for i := 0; i < len(emails); i++ {
+		sentAt := emailSentTime(emails[i])
+		if sentAt.After(contact.LastContacted) {
+			contact.LastContacted = sentAt
+		}
+
+		if _, ok := emailIdToEvents[emails[i].Id]; ok {
+			continue
+		}
+
+		legacyEvents := []string{}
+		for x := 0; x < emails[i].Opened && x < maxScoredOpensPerEmail; x++ {
+			legacyEvents = append(legacyEvents, models.EmailEventOpen)
+		}
+		for x := 0; x < emails[i].Clicked && x < maxScoredClicksPerEmail; x++ {
+			legacyEvents = append(legacyEvents, models.EmailEventClick)
+		}
+		if emails[i].Bounced {
+			legacyEvents = append(legacyEvents, models.EmailEventBounce)
+		}
+		if emails[i].Spam {
+			legacyEvents = append(legacyEvents, models.EmailEventSpam)
+		}
+
+		for x := 0; x < len(legacyEvents); x++ {
+			addEngagementEvent(contact, legacyEvents[x], sentAt)
+		}
+	}
+
+	emailIdToOpens := map[int64]int{}
+	emailIdToClicks := map[int64]int{}
+	for i := 0; i < len(events); i++ {
+		switch events[i].Event {
+		case models.EmailEventSent:
+			continue
+		case models.EmailEventOpen:
+			emailIdToOpens[events[i].EmailId] += 1
+		case models.EmailEventClick:
+			emailIdToClicks[events[i].EmailId] += 1
+		}
+
+		if !eventCountsTowardsScore(events[i].Event, emailIdToOpens[events[i].EmailId], emailIdToClicks[events[i].EmailId]) {
+			continue
+		}
+
+		addEngagementEvent(contact, events[i].Event, events[i].Created)
+	}
+
+	return nil
+}
+
+// Persists only the engagement columns so it can't race with edits to the
+// rest of the contact
+func SaveContactEngagement(contact *models.Contact) error {
+	_, err := db.DB.Model(contact).Column("engagement_score", "engagement_updated", "last_contacted", "last_engaged").Update()
+	if err != nil {
+		log.Printf("%v", err)
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+/*
+* Private methods
+ */
+
+func formatPercentage(value float32) string {
+	return strconv.FormatFloat(float64(value), 'f', 2, 32)
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	err = writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+/*
+* Public methods
+ */
+
+func WriteCampaignMetricsCSV(w io.Writer, campaigns []CampaignMetrics) error {
+	header := []string{"Campaign Id", "Name", "Subject", "Sent At", "Sent", "Delivered", "Opens", "Unique Opens", "Unique Opens %", "Clicks", "Unique Clicks", "Unique Clicks %", "Bounces", "Bounces %"}
+	rows := [][]string{}
+	for i := 0; i < len(campaigns); i++ {
+		rows = append(rows, []string{
+			strconv.FormatInt(campaigns[i].CampaignId, 10),
+			campaigns[i].Name,
+			campaigns[i].Subject,
+			campaigns[i].SendAt,
+			strconv.Itoa(campaigns[i].Sent),
+			strconv.Itoa(campaigns[i].Delivered),
+			strconv.Itoa(campaigns[i].Opens),
+			strconv.Itoa(campaigns[i].UniqueOpens),
+			formatPercentage(campaigns[i].UniqueOpensPercentage),
+			strconv.Itoa(campaigns[i].Clicks),
+			strconv.Itoa(campaigns[i].UniqueClicks),
+			formatPercentage(campaigns[i].UniqueClicksPercentage),
+			strconv.Itoa(campaigns[i].Bounces),
+			formatPercentage(campaigns[i].BouncesPercentage),
+		})
+	}
+	return writeCSV(w, header, rows)
+}
+
+func WriteTimeToOpenCSV(w io.Writer, buckets []TimeToOpenBucket) error {
+	header := []string{"Time To Open", "Opens", "Opens %"}
+	rows := [][]string{}
+	for i := 0; i < len(buckets); i++ {
+		rows = append(rows, []string{
+			buckets[i].Label,
+			strconv.Itoa(buckets[i].Count),
+			formatPercentage(buckets[i].Percentage),
+		})
+	}
+	return writeCSV(w, header, rows)
+}
+
+func WritePublicationBreakdownCSV(w io.Writer, publications []PublicationMetrics) error {
+	header := []string{"Publication Id", "Publication", "Sent", "Unique Opens", "Unique Opens %", "Unique Clicks", "Bounces"}
+	rows := [][]string{}
+	for i := 0; i < len(publications); i++ {
+		rows = append(rows, []string{
+			strconv.FormatInt(publications[i].PublicationId, 10),
+			publications[i].PublicationName,
+			strconv.Itoa(publications[i].Sent),
+			strconv.Itoa(publications[i].UniqueOpens),
+			formatPercentage(publications[i].UniqueOpensPercentage),
+			strconv.Itoa(publications[i].UniqueClicks),
+			strconv.Itoa(publications[i].Bounces),
+		})
+	}
+	return writeCSV(w, header, rows)
+}
+
+func WriteContactEngagementCSV(w io.Writer, contacts []ContactEngagement) error {
+	header := []string{"Contact Id", "Email", "First Name", "Last Name", "Opens", "Clicks", "Bounced", "First Opened", "Hours To Open"}
+	rows := [][]string{}
+	for i := 0; i < len(contacts); i++ {
+		firstOpened := ""
+		hoursToOpen := ""
+		if !contacts[i].FirstOpened.IsZero() {
+			firstOpened = contacts[i].FirstOpened.Format(time.RFC3339)
+			hoursToOpen = strconv.FormatFloat(contacts[i].HoursToOpen, 'f', 1, 64)
+		}
+
+		rows = append(rows, []string{
+			strconv.FormatInt(contacts[i].ContactId, 10),
+			contacts[i].Email,
+			contacts[i].FirstName,
+			contacts[i].LastName,
+			strconv.Itoa(contacts[i].Opens),
+			strconv.Itoa(contacts[i].Clicks),
+			strconv.FormatBool(contacts[i].Bounced),
+			firstOpened,
+			hoursToOpen,
+		})
+	}
+	return writeCSV(w, header, rows)
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/analytics"
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// Most campaigns that can be compared at once
+var maxComparedCampaigns = 10
+
+/*
+* Private methods
+ */
+
+func getCampaignsForComparison(r *http.Request) ([]models.Campaign, error) {
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+
+	campaigns := []models.Campaign{}
+	for i := 0; i < len(ids); i++ {
+		id := strings.TrimSpace(ids[i])
+		if id == "" {
+			continue
+		}
+
+		currentId, err := utilities.StringIdToInt(id)
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Campaign{}, err
+		}
+
+		campaign, err := getCampaign(r, currentId)
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Campaign{}, err
+		}
+
+		campaigns = append(campaigns, campaign)
+	}
+
+	if len(campaigns) < 2 {
+		return []models.Campaign{}, errors.New("Need at least two campaigns to compare")
+	}
+
+	if len(campaigns) > maxComparedCampaigns {
+		return []models.Campaign{}, errors.New("Can not compare more than 10 campaigns at once")
+	}
+
+	return campaigns, nil
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+func GetCampaignAnalytics(r *http.Request, id string) (analytics.CampaignMetrics, interface{}, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return analytics.CampaignMetrics{}, nil, err
+	}
+
+	metrics, err := analytics.GetCampaignMetrics(campaign)
+	return metrics, nil, err
+}
+
+func GetCampaignTimeToOpen(r *http.Request, id string) ([]analytics.TimeToOpenBucket, interface{}, int, int, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []analytics.TimeToOpenBucket{}, nil, 0, 0, err
+	}
+
+	buckets, err := analytics.GetCampaignTimeToOpen(campaign)
+	return buckets, nil, len(buckets), len(buckets), err
+}
+
+func GetCampaignPublications(r *http.Request, id string) ([]analytics.PublicationMetrics, interface{}, int, int, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []analytics.PublicationMetrics{}, nil, 0, 0, err
+	}
+
+	publications, err := analytics.GetCampaignPublicationBreakdown(campaign)
+	return publications, nil, len(publications), len(publications), err
+}
+
+func GetCampaignContacts(r *http.Request, id string) ([]analytics.ContactEngagement, interface{}, int, int, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []analytics.ContactEngagement{}, nil, 0, 0, err
+	}
+
+	contacts, err := analytics.GetCampaignContactEngagement(campaign)
+	return contacts, nil, len(contacts), len(contacts), err
+}
+
+func CompareCampaigns(r *http.Request) ([]analytics.CampaignMetrics, interface{}, int, int, error) {
+	campaigns, err := getCampaignsForComparison(r)
+	if err != nil {
+		return []analytics.CampaignMetrics{}, nil, 0, 0, err
+	}
+
+	comparison, err := analytics.CompareCampaigns(campaigns)
+	return comparison, nil, len(comparison), len(comparison), err
+}
+
+/*
+* Export methods
+ */
+
+// Writes one of the campaign reports as CSV. The report is picked with the
+// "report" parameter: summary (default), timetoopen, publications or contacts.
+func ExportCampaignAnalytics(r *http.Request, w io.Writer, id string) error {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	switch r.URL.Query().Get("report") {
+	case "", "summary":
+		metrics, err := analytics.GetCampaignMetrics(campaign)
+		if err != nil {
+			return err
+		}
+		return analytics.WriteCampaignMetricsCSV(w, []analytics.CampaignMetrics{metrics})
+	case "timetoopen":
+		buckets, err := analytics.GetCampaignTimeToOpen(campaign)
+		if err != nil {
+			return err
+		}
+		return analytics.WriteTimeToOpenCSV(w, buckets)
+	case "publications":
+		publications, err := analytics.GetCampaignPublicationBreakdown(campaign)
+		if err != nil {
+			return err
+		}
+		return analytics.WritePublicationBreakdownCSV(w, publications)
+	case "contacts":
+		contacts, err := analytics.GetCampaignContactEngagement(campaign)
+		if err != nil {
+			return err
+		}
+		return analytics.WriteContactEngagementCSV(w, contacts)
+	}
+
+	return errors.New("Invalid report type")
+}
+
+func ExportCampaignComparison(r *http.Request, w io.Writer) error {
+	campaigns, err := getCampaignsForComparison(r)
+	if err != nil {
+		return err
+	}
+
+	comparison, err := analytics.CompareCampaigns(campaigns)
+	if err != nil {
+		return err
+	}
+
+	return analytics.WriteCampaignMetricsCSV(w, comparison)
+}
@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	apiModels "github.com/news-ai/api-v1/models"
+
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/search"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// Parent is a pointer so a beat can be moved to the top with "parent": 0
+type updateBeatDetails struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Parent      *int64 `json:"parent"`
+}
+
+/*
+* Private methods
+ */
+
+/*
+* Get methods
+ */
+
+func getBeat(id int64) (models.Beat, models.BeatTaxonomy, error) {
+	if id == 0 {
+		return models.Beat{}, models.BeatTaxonomy{}, errors.New("datastore: no such entity")
+	}
+
+	taxonomy, err := models.GetBeatTaxonomy()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, models.BeatTaxonomy{}, err
+	}
+
+	beat, ok := taxonomy.Get(id)
+	if !ok {
+		return models.Beat{}, models.BeatTaxonomy{}, errors.New("No beat by this id")
+	}
+
+	return beat, taxonomy, nil
+}
+
+// The taxonomy is managed by admins
+func checkBeatAdmin(r *http.Request) (apiModels.UserPostgres, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return apiModels.UserPostgres{}, err
+	}
+
+	if !user.Data.IsAdmin {
+		return apiModels.UserPostgres{}, errors.New("Forbidden")
+	}
+
+	return user, nil
+}
+
+// Beats under the same parent can't share a slug, so paths stay unambiguous
+func checkBeatPlacement(taxonomy models.BeatTaxonomy, beat models.Beat) error {
+	if beat.ParentBeat != 0 {
+		if _, ok := taxonomy.Get(beat.ParentBeat); !ok {
+			return errors.New("No parent beat by this id")
+		}
+
+		for _, ancestor := range taxonomy.Ancestors(beat.ParentBeat) {
+			if ancestor.Id == beat.Id {
+				return errors.New("Beat can not be moved under itself")
+			}
+		}
+		if beat.ParentBeat == beat.Id {
+			return errors.New("Beat can not be moved under itself")
+		}
+	}
+
+	for _, sibling := range taxonomy.Beats {
+		if sibling.Id != beat.Id && sibling.ParentBeat == beat.ParentBeat && sibling.Slug == beat.Slug {
+			return errors.New("There is already a beat called " + sibling.Path)
+		}
+	}
+
+	return nil
+}
+
+// Makes sure every beat exists, leaving out repeats
+func validateBeatIds(beatIds []int64) ([]int64, error) {
+	if len(beatIds) == 0 {
+		return []int64{}, nil
+	}
+
+	taxonomy, err := models.GetBeatTaxonomy()
+	if err != nil {
+		log.Printf("%v", err)
+		return []int64{}, err
+	}
+
+	validBeatIds := []int64{}
+	for i := 0; i < len(beatIds); i++ {
+		if _, ok := taxonomy.Get(beatIds[i]); !ok {
+			return []int64{}, errors.New("No beat by this id")
+		}
+		validBeatIds = appendUniqueIds(validBeatIds, beatIds[i])
+	}
+
+	return validBeatIds, nil
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Gets the taxonomy ordered by path. ?q= only gets beats whose path has the
+// text in it and ?parent= only the beats right under a beat.
+func GetBeats(r *http.Request) ([]models.Beat, interface{}, int, int, error) {
+	taxonomy, err := models.GetBeatTaxonomy()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Beat{}, nil, 0, 0, err
+	}
+
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	parent := r.URL.Query().Get("parent")
+
+	beats := []models.Beat{}
+	for _, beat := range taxonomy.Beats {
+		if query != "" && !strings.Contains(strings.ToLower(beat.Path), query) && !strings.Contains(beat.Slug, query) {
+			continue
+		}
+
+		if parent != "" {
+			parentId, err := utilities.StringIdToInt(parent)
+			if err != nil || beat.ParentBeat != parentId {
+				continue
+			}
+		}
+
+		beats = append(beats, beat)
+	}
+
+	return beats, nil, len(beats), len(beats), nil
+}
+
+func GetBeat(id string) (models.Beat, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	beat, _, err := getBeat(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	return beat, nil, nil
+}
+
+// Contacts of the user on the beat or any beat under it
+func GetContactsForBeat(r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	contacts, total, err := search.SearchContactsByBeat(r, id, user.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	publications := contactsToPublications(contacts)
+	return contacts, publications, len(contacts), total, nil
+}
+
+// Publications on the beat or any beat under it
+func GetPublicationsForBeat(r *http.Request, id string) ([]models.Publication, interface{}, int, int, error) {
+	publications, total, err := filterPublicationsByBeat(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Publication{}, nil, 0, 0, err
+	}
+
+	return publications, nil, len(publications), total, nil
+}
+
+/*
+* Create methods
+ */
+
+func CreateBeat(r *http.Request) (models.Beat, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var beat models.Beat
+	err := decoder.Decode(buf, &beat)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	currentUser, err := checkBeatAdmin(r)
+	if err != nil {
+		return models.Beat{}, nil, err
+	}
+
+	_, err = beat.Validate()
+	if err != nil {
+		return models.Beat{}, nil, err
+	}
+
+	taxonomy, err := models.GetBeatTaxonomy()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	err = checkBeatPlacement(taxonomy, beat)
+	if err != nil {
+		return models.Beat{}, nil, err
+	}
+
+	_, err = beat.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	beat, _, err = getBeat(beat.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	return beat, nil, nil
+}
+
+/*
+* Update methods
+ */
+
+// Renames or moves a beat. Contacts and publications keep their beats.
+func UpdateBeat(r *http.Request, id string) (models.Beat, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	_, err = checkBeatAdmin(r)
+	if err != nil {
+		return models.Beat{}, nil, err
+	}
+
+	beat, taxonomy, err := getBeat(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var updatedBeat updateBeatDetails
+	err = decoder.Decode(buf, &updatedBeat)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	utilities.UpdateIfNotBlank(&beat.Name, updatedBeat.Name)
+	utilities.UpdateIfNotBlank(&beat.Description, updatedBeat.Description)
+
+	// A new name gets a new slug unless one is given
+	if updatedBeat.Slug != "" {
+		beat.Slug = updatedBeat.Slug
+	} else if updatedBeat.Name != "" {
+		beat.Slug = ""
+	}
+
+	if updatedBeat.Parent != nil {
+		beat.ParentBeat = *updatedBeat.Parent
+	}
+
+	_, err = beat.Validate()
+	if err != nil {
+		return models.Beat{}, nil, err
+	}
+
+	err = checkBeatPlacement(taxonomy, beat)
+	if err != nil {
+		return models.Beat{}, nil, err
+	}
+
+	_, err = beat.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	beat, _, err = getBeat(beat.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Beat{}, nil, err
+	}
+
+	return beat, nil, nil
+}
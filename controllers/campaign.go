@@ -0,0 +1,480 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/search"
+	"github.com/news-ai/tabulae-v1/sync"
+
+	"github.com/news-ai/web/utilities"
+)
+
+/*
+* Private methods
+ */
+
+/*
+* Get methods
+ */
+
+func getCampaignUnauthorized(id int64) (models.Campaign, error) {
+	if id == 0 {
+		return models.Campaign{}, errors.New("datastore: no such entity")
+	}
+
+	campaign := models.Campaign{}
+	err := db.DB.Model(&campaign).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, err
+	}
+
+	if !campaign.Created.IsZero() {
+		campaign.Type = "campaigns"
+		return campaign, nil
+	}
+
+	return models.Campaign{}, errors.New("No campaign by this id")
+}
+
+func getCampaign(r *http.Request, id int64) (models.Campaign, error) {
+	campaign, err := getCampaignUnauthorized(id)
+	if err != nil {
+		return models.Campaign{}, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, errors.New("Could not get user")
+	}
+
+	if campaign.CreatedBy != user.Id && !user.Data.IsAdmin {
+		if campaign.TeamId == 0 || user.Data.TeamId == 0 || campaign.TeamId != user.Data.TeamId {
+			return models.Campaign{}, errors.New("Forbidden")
+		}
+	}
+
+	return campaign, nil
+}
+
+func getCampaignsForUser(r *http.Request, userId int64) ([]models.Campaign, int, error) {
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	campaigns := []models.Campaign{}
+	total, err := db.DB.Model(&campaigns).Where("created_by = ?", userId).Where("archived = ?", false).Order("created DESC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Campaign{}, 0, err
+	}
+
+	for i := 0; i < len(campaigns); i++ {
+		campaigns[i].Type = "campaigns"
+	}
+
+	return campaigns, total, nil
+}
+
+func filterEmailsByCampaignId(campaignId int64) ([]models.Email, error) {
+	emails := []models.Email{}
+	err := db.DB.Model(&emails).Where("campaign_id = ?", campaignId).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Email{}, err
+	}
+
+	for i := 0; i < len(emails); i++ {
+		emails[i].Type = "emails"
+	}
+
+	return emails, nil
+}
+
+/*
+* Create methods
+ */
+
+// Makes sure every email in a send references a campaign the user owns. Emails
+// that do not reference one are grouped into a new campaign. Sends that come
+// in several batches make the campaign with the first batch and pass its id
+// on the emails of the later ones.
+func assignCampaignToEmails(r *http.Request, currentUser apiModels.UserPostgres, emails []models.Email) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	campaignId := int64(0)
+	for i := 0; i < len(emails); i++ {
+		if emails[i].CampaignId == 0 {
+			continue
+		}
+		if campaignId != 0 && emails[i].CampaignId != campaignId {
+			return errors.New("Emails of a send have to be in the same campaign")
+		}
+		campaignId = emails[i].CampaignId
+	}
+
+	if campaignId != 0 {
+		campaign, err := getCampaign(r, campaignId)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		// Sent campaigns still take the later batches of their send
+		if campaign.Status == models.CampaignStatusCancelled {
+			return errors.New("Campaign has already been " + campaign.Status)
+		}
+	} else {
+		campaign := models.Campaign{}
+		campaign.Subject = emails[0].Subject
+		if emails[0].BaseSubject != "" {
+			campaign.Subject = emails[0].BaseSubject
+		}
+
+		campaign.Name = campaign.Subject
+		if strings.TrimSpace(campaign.Name) == "" {
+			campaign.Name = "(no subject)"
+		}
+
+		campaign.ListId = emails[0].ListId
+		campaign.TemplateId = emails[0].TemplateId
+		campaign.SendAt = emails[0].SendAt
+		campaign.TeamId = currentUser.Data.TeamId
+
+		_, err := campaign.Create(r, currentUser)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		campaignId = campaign.Id
+	}
+
+	for i := 0; i < len(emails); i++ {
+		emails[i].CampaignId = campaignId
+	}
+
+	return nil
+}
+
+/*
+* Update methods
+ */
+
+// Updates the status of the campaigns of emails that have just been sent
+func markCampaignsSent(emails []models.Email) {
+	campaignSendAt := map[int64]time.Time{}
+	for i := 0; i < len(emails); i++ {
+		if emails[i].CampaignId != 0 {
+			campaignSendAt[emails[i].CampaignId] = emails[i].SendAt
+		}
+	}
+
+	for campaignId, sendAt := range campaignSendAt {
+		campaign, err := getCampaignUnauthorized(campaignId)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+
+		_, err = campaign.MarkSent(sendAt)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+func GetCampaign(r *http.Request, id string) (models.Campaign, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	campaign, err := getCampaign(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	return campaign, nil, nil
+}
+
+func GetCampaigns(r *http.Request) ([]models.Campaign, interface{}, int, int, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Campaign{}, nil, 0, 0, err
+	}
+
+	campaigns, total, err := getCampaignsForUser(r, user.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Campaign{}, nil, 0, 0, err
+	}
+
+	return campaigns, nil, len(campaigns), total, nil
+}
+
+func GetCampaignStats(r *http.Request, id string) (interface{}, interface{}, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	campaignOwner, _, err := controllers.GetUserById(r, campaign.CreatedBy)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	stats, err := search.SearchEmailCampaignStats(campaignOwner, campaign)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	return stats, nil, nil
+}
+
+func GetEmailsForCampaign(r *http.Request, id string) ([]models.Email, interface{}, int, int, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Email{}, nil, 0, 0, err
+	}
+
+	emails, err := filterEmailsByCampaignId(campaign.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Email{}, nil, 0, 0, err
+	}
+
+	return emails, nil, len(emails), 0, nil
+}
+
+/*
+* Create methods
+ */
+
+func CreateCampaign(r *http.Request) (models.Campaign, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var campaign models.Campaign
+	err := decoder.Decode(buf, &campaign)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return campaign, nil, err
+	}
+
+	if campaign.Name == "" {
+		campaign.Name = campaign.Subject
+	}
+
+	if strings.TrimSpace(campaign.Name) == "" {
+		return models.Campaign{}, nil, errors.New("Campaign needs a name")
+	}
+
+	// Status is only changed by sending or cancelling
+	campaign.Status = models.CampaignStatusDraft
+	campaign.TeamId = currentUser.Data.TeamId
+
+	_, err = campaign.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	campaign.Type = "campaigns"
+	return campaign, nil, nil
+}
+
+/*
+* Update methods
+ */
+
+func UpdateCampaign(r *http.Request, id string) (models.Campaign, interface{}, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	if campaign.CreatedBy != user.Id && !user.Data.IsAdmin {
+		return models.Campaign{}, nil, errors.New("Forbidden")
+	}
+
+	decoder := ffjson.NewDecoder()
+	buf, _ := ioutil.ReadAll(r.Body)
+	var updatedCampaign models.Campaign
+	err = decoder.Decode(buf, &updatedCampaign)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	utilities.UpdateIfNotBlank(&campaign.Name, updatedCampaign.Name)
+
+	// The content of a campaign can only change before it is sent
+	if campaign.Status == models.CampaignStatusDraft {
+		utilities.UpdateIfNotBlank(&campaign.Subject, updatedCampaign.Subject)
+
+		if updatedCampaign.TemplateId != 0 {
+			campaign.TemplateId = updatedCampaign.TemplateId
+		}
+
+		if updatedCampaign.ListId != 0 {
+			campaign.ListId = updatedCampaign.ListId
+		}
+
+		if !updatedCampaign.SendAt.IsZero() {
+			campaign.SendAt = updatedCampaign.SendAt
+		}
+	}
+
+	// If new campaign wants to be archived then archive it
+	if updatedCampaign.Archived == true {
+		campaign.Archived = true
+	}
+
+	// If they are already archived and you want to unarchive the campaign
+	if campaign.Archived == true && updatedCampaign.Archived == false {
+		campaign.Archived = false
+	}
+
+	campaign.Save()
+	return campaign, nil, nil
+}
+
+// Cancels every email of the campaign that has not gone out yet
+func CancelCampaign(r *http.Request, id string) (models.Campaign, interface{}, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	if campaign.Status == models.CampaignStatusSent {
+		return models.Campaign{}, nil, errors.New("Campaign has already been sent")
+	}
+
+	emails, err := filterEmailsByCampaignId(campaign.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Campaign{}, nil, err
+	}
+
+	emailIds := []int64{}
+	for i := 0; i < len(emails); i++ {
+		if emails[i].Delievered || emails[i].Cancel {
+			continue
+		}
+
+		if !emails[i].IsSent || (!emails[i].SendAt.IsZero() && emails[i].SendAt.After(time.Now())) {
+			emails[i].Cancel = true
+			emails[i].Save()
+			emailIds = append(emailIds, emails[i].Id)
+		}
+	}
+
+	campaign.Status = models.CampaignStatusCancelled
+	campaign.Save()
+
+	sync.EmailResourceBulkSync(r, emailIds)
+	return campaign, nil, nil
+}
+
+// Groups the user's emails from before campaigns existed into campaigns, using
+// the same day and subject grouping that was used for their stats. Run by a
+// migration for every user with such emails.
+func BackfillEmailCampaigns(r *http.Request) ([]models.Campaign, error) {
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Campaign{}, err
+	}
+
+	emails := []models.Email{}
+	err = db.DB.Model(&emails).Where("created_by = ?", currentUser.Id).Where("campaign_id = ?", 0).Where("is_sent = ?", true).Order("created ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Campaign{}, err
+	}
+
+	emailsByKey := map[string][]models.Email{}
+	keys := []string{}
+	for i := 0; i < len(emails); i++ {
+		key := GetEmailCampaignKey(emails[i])
+		if _, ok := emailsByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		emailsByKey[key] = append(emailsByKey[key], emails[i])
+	}
+
+	campaigns := []models.Campaign{}
+	for i := 0; i < len(keys); i++ {
+		campaignEmails := emailsByKey[keys[i]]
+		err = assignCampaignToEmails(r, currentUser, campaignEmails)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+
+		emailIds := []int64{}
+		for x := 0; x < len(campaignEmails); x++ {
+			campaignEmails[x].Save()
+			emailIds = append(emailIds, campaignEmails[x].Id)
+		}
+
+		campaign, err := getCampaignUnauthorized(campaignEmails[0].CampaignId)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+
+		// Use the date the emails went out rather than when the backfill ran
+		campaign.Created = campaignEmails[0].Created
+		campaign.MarkSent(campaignEmails[0].SendAt)
+		campaigns = append(campaigns, campaign)
+
+		sync.EmailResourceBulkSync(r, emailIds)
+	}
+
+	return campaigns, nil
+}
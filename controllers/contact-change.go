@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+type revertContactChangeDetails struct {
+	ChangeId int64 `json:"changeid"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Gets the changes made to a contact, newest first. ?field= only gets the
+// changes to one field.
+func GetContactHistory(r *http.Request, id string) ([]models.ContactChange, interface{}, int, int, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.ContactChange{}, nil, 0, 0, err
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	changes := []models.ContactChange{}
+	query := db.DB.Model(&changes).Where("contact_id = ?", contact.Id)
+	if field := r.URL.Query().Get("field"); field != "" {
+		query = query.Where("field = ?", field)
+	}
+
+	total, err := query.Order("created DESC", "id DESC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.ContactChange{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(changes); i++ {
+		changes[i].Type = "contactchanges"
+	}
+
+	return changes, nil, len(changes), total, nil
+}
+
+/*
+* Action methods
+ */
+
+// Puts a field back to what it was before a change. The revert is recorded
+// as a change of its own.
+func RevertContactChange(r *http.Request, id string) (models.Contact, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var revertDetails revertContactChangeDetails
+	err := decoder.Decode(buf, &revertDetails)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.ReadOnly {
+		return models.Contact{}, nil, errors.New("Forbidden")
+	}
+
+	change := models.ContactChange{}
+	err = db.DB.Model(&change).Where("id = ?", revertDetails.ChangeId).Where("contact_id = ?", contact.Id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, errors.New("No change by this id for this contact")
+	}
+
+	if contact.ParentContact != 0 {
+		for _, field := range models.MasterContactFields {
+			if field == change.Field {
+				return models.Contact{}, nil, errors.New("This field comes from the master contact")
+			}
+		}
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	err = contact.SetChangeValue(change.Field, change.OldValue)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	_, err = contact.RevertWithHistory(r, user.Id, change.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.IsMasterContact {
+		err = contact.PropagateToChildren(user.Id)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	return contact, nil, nil
+}
@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+type masterContactDetails struct {
+	Parent int64 `json:"parent"`
+}
+
+/*
+* Private methods
+ */
+
+// Which master fields an update would change. Blank values leave a field
+// alone so they don't count.
+func masterFieldsChanged(contact models.Contact, updatedContact models.Contact) []string {
+	updatedContact.Normalize()
+
+	changed := []string{}
+	stringFields := []struct {
+		name             string
+		current, updated string
+	}{
+		{"firstname", contact.FirstName, updatedContact.FirstName},
+		{"lastname", contact.LastName, updatedContact.LastName},
+		{"email", contact.Email, updatedContact.Email},
+		{"linkedin", contact.LinkedIn, updatedContact.LinkedIn},
+		{"twitter", contact.Twitter, updatedContact.Twitter},
+		{"instagram", contact.Instagram, updatedContact.Instagram},
+		{"website", contact.Website, updatedContact.Website},
+		{"blog", contact.Blog, updatedContact.Blog},
+		{"location", contact.Location, updatedContact.Location},
+		{"phonenumber", contact.PhoneNumber, updatedContact.PhoneNumber},
+		{"imageurl", contact.ImageURL, updatedContact.ImageURL},
+	}
+	for _, field := range stringFields {
+		if field.updated != "" && field.updated != field.current {
+			changed = append(changed, field.name)
+		}
+	}
+
+	if updatedContact.Employers != nil && !reflect.DeepEqual(updatedContact.Employers, contact.Employers) {
+		changed = append(changed, "employers")
+	}
+	if updatedContact.PastEmployers != nil && !reflect.DeepEqual(updatedContact.PastEmployers, contact.PastEmployers) {
+		changed = append(changed, "pastemployers")
+	}
+
+	return changed
+}
+
+// Marks the fields children get from their master
+func setMasterFields(contacts []models.Contact) {
+	for i := 0; i < len(contacts); i++ {
+		if contacts[i].ParentContact != 0 {
+			contacts[i].MasterFields = models.MasterContactFields
+		}
+	}
+}
+
+// Finds the master contact for the same person by email, then by Twitter.
+// Only masters made by the user or their team are looked at, and the ones
+// made by someone else can be linked to but not changed.
+func findMasterContact(r *http.Request, contact models.Contact) (models.Contact, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, err
+	}
+
+	lookups := []struct {
+		column, value string
+	}{
+		{"email", contact.Email},
+		{"twitter", contact.Twitter},
+	}
+
+	for _, lookup := range lookups {
+		if lookup.value == "" {
+			continue
+		}
+
+		masters := []models.Contact{}
+		query := db.DB.Model(&masters).Where("is_master_contact = ?", true).Where("is_deleted = ?", false).Where(lookup.column+" = ?", lookup.value)
+		if user.Data.TeamId != 0 {
+			query = query.Where("created_by = ? OR team_id = ?", user.Id, user.Data.TeamId)
+		} else {
+			query = query.Where("created_by = ?", user.Id)
+		}
+
+		err = query.Order("id ASC").Limit(1).Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Contact{}, err
+		}
+
+		if len(masters) > 0 {
+			masters[0].Type = "contacts"
+			masters[0].ReadOnly = masters[0].CreatedBy != user.Id && !user.Data.IsAdmin
+			return masters[0], nil
+		}
+	}
+
+	return models.Contact{}, errors.New("No master contact for this contact")
+}
+
+// Fills in what the master doesn't know yet from one of its children, then
+// makes the child show the master's fields. Masters the user can't change
+// are linked to as they are.
+func linkMasterContact(r *http.Request, contact *models.Contact, master *models.Contact, actorId int64) error {
+	changed := false
+	for _, field := range []struct {
+		master *string
+		child  string
+	}{
+		{&master.FirstName, contact.FirstName},
+		{&master.LastName, contact.LastName},
+		{&master.Email, contact.Email},
+		{&master.LinkedIn, contact.LinkedIn},
+		{&master.Twitter, contact.Twitter},
+		{&master.Instagram, contact.Instagram},
+		{&master.Website, contact.Website},
+		{&master.Blog, contact.Blog},
+		{&master.Location, contact.Location},
+		{&master.PhoneNumber, contact.PhoneNumber},
+		{&master.ImageURL, contact.ImageURL},
+	} {
+		if *field.master == "" && field.child != "" {
+			*field.master = field.child
+			changed = true
+		}
+	}
+	if len(master.Employers) == 0 && len(contact.Employers) > 0 {
+		master.Employers = contact.Employers
+		changed = true
+	}
+	if len(master.PastEmployers) == 0 && len(contact.PastEmployers) > 0 {
+		master.PastEmployers = contact.PastEmployers
+		changed = true
+	}
+
+	if changed && !master.ReadOnly {
+		_, err := master.SaveWithHistory(r, actorId, models.ContactChangeSourceMaster)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		err = master.PropagateToChildren(actorId)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+	}
+
+	contact.ParentContact = master.Id
+	contact.CopyMasterFields(*master)
+	_, err := contact.SaveWithHistory(r, actorId, models.ContactChangeSourceMaster)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	contact.MasterFields = models.MasterContactFields
+	return nil
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Gets the master of a contact, or the contact itself if it is a master
+func GetMasterForContact(r *http.Request, id string) (models.Contact, interface{}, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.IsMasterContact {
+		return contact, nil, nil
+	}
+
+	if contact.ParentContact == 0 {
+		return models.Contact{}, nil, errors.New("Contact has no master contact")
+	}
+
+	master, err := getContact(r, contact.ParentContact)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	return master, nil, nil
+}
+
+// Gets the children of a master contact the user can see
+func GetChildContacts(r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
+	master, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	if !master.IsMasterContact {
+		return []models.Contact{}, nil, 0, 0, errors.New("Contact is not a master contact")
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	contacts := []models.Contact{}
+	query := db.DB.Model(&contacts).Where("parent_contact = ?", master.Id).Where("is_deleted = ?", false)
+	if !user.Data.IsAdmin {
+		query = query.Where("created_by = ?", user.Id)
+	}
+
+	err = query.Order("id ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		contacts[i].Type = "contacts"
+	}
+	setMasterFields(contacts)
+
+	return contacts, nil, len(contacts), 0, nil
+}
+
+/*
+* Action methods
+ */
+
+// Links a contact to the master contact for the same person, making one from
+// the contact if there is none yet. A master can also be given as "parent".
+func CreateMasterForContact(r *http.Request, id string) (models.Contact, interface{}, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.ReadOnly {
+		return models.Contact{}, nil, errors.New("Forbidden")
+	}
+
+	if contact.IsMasterContact {
+		return models.Contact{}, nil, errors.New("Contact is a master contact")
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	var masterDetails masterContactDetails
+	buf, _ := ioutil.ReadAll(r.Body)
+	if len(buf) > 0 {
+		decoder := ffjson.NewDecoder()
+		err = decoder.Decode(buf, &masterDetails)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Contact{}, nil, err
+		}
+	}
+
+	var master models.Contact
+	switch {
+	case masterDetails.Parent != 0:
+		master, err = getContact(r, masterDetails.Parent)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Contact{}, nil, err
+		}
+		if !master.IsMasterContact {
+			return models.Contact{}, nil, errors.New("Parent is not a master contact")
+		}
+	case contact.ParentContact != 0:
+		return contact, nil, nil
+	default:
+		master, err = findMasterContact(r, contact)
+		if err != nil {
+			// Masters don't belong to a list, they are shared with the team
+			// of the user that made them
+			master = models.Contact{}
+			master.CopyMasterFields(contact)
+			master.IsMasterContact = true
+			master.TeamId = currentUser.Data.TeamId
+			_, err = master.Create(r, currentUser)
+			if err != nil {
+				log.Printf("%v", err)
+				return models.Contact{}, nil, err
+			}
+		}
+	}
+
+	err = linkMasterContact(r, &contact, &master, currentUser.Id)
+	if err != nil {
+		return models.Contact{}, nil, err
+	}
+
+	return contact, master, nil
+}
+
+// Stops a contact following its master. It keeps the master's fields as its
+// own.
+func UnlinkMasterContact(r *http.Request, id string) (models.Contact, interface{}, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.ReadOnly {
+		return models.Contact{}, nil, errors.New("Forbidden")
+	}
+
+	if contact.ParentContact == 0 {
+		return models.Contact{}, nil, errors.New("Contact has no master contact")
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	contact.ParentContact = 0
+	contact.MasterFields = nil
+	_, err = contact.SaveWithHistory(r, user.Id, models.ContactChangeSourceManual)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	return contact, nil, nil
+}
@@ -0,0 +1,705 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pg/pg"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// Contacts that look like the same person. Contacts are linked by what they
+// share, so a group can hold contacts that only match through another one.
+type DuplicateContactGroup struct {
+	Contacts []int64  `json:"contacts"`
+	Reasons  []string `json:"reasons"`
+
+	// Of the weakest match holding the group together, between 0 and 1
+	Confidence float64 `json:"confidence"`
+}
+
+// A contact that looks like the same person as the one asked about
+type DuplicateContact struct {
+	ContactId  int64    `json:"contactid"`
+	Reasons    []string `json:"reasons"`
+	Confidence float64  `json:"confidence"`
+}
+
+type mergeContactsDetails struct {
+	Contacts []int64 `json:"contacts"`
+}
+
+type duplicateLink struct {
+	a, b       int
+	reason     string
+	confidence float64
+}
+
+// How sure a match on each of these is
+var duplicateReasonConfidence = map[string]float64{
+	"email":     1,
+	"twitter":   0.95,
+	"linkedin":  0.95,
+	"instagram": 0.9,
+	"name":      0.6,
+}
+
+/*
+* Private methods
+ */
+
+/*
+* Normalization methods
+ */
+
+// LinkedIn URLs are stored with and without the scheme, www and a trailing
+// slash
+func normalizeLinkedIn(linkedIn string) string {
+	linkedIn = strings.ToLower(strings.TrimSpace(linkedIn))
+	linkedIn = strings.TrimPrefix(linkedIn, "https://")
+	linkedIn = strings.TrimPrefix(linkedIn, "http://")
+	linkedIn = strings.TrimPrefix(linkedIn, "www.")
+	return strings.TrimRight(linkedIn, "/")
+}
+
+func normalizeHandle(handle string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(handle)), "@")
+}
+
+// Lower case letters of each part of a name, without punctuation
+func normalizeNameParts(name string) []string {
+	parts := strings.FieldsFunc(strings.ToLower(name), func(c rune) bool {
+		return !unicode.IsLetter(c)
+	})
+	return parts
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := 0; j <= len(rb); j++ {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Names match if the last names are the same and the first names are the
+// same, one is the initial of the other or they are one typo apart
+func similarNames(a, b models.Contact) bool {
+	firstA, lastA := normalizeNameParts(a.FirstName), normalizeNameParts(a.LastName)
+	firstB, lastB := normalizeNameParts(b.FirstName), normalizeNameParts(b.LastName)
+	if len(firstA) == 0 || len(lastA) == 0 || len(firstB) == 0 || len(lastB) == 0 {
+		return false
+	}
+
+	if strings.Join(lastA, " ") != strings.Join(lastB, " ") {
+		return false
+	}
+
+	nameA, nameB := firstA[0], firstB[0]
+	switch {
+	case nameA == nameB:
+		return true
+	case len(nameA) == 1 || len(nameB) == 1:
+		return nameA[0] == nameB[0]
+	case len(nameA) >= 4 && len(nameB) >= 4:
+		return levenshtein(nameA, nameB) <= 1
+	}
+	return false
+}
+
+// Finds which contacts look like the same person. Exact matches are found
+// by key, names are only compared between contacts at the same publication.
+func findDuplicateLinks(contacts []models.Contact) []duplicateLink {
+	links := []duplicateLink{}
+	linked := map[[2]int]bool{}
+	addLink := func(a, b int, reason string) {
+		if a > b {
+			a, b = b, a
+		}
+		key := [2]int{a, b}
+		if a == b || linked[key] {
+			return
+		}
+		linked[key] = true
+		links = append(links, duplicateLink{a, b, reason, duplicateReasonConfidence[reason]})
+	}
+
+	// Strongest reasons first so a pair is linked by the best one
+	keys := []struct {
+		reason string
+		key    func(models.Contact) string
+	}{
+		{"email", func(c models.Contact) string { return strings.ToLower(strings.TrimSpace(c.Email)) }},
+		{"twitter", func(c models.Contact) string { return normalizeHandle(c.Twitter) }},
+		{"linkedin", func(c models.Contact) string { return normalizeLinkedIn(c.LinkedIn) }},
+		{"instagram", func(c models.Contact) string { return normalizeHandle(c.Instagram) }},
+	}
+
+	for _, k := range keys {
+		first := map[string]int{}
+		for i := 0; i < len(contacts); i++ {
+			value := k.key(contacts[i])
+			if value == "" {
+				continue
+			}
+			if x, ok := first[value]; ok {
+				addLink(x, i, k.reason)
+			} else {
+				first[value] = i
+			}
+		}
+	}
+
+	// Names only match with the same last name, so only contacts at the
+	// same publication with the same last name are compared
+	type nameBucket struct {
+		publicationId int64
+		lastName      string
+	}
+	byName := map[nameBucket][]int{}
+	for i := 0; i < len(contacts); i++ {
+		lastName := strings.Join(normalizeNameParts(contacts[i].LastName), " ")
+		if lastName == "" || len(normalizeNameParts(contacts[i].FirstName)) == 0 {
+			continue
+		}
+
+		seen := map[int64]bool{}
+		for _, id := range append(append([]int64{}, contacts[i].Employers...), contacts[i].PastEmployers...) {
+			if !seen[id] {
+				seen[id] = true
+				bucket := nameBucket{id, lastName}
+				byName[bucket] = append(byName[bucket], i)
+			}
+		}
+	}
+
+	for _, indexes := range byName {
+		for x := 0; x < len(indexes); x++ {
+			for y := x + 1; y < len(indexes); y++ {
+				if similarNames(contacts[indexes[x]], contacts[indexes[y]]) {
+					addLink(indexes[x], indexes[y], "name")
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// Contacts the current user could merge
+func getContactsForDuplicates(r *http.Request) ([]models.Contact, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+
+	contacts := []models.Contact{}
+	err = db.DB.Model(&contacts).Where("created_by = ?", user.Id).Where("is_deleted = ?", false).Order("id ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		contacts[i].Type = "contacts"
+	}
+
+	return contacts, nil
+}
+
+func appendUniqueIds(ids []int64, add ...int64) []int64 {
+	exists := map[int64]bool{}
+	for i := 0; i < len(ids); i++ {
+		exists[ids[i]] = true
+	}
+	for _, id := range add {
+		if !exists[id] {
+			exists[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Fills in the primary contact with what the duplicate has that it doesn't.
+// Anything the primary already has is kept.
+func mergeContactFields(primary *models.Contact, duplicate models.Contact) {
+	if primary.FirstName == "" && primary.LastName == "" {
+		primary.FirstName = duplicate.FirstName
+		primary.LastName = duplicate.LastName
+	}
+
+	if primary.Email == "" && duplicate.Email != "" {
+		primary.Email = duplicate.Email
+		primary.EmailBounced = duplicate.EmailBounced
+	}
+	if primary.Twitter == "" && duplicate.Twitter != "" {
+		primary.Twitter = duplicate.Twitter
+		primary.TwitterInvalid = duplicate.TwitterInvalid
+		primary.TwitterPrivate = duplicate.TwitterPrivate
+	}
+	if primary.Instagram == "" && duplicate.Instagram != "" {
+		primary.Instagram = duplicate.Instagram
+		primary.InstagramInvalid = duplicate.InstagramInvalid
+		primary.InstagramPrivate = duplicate.InstagramPrivate
+	}
+
+	fillIfBlank(&primary.LinkedIn, duplicate.LinkedIn)
+	fillIfBlank(&primary.MuckRack, duplicate.MuckRack)
+	fillIfBlank(&primary.Website, duplicate.Website)
+	fillIfBlank(&primary.Blog, duplicate.Blog)
+	fillIfBlank(&primary.Location, duplicate.Location)
+	fillIfBlank(&primary.PhoneNumber, duplicate.PhoneNumber)
+	fillIfBlank(&primary.ImageURL, duplicate.ImageURL)
+
+	notes := strings.TrimSpace(duplicate.Notes)
+	if notes != "" && !strings.Contains(primary.Notes, notes) {
+		if strings.TrimSpace(primary.Notes) == "" {
+			primary.Notes = notes
+		} else {
+			primary.Notes = primary.Notes + "\n\n" + notes
+		}
+	}
+
+	tags := map[string]bool{}
+	for i := 0; i < len(primary.Tags); i++ {
+		tags[strings.ToLower(primary.Tags[i])] = true
+	}
+	for i := 0; i < len(duplicate.Tags); i++ {
+		if !tags[strings.ToLower(duplicate.Tags[i])] {
+			tags[strings.ToLower(duplicate.Tags[i])] = true
+			primary.Tags = append(primary.Tags, duplicate.Tags[i])
+		}
+	}
+
+	primary.Beats = appendUniqueIds(primary.Beats, duplicate.Beats...)
+
+	// A publication is only a past employer if neither has it as current
+	primary.Employers = appendUniqueIds(primary.Employers, duplicate.Employers...)
+	current := map[int64]bool{}
+	for i := 0; i < len(primary.Employers); i++ {
+		current[primary.Employers[i]] = true
+	}
+	pastEmployers := []int64{}
+	for _, id := range appendUniqueIds(primary.PastEmployers, duplicate.PastEmployers...) {
+		if !current[id] {
+			pastEmployers = append(pastEmployers, id)
+		}
+	}
+	primary.PastEmployers = pastEmployers
+
+	customFields := map[string]int{}
+	for i := 0; i < len(primary.CustomFields); i++ {
+		if _, ok := customFields[primary.CustomFields[i].Name]; !ok {
+			customFields[primary.CustomFields[i].Name] = i
+		}
+	}
+	for _, customField := range duplicate.CustomFields {
+		x, found := customFields[customField.Name]
+		if !found {
+			customFields[customField.Name] = len(primary.CustomFields)
+			primary.CustomFields = append(primary.CustomFields, customField)
+			continue
+		}
+		if primary.CustomFields[x].Value == "" {
+			primary.CustomFields[x].Value = customField.Value
+		}
+	}
+
+	primary.IsMasterContact = primary.IsMasterContact || duplicate.IsMasterContact
+	if primary.ParentContact == 0 && duplicate.ParentContact != primary.Id {
+		primary.ParentContact = duplicate.ParentContact
+	}
+
+	if duplicate.LastContacted.After(primary.LastContacted) {
+		primary.LastContacted = duplicate.LastContacted
+	}
+	if duplicate.LastEngaged.After(primary.LastEngaged) {
+		primary.LastEngaged = duplicate.LastEngaged
+	}
+	if duplicate.LinkedInUpdated.After(primary.LinkedInUpdated) {
+		primary.LinkedInUpdated = duplicate.LinkedInUpdated
+	}
+	if duplicate.EngagementScore > primary.EngagementScore {
+		primary.EngagementScore = duplicate.EngagementScore
+		primary.EngagementUpdated = duplicate.EngagementUpdated
+	}
+}
+
+func fillIfBlank(field *string, value string) {
+	if strings.TrimSpace(*field) == "" && strings.TrimSpace(value) != "" {
+		*field = value
+	}
+}
+
+// Swaps the duplicates in a list for the primary contact, keeping the place
+// of the first one and dropping the rest
+func replaceListContacts(contacts []int64, primaryId int64, duplicates map[int64]bool) ([]int64, bool) {
+	changed := false
+	hasPrimary := false
+	replaced := []int64{}
+	for i := 0; i < len(contacts); i++ {
+		id := contacts[i]
+		if duplicates[id] {
+			changed = true
+			id = primaryId
+		}
+		if id == primaryId {
+			if hasPrimary {
+				continue
+			}
+			hasPrimary = true
+		}
+		replaced = append(replaced, id)
+	}
+	return replaced, changed
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Groups the contacts of the user that look like the same person
+func GetDuplicateContacts(r *http.Request) ([]DuplicateContactGroup, interface{}, int, int, error) {
+	contacts, err := getContactsForDuplicates(r)
+	if err != nil {
+		return []DuplicateContactGroup{}, nil, 0, 0, err
+	}
+
+	links := findDuplicateLinks(contacts)
+
+	parent := make([]int, len(contacts))
+	for i := 0; i < len(parent); i++ {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	confidence := map[int]float64{}
+	reasons := map[int][]string{}
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].confidence > links[j].confidence
+	})
+	for _, link := range links {
+		rootA, rootB := find(link.a), find(link.b)
+		if rootA == rootB {
+			continue
+		}
+
+		parent[rootB] = rootA
+		groupConfidence := link.confidence
+		for _, root := range []int{rootA, rootB} {
+			if c, ok := confidence[root]; ok && c < groupConfidence {
+				groupConfidence = c
+			}
+		}
+		confidence[rootA] = groupConfidence
+
+		groupReasons := append(reasons[rootA], reasons[rootB]...)
+		found := false
+		for i := 0; i < len(groupReasons); i++ {
+			if groupReasons[i] == link.reason {
+				found = true
+			}
+		}
+		if !found {
+			groupReasons = append(groupReasons, link.reason)
+		}
+		reasons[rootA] = groupReasons
+		delete(reasons, rootB)
+		delete(confidence, rootB)
+	}
+
+	groupIndex := map[int]int{}
+	groups := []DuplicateContactGroup{}
+	included := []interface{}{}
+	for i := 0; i < len(contacts); i++ {
+		root := find(i)
+		if _, ok := confidence[root]; !ok {
+			continue
+		}
+
+		if _, ok := groupIndex[root]; !ok {
+			groupIndex[root] = len(groups)
+			groups = append(groups, DuplicateContactGroup{
+				Contacts:   []int64{},
+				Reasons:    reasons[root],
+				Confidence: confidence[root],
+			})
+		}
+		groups[groupIndex[root]].Contacts = append(groups[groupIndex[root]].Contacts, contacts[i].Id)
+		included = append(included, contacts[i])
+	}
+
+	return groups, included, len(groups), 0, nil
+}
+
+// Contacts of the user that look like the same person as this one
+func GetDuplicatesForContact(r *http.Request, id string) ([]DuplicateContact, interface{}, int, int, error) {
+	contactId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []DuplicateContact{}, nil, 0, 0, err
+	}
+
+	contact, err := getContact(r, contactId)
+	if err != nil {
+		log.Printf("%v", err)
+		return []DuplicateContact{}, nil, 0, 0, err
+	}
+
+	contacts, err := getContactsForDuplicates(r)
+	if err != nil {
+		return []DuplicateContact{}, nil, 0, 0, err
+	}
+
+	// The contact might belong to someone else on the team
+	index := -1
+	for i := 0; i < len(contacts); i++ {
+		if contacts[i].Id == contact.Id {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		index = len(contacts)
+		contacts = append(contacts, contact)
+	}
+
+	duplicates := []DuplicateContact{}
+	included := []interface{}{}
+	for _, link := range findDuplicateLinks(contacts) {
+		other := -1
+		if link.a == index {
+			other = link.b
+		} else if link.b == index {
+			other = link.a
+		}
+		if other == -1 {
+			continue
+		}
+
+		duplicates = append(duplicates, DuplicateContact{
+			ContactId:  contacts[other].Id,
+			Reasons:    []string{link.reason},
+			Confidence: link.confidence,
+		})
+		included = append(included, contacts[other])
+	}
+
+	return duplicates, included, len(duplicates), 0, nil
+}
+
+/*
+* Action methods
+ */
+
+// Merges the contacts in the body into this one. Their fields fill in what
+// this contact is missing, and their lists, emails, feeds and child contacts
+// move over to it. The duplicates are deleted and point to this contact.
+func MergeContacts(r *http.Request, id string) (models.Contact, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var mergeDetails mergeContactsDetails
+	err := decoder.Decode(buf, &mergeDetails)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	contactId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	primary, err := getContact(r, contactId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if primary.ReadOnly || primary.IsDeleted {
+		return models.Contact{}, nil, errors.New("Forbidden")
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	duplicateIds := []int64{}
+	duplicateIdMap := map[int64]bool{}
+	listIds := []int64{}
+	if primary.ListId != 0 {
+		listIds = append(listIds, primary.ListId)
+	}
+
+	for _, duplicateId := range mergeDetails.Contacts {
+		if duplicateId == primary.Id || duplicateIdMap[duplicateId] {
+			continue
+		}
+
+		duplicate, err := getContact(r, duplicateId)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Contact{}, nil, err
+		}
+
+		if duplicate.ReadOnly || duplicate.IsDeleted {
+			return models.Contact{}, nil, errors.New("Contact can not be merged")
+		}
+
+		mergeContactFields(&primary, duplicate)
+		duplicateIds = append(duplicateIds, duplicate.Id)
+		duplicateIdMap[duplicate.Id] = true
+		if duplicate.ListId != 0 {
+			listIds = appendUniqueIds(listIds, duplicate.ListId)
+		}
+	}
+
+	if len(duplicateIds) == 0 {
+		return models.Contact{}, nil, errors.New("No contacts to merge")
+	}
+
+	// The primary can't end up as its own child
+	if duplicateIdMap[primary.ParentContact] {
+		primary.ParentContact = 0
+	}
+
+	now := time.Now()
+	err = db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		// Contacts can be on lists other than the one they were made for
+		mediaLists := []models.MediaList{}
+		query := tx.Model(&mediaLists).Column("id", "contacts")
+		if user.Data.TeamId != 0 {
+			query = query.Where("created_by = ? OR team_id = ? OR id IN (?)", user.Id, user.Data.TeamId, pg.In(listIds))
+		} else {
+			query = query.Where("created_by = ? OR id IN (?)", user.Id, pg.In(listIds))
+		}
+		err := query.For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(mediaLists); i++ {
+			contacts, changed := replaceListContacts(mediaLists[i].Contacts, primary.Id, duplicateIdMap)
+			if !changed {
+				continue
+			}
+
+			mediaLists[i].Contacts = contacts
+			mediaLists[i].Updated = now
+			_, err = tx.Model(&mediaLists[i]).Column("contacts", "updated").Update()
+			if err != nil {
+				return err
+			}
+		}
+
+		// Email history and everything else about the duplicates
+		repoint := []interface{}{&models.Email{}, &models.EmailEvent{}, &models.Feed{}, &models.Headline{}, &models.HeadlineAttribution{}, &models.ContactUnsubscribe{}, &models.Employment{}}
+		for _, model := range repoint {
+			_, err = tx.Model(model).Set("contact_id = ?", primary.Id).Where("contact_id IN (?)", pg.In(duplicateIds)).Update()
+			if err != nil {
+				return err
+			}
+		}
+
+		children := []models.Contact{}
+		err = tx.Model(&children).Where("parent_contact IN (?)", pg.In(duplicateIds)).Where("id != ?", primary.Id).Where("id NOT IN (?)", pg.In(duplicateIds)).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(children); i++ {
+			children[i].ParentContact = primary.Id
+			_, err = children[i].SaveWithHistoryTx(tx, user.Id, models.ContactChangeSourceMerge)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Model(&models.Contact{}).Set("is_deleted = ?", true).Set("merged_into = ?", primary.Id).Set("updated = ?", now).Where("id IN (?)", pg.In(duplicateIds)).Update()
+		if err != nil {
+			return err
+		}
+
+		_, err = primary.SaveWithHistoryTx(tx, user.Id, models.ContactChangeSourceMerge)
+		if err != nil {
+			return err
+		}
+
+		changes := []models.ContactChange{}
+		for _, duplicateId := range duplicateIds {
+			changes = append(changes, models.ContactChange{
+				ContactId: duplicateId,
+				Field:     "isdeleted",
+				OldValue:  "false",
+				NewValue:  "true",
+				ActorId:   user.Id,
+				Source:    models.ContactChangeSourceMerge,
+			})
+			changes[len(changes)-1].CreatedBy = user.Id
+			changes[len(changes)-1].Created = now
+		}
+		_, err = tx.Model(&changes).Insert()
+		return err
+	})
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	// Children of the duplicates now follow the primary
+	if primary.IsMasterContact {
+		err = primary.PropagateToChildren(user.Id)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	return primary, nil, nil
+}
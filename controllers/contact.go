@@ -1,27 +1,29 @@
 package controllers
 
-// import (
-// 	"errors"
-// 	"io/ioutil"
-// 	"log"
-// 	"net/http"
-// 	"strconv"
-// 	"strings"
-// 	"time"
-
-// 	gcontext "github.com/gorilla/context"
-// 	"github.com/pquerna/ffjson/ffjson"
-
-// 	"github.com/news-ai/web/permissions"
-// 	"github.com/news-ai/web/utilities"
-
-// 	"github.com/news-ai/api-v1/controllers"
-// 	apiSearch "github.com/news-ai/api-v1/search"
-
-// 	"github.com/news-ai/tabulae-v1/models"
-// 	"github.com/news-ai/tabulae-v1/search"
-// 	"github.com/news-ai/tabulae-v1/sync"
-// )
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	gcontext "github.com/gorilla/context"
+	"github.com/pquerna/ffjson/ffjson"
+
+	// "github.com/news-ai/web/permissions"
+	"github.com/news-ai/web/utilities"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+	apiSearch "github.com/news-ai/api-v1/search"
+
+	"github.com/news-ai/tabulae-v1/models"
+	// "github.com/news-ai/tabulae-v1/search"
+	"github.com/news-ai/tabulae-v1/sync"
+)
 
 // /*
 // * Private methods
@@ -46,56 +48,64 @@ package controllers
 // * Get methods
 //  */
 
-// func getContact(r *http.Request, id int64) (models.Contact, error) {
-// 	if id == 0 {
-// 		return models.Contact{}, errors.New("datastore: no such entity")
-// 	}
-// 	// Get the Contact by id
-// 	// var contact models.Contact
-// 	// contactId := datastore.NewKey( "Contact", "", id, nil)
-// 	// err := nds.Get( contactId, &contact)
-// 	// if err != nil {
-// 	// 	log.Printf( "%v", err)
-// 	// 	return models.Contact{}, err
-// 	// }
-
-// 	// if !contact.Created.IsZero() {
-// 	// 	contact.Format(contactId, "contacts")
-
-// 	// 	user, err := controllers.GetCurrentUser( r)
-// 	// 	if err != nil {
-// 	// 		log.Printf( "%v", err)
-// 	// 		return models.Contact{}, err
-// 	// 	}
-
-// 	// 	contactList, err := getMediaList( r, contact.ListId)
-// 	// 	if err != nil {
-// 	// 		err = errors.New("Forbidden")
-// 	// 		log.Printf( "%v", err)
-// 	// 		return models.Contact{}, err
-// 	// 	}
-
-// 	// 	// If it is a public list
-// 	// 	if contact.ListId != 0 && contactList.PublicList {
-// 	// 		// You don't own it and you are not an admin
-// 	// 		if contactList.PublicList && !permissions.AccessToObject(contact.CreatedBy, user.Id) && !user.IsAdmin {
-// 	// 			contact.ReadOnly = true
-// 	// 		}
-// 	// 		return contact, nil
-// 	// 	}
-
-// 	// 	// This runs if it is not a public list
-// 	// 	if contactList.TeamId != user.Data.TeamId && !contact.IsMasterContact && !permissions.AccessToObject(contact.CreatedBy, user.Id) && !user.Data.IsAdmin {
-// 	// 		err = errors.New("Forbidden")
-// 	// 		log.Printf( "%v", err)
-// 	// 		return models.Contact{}, err
-// 	// 	}
-
-// 	// 	return contact, nil
-// 	// }
-
-// 	return models.Contact{}, errors.New("No contact by this id")
-// }
+func getContact(r *http.Request, id int64) (models.Contact, error) {
+	if id == 0 {
+		return models.Contact{}, errors.New("datastore: no such entity")
+	}
+
+	// Get the Contact by id
+	contact := models.Contact{}
+	err := db.DB.Model(&contact).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, err
+	}
+
+	if contact.Created.IsZero() {
+		return models.Contact{}, errors.New("No contact by this id")
+	}
+
+	contact.Type = "contacts"
+	if contact.ParentContact != 0 {
+		contact.MasterFields = models.MasterContactFields
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, errors.New("Could not get user")
+	}
+
+	if contact.CreatedBy == user.Id || user.Data.IsAdmin {
+		return contact, nil
+	}
+
+	// Master contacts are shared, and contacts on a list can be seen by
+	// anyone that can see the list. Only the owner can change them.
+	if contact.IsMasterContact {
+		contact.ReadOnly = true
+		return contact, nil
+	}
+
+	if contact.ListId != 0 {
+		contactList, err := getMediaList(r, contact.ListId)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Contact{}, errors.New("Forbidden")
+		}
+
+		if contactList.PublicList && contactList.CreatedBy != user.Id {
+			contact.ReadOnly = true
+		}
+		return contact, nil
+	}
+
+	if contact.TeamId == 0 || user.Data.TeamId == 0 || contact.TeamId != user.Data.TeamId {
+		return models.Contact{}, errors.New("Forbidden")
+	}
+
+	return contact, nil
+}
 
 // /*
 // * Create methods
@@ -137,150 +147,188 @@ package controllers
 // * Update methods
 //  */
 
-// func updateContact(r *http.Request, contact *models.Contact, updatedContact models.Contact) (models.Contact, interface{}, error) {
-// 	currentUser, err := controllers.GetCurrentUser(r)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return *contact, nil, err
-// 	}
-
-// 	// Check if the old Twitter is changed to a new one
-// 	// If both of them are not empty but also not the same
-// 	if contact.Twitter != "" && updatedContact.Twitter != "" && contact.Twitter != updatedContact.Twitter {
-// 		updatedContact.Normalize()
-// 		contact.TwitterPrivate = false
-// 		contact.TwitterInvalid = false
-// 		sync.TwitterSync(r, updatedContact.Twitter)
-// 	}
-
-// 	// If you are changing Instagram usernames
-// 	if contact.Instagram != "" && updatedContact.Instagram != "" && contact.Instagram != updatedContact.Instagram {
-// 		contact.InstagramPrivate = false
-// 		contact.InstagramInvalid = false
-// 		sync.InstagramSync(r, updatedContact.Instagram, currentUser.Data.InstagramAuthKey)
-// 	}
-
-// 	if contact.Twitter == "" && updatedContact.Twitter != "" {
-// 		updatedContact.Normalize()
-// 		contact.TwitterPrivate = false
-// 		contact.TwitterInvalid = false
-// 		sync.TwitterSync(r, updatedContact.Twitter)
-// 	}
-
-// 	// If they add a new Instagram
-// 	if contact.Instagram == "" && updatedContact.Instagram != "" {
-// 		updatedContact.Normalize()
-// 		contact.InstagramPrivate = false
-// 		contact.InstagramInvalid = false
-// 		sync.InstagramSync(r, updatedContact.Instagram, currentUser.Data.InstagramAuthKey)
-// 	}
-
-// 	previousEmail := contact.Email
-
-// 	utilities.UpdateIfNotBlank(&contact.FirstName, updatedContact.FirstName)
-// 	utilities.UpdateIfNotBlank(&contact.LastName, updatedContact.LastName)
-// 	utilities.UpdateIfNotBlank(&contact.Email, updatedContact.Email)
-// 	utilities.UpdateIfNotBlank(&contact.LinkedIn, updatedContact.LinkedIn)
-// 	utilities.UpdateIfNotBlank(&contact.Twitter, updatedContact.Twitter)
-// 	utilities.UpdateIfNotBlank(&contact.Instagram, updatedContact.Instagram)
-// 	utilities.UpdateIfNotBlank(&contact.Website, updatedContact.Website)
-// 	utilities.UpdateIfNotBlank(&contact.Blog, updatedContact.Blog)
-// 	utilities.UpdateIfNotBlank(&contact.Notes, updatedContact.Notes)
-// 	utilities.UpdateIfNotBlank(&contact.Location, updatedContact.Location)
-// 	utilities.UpdateIfNotBlank(&contact.PhoneNumber, updatedContact.PhoneNumber)
-
-// 	if contact.Email != previousEmail {
-// 		_, err = enrichContact(r, contact)
-// 		if err != nil {
-// 			log.Printf("%v", err)
-// 		}
-// 	}
-
-// 	if updatedContact.ListId != 0 {
-// 		contact.ListId = updatedContact.ListId
-// 	}
-
-// 	if len(updatedContact.CustomFields) > 0 {
-// 		contact.CustomFields = updatedContact.CustomFields
-// 	}
-
-// 	if len(updatedContact.Employers) > 0 {
-// 		contact.Employers = updatedContact.Employers
-// 	}
-
-// 	if len(updatedContact.PastEmployers) > 0 {
-// 		contact.PastEmployers = updatedContact.PastEmployers
-// 	}
-
-// 	if len(updatedContact.Tags) > 0 {
-// 		contact.Tags = updatedContact.Tags
-// 	}
-
-// 	// Special case when you want to remove all the employers
-// 	if len(contact.Employers) > 0 && len(updatedContact.Employers) == 0 {
-// 		contact.Employers = updatedContact.Employers
-// 	}
-
-// 	// Special case when you want to remove all the past employers
-// 	if len(contact.PastEmployers) > 0 && len(updatedContact.PastEmployers) == 0 {
-// 		contact.PastEmployers = updatedContact.PastEmployers
-// 	}
-
-// 	// Special case when you want to remove all the past employers
-// 	if len(contact.Tags) > 0 && len(updatedContact.Tags) == 0 {
-// 		contact.Tags = updatedContact.Tags
-// 	}
-
-// 	_, err = Save(r, contact)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, err
-// 	}
-
-// 	// When editing a contact on the list view we need the timeseries data in it
-// 	if contact.ListId == 0 {
-// 		return *contact, nil, nil
-// 	}
-
-// 	mediaList, err := getMediaList(r, contact.ListId)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return *contact, nil, nil
-// 	}
-
-// 	contacts, err := ContactsToDefaultFields(r, []models.Contact{*contact}, mediaList)
-
-// 	return contacts[0], nil, nil
-// }
-
-// /*
-// * Filter methods
-//  */
-
-// func filterContacts(r *http.Request, queryType, query string) ([]models.Contact, error) {
-// 	// Get an contact by a query type
-// 	ks, err := datastore.NewQuery("Contact").Filter(queryType+" =", query).KeysOnly().GetAll(nil)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []models.Contact{}, err
-// 	}
-
-// 	var contacts []models.Contact
-// 	contacts = make([]models.Contact, len(ks))
-// 	err = nds.GetMulti(ks, contacts)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []models.Contact{}, err
-// 	}
-
-// 	if len(contacts) > 0 {
-// 		for i := 0; i < len(contacts); i++ {
-// 			contacts[i].Type = "contacts"
-// 		}
-// 		return contacts, nil
-// 	}
-// 	return []models.Contact{}, errors.New("No contact by this " + queryType)
-// }
+func updateContact(r *http.Request, contact *models.Contact, updatedContact models.Contact) (models.Contact, interface{}, error) {
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return *contact, nil, err
+	}
+
+	// Children show the fields of their master, which can only be changed on
+	// the master itself
+	if contact.ParentContact != 0 {
+		changed := masterFieldsChanged(*contact, updatedContact)
+		if len(changed) > 0 {
+			return *contact, nil, errors.New("These fields come from the master contact: " + strings.Join(changed, ", "))
+		}
+	}
+
+	// Check if the old Twitter is changed to a new one
+	// If both of them are not empty but also not the same
+	if contact.Twitter != "" && updatedContact.Twitter != "" && contact.Twitter != updatedContact.Twitter {
+		updatedContact.Normalize()
+		contact.TwitterPrivate = false
+		contact.TwitterInvalid = false
+		sync.TwitterSync(r, updatedContact.Twitter)
+	}
+
+	// If you are changing Instagram usernames
+	if contact.Instagram != "" && updatedContact.Instagram != "" && contact.Instagram != updatedContact.Instagram {
+		contact.InstagramPrivate = false
+		contact.InstagramInvalid = false
+		sync.InstagramSync(r, updatedContact.Instagram, currentUser.Data.InstagramAuthKey)
+	}
+
+	if contact.Twitter == "" && updatedContact.Twitter != "" {
+		updatedContact.Normalize()
+		contact.TwitterPrivate = false
+		contact.TwitterInvalid = false
+		sync.TwitterSync(r, updatedContact.Twitter)
+	}
+
+	// If they add a new Instagram
+	if contact.Instagram == "" && updatedContact.Instagram != "" {
+		updatedContact.Normalize()
+		contact.InstagramPrivate = false
+		contact.InstagramInvalid = false
+		sync.InstagramSync(r, updatedContact.Instagram, currentUser.Data.InstagramAuthKey)
+	}
+
+	// A new address has to be verified again
+	if updatedContact.Email != "" && updatedContact.Email != contact.Email {
+		contact.EmailVerification = ""
+		contact.EmailVerificationReason = ""
+		contact.EmailVerified = time.Time{}
+	}
+
+	// Feeds are looked for on sites that are new to the contact
+	sitesChanged := (updatedContact.Website != "" && updatedContact.Website != contact.Website) || (updatedContact.Blog != "" && updatedContact.Blog != contact.Blog)
+
+	utilities.UpdateIfNotBlank(&contact.FirstName, updatedContact.FirstName)
+	utilities.UpdateIfNotBlank(&contact.LastName, updatedContact.LastName)
+	utilities.UpdateIfNotBlank(&contact.Email, updatedContact.Email)
+	utilities.UpdateIfNotBlank(&contact.LinkedIn, updatedContact.LinkedIn)
+	utilities.UpdateIfNotBlank(&contact.Twitter, updatedContact.Twitter)
+	utilities.UpdateIfNotBlank(&contact.Instagram, updatedContact.Instagram)
+	utilities.UpdateIfNotBlank(&contact.Website, updatedContact.Website)
+	utilities.UpdateIfNotBlank(&contact.Blog, updatedContact.Blog)
+	utilities.UpdateIfNotBlank(&contact.Notes, updatedContact.Notes)
+	utilities.UpdateIfNotBlank(&contact.Location, updatedContact.Location)
+	utilities.UpdateIfNotBlank(&contact.PhoneNumber, updatedContact.PhoneNumber)
+	utilities.UpdateIfNotBlank(&contact.ImageURL, updatedContact.ImageURL)
+
+	if updatedContact.ListId != 0 {
+		contact.ListId = updatedContact.ListId
+	}
+
+	if len(updatedContact.CustomFields) > 0 {
+		contact.CustomFields = updatedContact.CustomFields
+	}
+
+	if len(updatedContact.Tags) > 0 {
+		contact.Tags = updatedContact.Tags
+	}
+
+	// Special case when you want to remove all the tags
+	if len(contact.Tags) > 0 && updatedContact.Tags != nil && len(updatedContact.Tags) == 0 {
+		contact.Tags = updatedContact.Tags
+	}
+
+	// An empty list takes every beat off
+	if updatedContact.Beats != nil {
+		beats, err := validateBeatIds(updatedContact.Beats)
+		if err != nil {
+			return models.Contact{}, nil, err
+		}
+		contact.Beats = beats
+	}
+
+	// Employers of a child come from its master. Jobs are kept as
+	// employments, which are opened and closed to match.
+	employersChanged := false
+	if contact.ParentContact == 0 && (updatedContact.Employers != nil || updatedContact.PastEmployers != nil) {
+		_, err = syncEmployments(*contact, models.EmploymentSourceLegacy)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+		employersChanged = true
+
+		if updatedContact.Employers != nil {
+			contact.Employers = updatedContact.Employers
+		}
+
+		if updatedContact.PastEmployers != nil {
+			contact.PastEmployers = updatedContact.PastEmployers
+		}
+	}
+
+	_, err = contact.SaveWithHistory(r, currentUser.Id, models.ContactChangeSourceManual)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+	sync.ResourceSync(r, contact.Id, "Contact", "create")
+
+	if employersChanged {
+		_, err = syncEmployments(*contact, models.EmploymentSourceManual)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	if contact.IsMasterContact {
+		err = contact.PropagateToChildren(currentUser.Id)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	if sitesChanged {
+		discoverContactFeedsLater(currentUser.Id, *contact)
+	}
+
+	contact.Type = "contacts"
+
+	// When editing a contact on the list view we need the timeseries data in it
+	if contact.ListId == 0 {
+		return *contact, nil, nil
+	}
+
+	mediaList, err := getMediaList(r, contact.ListId)
+	if err != nil {
+		log.Printf("%v", err)
+		return *contact, nil, nil
+	}
+
+	contacts, err := ContactsToDefaultFields(r, []models.Contact{*contact}, mediaList)
+	if err != nil || len(contacts) == 0 {
+		return *contact, nil, nil
+	}
+
+	return contacts[0], nil, nil
+}
+
+/*
+* Filter methods
+ */
+
+func filterContacts(r *http.Request, queryType, query string) ([]models.Contact, error) {
+	// Get contacts by a query type
+	contacts := []models.Contact{}
+	err := db.DB.Model(&contacts).Where(queryType+" = ?", query).Where("is_deleted = ?", false).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+
+	if len(contacts) > 0 {
+		for i := 0; i < len(contacts); i++ {
+			contacts[i].Type = "contacts"
+		}
+		return contacts, nil
+	}
+	return []models.Contact{}, errors.New("No contact by this " + queryType)
+}
 
 // func filterContact(r *http.Request, queryType, query string) (models.Contact, error) {
 // 	// Get an contact by a query type
@@ -369,31 +417,24 @@ package controllers
 // 	return []models.MediaList{}, errors.New("No media lists for this email")
 // }
 
-// func filterContactsForListId(r *http.Request, listId int64) ([]models.Contact, error) {
-// 	// Get an contact by a query type
-// 	ks, err := datastore.NewQuery("Contact").Filter("ListId =", listId).Filter("IsDeleted =", false).KeysOnly().GetAll(nil)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []models.Contact{}, err
-// 	}
-
-// 	var contacts []models.Contact
-// 	contacts = make([]models.Contact, len(ks))
-// 	err = nds.GetMulti(ks, contacts)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []models.Contact{}, err
-// 	}
-
-// 	if len(contacts) > 0 {
-// 		for i := 0; i < len(contacts); i++ {
-// 			contacts[i].Type = "contacts"
-// 		}
-// 		return contacts, nil
-// 	}
-
-// 	return []models.Contact{}, errors.New("No contact by this ListId")
-// }
+func filterContactsForListId(r *http.Request, listId int64) ([]models.Contact, error) {
+	// Get an contact by a query type
+	contacts := []models.Contact{}
+	err := db.DB.Model(&contacts).Where("list_id = ?", listId).Where("is_deleted = ?", false).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+
+	if len(contacts) > 0 {
+		for i := 0; i < len(contacts); i++ {
+			contacts[i].Type = "contacts"
+		}
+		return contacts, nil
+	}
+
+	return []models.Contact{}, errors.New("No contact by this ListId")
+}
 
 // /*
 // * Normalization methods
@@ -460,42 +501,40 @@ package controllers
 // 	return fitleredContacts, nil
 // }
 
-// func contactsToPublications(contacts []models.Contact) []models.Publication {
-// 	publicationIds := []int64{}
-
-// 	for i := 0; i < len(contacts); i++ {
-// 		publicationIds = append(publicationIds, contacts[i].Employers...)
-// 		publicationIds = append(publicationIds, contacts[i].PastEmployers...)
-// 	}
-
-// 	// Work on includes
-// 	var publications []models.Publication
-// 	filteredPublicationIds := []*datastore.Key{}
-// 	publicationExists := map[int64]bool{}
-// 	publicationExists = make(map[int64]bool)
-
-// 	for i := 0; i < len(publicationIds); i++ {
-// 		if _, ok := publicationExists[publicationIds[i]]; !ok {
-// 			filteredPublicationIds = append(filteredPublicationIds, datastore.NewKey("Publication", "", publicationIds[i], nil))
-
-// 			publicationExists[publicationIds[i]] = true
-// 		}
-// 	}
-
-// 	publications = make([]models.Publication, len(filteredPublicationIds))
-// 	err := nds.GetMulti(filteredPublicationIds, publications)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []models.Publication{}
-// 	}
-
-// 	for i := 0; i < len(publications); i++ {
-// 		publications[i].Id = filteredPublicationIds[i].IntID()
-// 		publications[i].Type = "publications"
-// 	}
-
-// 	return publications
-// }
+func contactsToPublications(contacts []models.Contact) []models.Publication {
+	publicationIds := []int64{}
+	publicationExists := map[int64]bool{}
+
+	for i := 0; i < len(contacts); i++ {
+		employers := []int64{}
+		employers = append(employers, contacts[i].Employers...)
+		employers = append(employers, contacts[i].PastEmployers...)
+		for x := 0; x < len(employers); x++ {
+			if _, ok := publicationExists[employers[x]]; !ok {
+				publicationIds = append(publicationIds, employers[x])
+				publicationExists[employers[x]] = true
+			}
+		}
+	}
+
+	if len(publicationIds) == 0 {
+		return []models.Publication{}
+	}
+
+	// Work on includes
+	publications := []models.Publication{}
+	err := db.DB.Model(&publications).Where("id IN (?)", pg.In(publicationIds)).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Publication{}
+	}
+
+	for i := 0; i < len(publications); i++ {
+		publications[i].Type = "publications"
+	}
+
+	return publications
+}
 
 // func contactsToLists(r *http.Request, contacts []models.Contact) []models.MediaList {
 // 	mediaListIds := []int64{}
@@ -797,202 +836,161 @@ package controllers
 // 	return contacts, nil
 // }
 
-// func GetContact(r *http.Request, id string) (models.Contact, interface{}, error) {
-// 	// Get the details of the current user
-// 	currentId, err := utilities.StringIdToInt(id)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, err
-// 	}
-
-// 	contact, err := getContact(r, currentId)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, err
-// 	}
-
-// 	includes := getIncludesForContacts(r, []models.Contact{contact})
-// 	return contact, includes, nil
-// }
-
-// func ContactsToDefaultFields(r *http.Request, contacts []models.Contact, mediaList models.MediaList) ([]models.Contact, error) {
-// 	instagramUsers := []string{}
-// 	twitterUsers := []string{}
-
-// 	// user, err := controllers.GetCurrentUser( r)
-// 	// if err != nil {
-// 	// 	log.Printf( "%v", err)
-// 	// 	return []models.Contact{}, errors.New("Could not get user")
-// 	// }
-
-// 	for i := 0; i < len(contacts); i++ {
-// 		if contacts[i].Instagram != "" {
-// 			instagramUsers = append(instagramUsers, contacts[i].Instagram)
-// 		}
-
-// 		if contacts[i].Twitter != "" {
-// 			twitterUsers = append(twitterUsers, contacts[i].Twitter)
-// 		}
-// 	}
-
-// 	readOnlyPresent := []string{}
-// 	instagramTimeseries := []apiSearch.InstagramTimeseries{}
-// 	twitterTimeseries := []apiSearch.TwitterTimeseries{}
-
-// 	// Check if there are special fields we need to get data for
-// 	for i := 0; i < len(mediaList.FieldsMap); i++ {
-// 		if mediaList.FieldsMap[i].ReadOnly && !mediaList.FieldsMap[i].Hidden {
-// 			readOnlyPresent = append(readOnlyPresent, mediaList.FieldsMap[i].Value)
-// 			if strings.Contains(mediaList.FieldsMap[i].Value, "instagram") {
-// 				if len(instagramTimeseries) == 0 {
-// 					instagramTimeseries, _ = apiSearch.SearchInstagramTimeseriesByUsernames(r, instagramUsers)
-// 				}
-// 			}
-// 			if strings.Contains(mediaList.FieldsMap[i].Value, "twitter") {
-// 				if len(twitterTimeseries) == 0 {
-// 					twitterTimeseries, _ = apiSearch.SearchTwitterTimeseriesByUsernames(r, twitterUsers)
-// 				}
-// 			}
-// 		}
-// 	}
-
-// 	if len(readOnlyPresent) > 0 {
-// 		customFieldInstagramUsernameToValue := map[string]apiSearch.InstagramTimeseries{}
-// 		customFieldTwitterUsernameToValue := map[string]apiSearch.TwitterTimeseries{}
-
-// 		if len(instagramTimeseries) > 0 {
-// 			for i := 0; i < len(instagramTimeseries); i++ {
-// 				lowerCaseUsername := strings.ToLower(instagramTimeseries[i].Username)
-// 				customFieldInstagramUsernameToValue[lowerCaseUsername] = instagramTimeseries[i]
-// 			}
-// 		}
-
-// 		if len(twitterTimeseries) > 0 {
-// 			for i := 0; i < len(twitterTimeseries); i++ {
-// 				lowerCaseUsername := strings.ToLower(twitterTimeseries[i].Username)
-// 				customFieldTwitterUsernameToValue[lowerCaseUsername] = twitterTimeseries[i]
-// 			}
-// 		}
-
-// 		for i := 0; i < len(contacts); i++ {
-// 			for x := 0; x < len(readOnlyPresent); x++ {
-// 				customField := models.CustomContactField{}
-// 				customField.Name = readOnlyPresent[x]
-
-// 				lowerCaseInstagramUsername := strings.ToLower(contacts[i].Instagram)
-// 				lowerCaseTwitterUsername := strings.ToLower(contacts[i].Twitter)
-
-// 				if lowerCaseInstagramUsername != "" {
-// 					if _, ok := customFieldInstagramUsernameToValue[lowerCaseInstagramUsername]; ok {
-// 						instagramProfile := customFieldInstagramUsernameToValue[lowerCaseInstagramUsername]
-
-// 						if customField.Name == "instagramfollowers" {
-// 							customField.Value = strconv.Itoa(instagramProfile.Followers)
-// 						} else if customField.Name == "instagramfollowing" {
-// 							customField.Value = strconv.Itoa(instagramProfile.Following)
-// 						} else if customField.Name == "instagramlikes" {
-// 							customField.Value = strconv.Itoa(instagramProfile.Likes)
-// 						} else if customField.Name == "instagramcomments" {
-// 							customField.Value = strconv.Itoa(instagramProfile.Comments)
-// 						} else if customField.Name == "instagramposts" {
-// 							customField.Value = strconv.Itoa(instagramProfile.Posts)
-// 						}
-// 					}
-// 				}
-
-// 				if lowerCaseTwitterUsername != "" {
-// 					if _, ok := customFieldTwitterUsernameToValue[lowerCaseTwitterUsername]; ok {
-// 						twitterProfile := customFieldTwitterUsernameToValue[lowerCaseTwitterUsername]
-
-// 						if customField.Name == "twitterfollowers" {
-// 							customField.Value = strconv.Itoa(twitterProfile.Followers)
-// 						} else if customField.Name == "twitterfollowing" {
-// 							customField.Value = strconv.Itoa(twitterProfile.Following)
-// 						} else if customField.Name == "twitterlikes" {
-// 							customField.Value = strconv.Itoa(twitterProfile.Likes)
-// 						} else if customField.Name == "twitterretweets" {
-// 							customField.Value = strconv.Itoa(twitterProfile.Retweets)
-// 						} else if customField.Name == "twitterposts" {
-// 							customField.Value = strconv.Itoa(twitterProfile.Posts)
-// 						}
-// 					}
-// 				}
-
-// 				if customField.Name == "latestheadline" {
-// 					// Get the feed of the contact
-// 					headlines, _, _, _, err := GetHeadlinesForContactById(r, contacts[i].Id)
-
-// 					// Set the value of the post name to the user
-// 					if err == nil && len(headlines) > 0 {
-// 						customField.Value = headlines[0].Title
-// 					}
-// 				}
-
-// 				if customField.Name == "lastcontacted" && contacts[i].Email != "" {
-// 					// lastCreatedMemcacheKey := "lastcontacted" + strconv.FormatInt(user.Id, 10) + contacts[i].Email
-// 					// item, err := memcache.Get( lastCreatedMemcacheKey)
-// 					// if err != nil {
-// 					emails, _, _, err := GetOrderedEmailsForContact(r, contacts[i])
-
-// 					// Set the value of the post name to the user
-// 					if err == nil && len(emails) > 0 {
-// 						lastUnarchivedEmail := -1
-// 						for y := 0; y < len(emails); y++ {
-// 							if !emails[y].Archived && !emails[y].Cancel {
-// 								lastUnarchivedEmail = y
-// 								break
-// 							}
-// 						}
-// 						// The processing here is a little more complex
-// 						// customField.Value = emails[0].Created
-// 						// Also, don't count it if the email has been
-// 						// archived. So we look for the last unarchived email.
-// 						if lastUnarchivedEmail != -1 && lastUnarchivedEmail < len(emails) {
-// 							if !emails[lastUnarchivedEmail].SendAt.IsZero() {
-// 								customField.Value = emails[lastUnarchivedEmail].SendAt.Format(time.RFC3339)
-// 							} else {
-// 								customField.Value = emails[lastUnarchivedEmail].Created.Format(time.RFC3339)
-// 							}
-
-// 							// Outliar checks
-// 							// First check if email is sent & delivered.
-// 							if customField.Value != "" && emails[lastUnarchivedEmail].IsSent && emails[lastUnarchivedEmail].Delievered {
-// 								// Sometimes email is marked as sent, but hasn't actually been sent
-// 								// because Gmail rejected it. This is to check that.
-// 								if emails[lastUnarchivedEmail].Method == "gmail" && emails[lastUnarchivedEmail].GmailId == "" {
-// 									customField.Value = ""
-// 								} else if emails[lastUnarchivedEmail].Method == "sendgrid" && emails[lastUnarchivedEmail].SendGridId == "" {
-// 									customField.Value = ""
-// 								}
-// 								// else {
-// 								// 	item1 := &memcache.Item{
-// 								// 		Key:   lastCreatedMemcacheKey,
-// 								// 		Value: []byte(customField.Value),
-// 								// 	}
-// 								// 	err = memcache.Set( item1)
-// 								// 	if err != nil {
-// 								// 		log.Printf( "%v", err)
-// 								// 	}
-// 								// }
-// 							}
-// 						}
-// 					}
-// 					// } else {
-// 					// 	emailDate := string(item.Value[:])
-// 					// 	log.Debugf( "memcache: %v", emailDate)
-// 					// 	customField.Value = emailDate
-// 					// }
-// 				}
-
-// 				if customField.Value != "" {
-// 					contacts[i].CustomFields = append(contacts[i].CustomFields, customField)
-// 				}
-// 			}
-// 		}
-// 	}
-
-// 	return contacts, nil
-// }
+func GetContact(r *http.Request, id string) (models.Contact, interface{}, error) {
+	// Get the details of the current user
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	contact, err := getContact(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	publications := contactsToPublications([]models.Contact{contact})
+	return contact, publications, nil
+}
+
+func ContactsToDefaultFields(r *http.Request, contacts []models.Contact, mediaList models.MediaList) ([]models.Contact, error) {
+	instagramUsers := []string{}
+	twitterUsers := []string{}
+
+	// user, err := controllers.GetCurrentUser( r)
+	// if err != nil {
+	// 	log.Printf( "%v", err)
+	// 	return []models.Contact{}, errors.New("Could not get user")
+	// }
+
+	for i := 0; i < len(contacts); i++ {
+		if contacts[i].Instagram != "" {
+			instagramUsers = append(instagramUsers, contacts[i].Instagram)
+		}
+
+		if contacts[i].Twitter != "" {
+			twitterUsers = append(twitterUsers, contacts[i].Twitter)
+		}
+	}
+
+	readOnlyPresent := []string{}
+	instagramTimeseries := []apiSearch.InstagramTimeseries{}
+	twitterTimeseries := []apiSearch.TwitterTimeseries{}
+	latestHeadlines := map[int64]models.Headline{}
+
+	// Check if there are special fields we need to get data for
+	for i := 0; i < len(mediaList.FieldsMap); i++ {
+		if mediaList.FieldsMap[i].ReadOnly && !mediaList.FieldsMap[i].Hidden {
+			readOnlyPresent = append(readOnlyPresent, mediaList.FieldsMap[i].Value)
+			if strings.Contains(mediaList.FieldsMap[i].Value, "instagram") {
+				if len(instagramTimeseries) == 0 {
+					instagramTimeseries, _ = apiSearch.SearchInstagramTimeseriesByUsernames(r, instagramUsers)
+				}
+			}
+			if strings.Contains(mediaList.FieldsMap[i].Value, "twitter") {
+				if len(twitterTimeseries) == 0 {
+					twitterTimeseries, _ = apiSearch.SearchTwitterTimeseriesByUsernames(r, twitterUsers)
+				}
+			}
+			if mediaList.FieldsMap[i].Value == "latestheadline" {
+				contactIds := []int64{}
+				for x := 0; x < len(contacts); x++ {
+					contactIds = append(contactIds, contacts[x].Id)
+				}
+
+				var err error
+				latestHeadlines, err = models.GetLatestHeadlines(contactIds)
+				if err != nil {
+					log.Printf("%v", err)
+				}
+			}
+		}
+	}
+
+	if len(readOnlyPresent) > 0 {
+		customFieldInstagramUsernameToValue := map[string]apiSearch.InstagramTimeseries{}
+		customFieldTwitterUsernameToValue := map[string]apiSearch.TwitterTimeseries{}
+
+		if len(instagramTimeseries) > 0 {
+			for i := 0; i < len(instagramTimeseries); i++ {
+				lowerCaseUsername := strings.ToLower(instagramTimeseries[i].Username)
+				customFieldInstagramUsernameToValue[lowerCaseUsername] = instagramTimeseries[i]
+			}
+		}
+
+		if len(twitterTimeseries) > 0 {
+			for i := 0; i < len(twitterTimeseries); i++ {
+				lowerCaseUsername := strings.ToLower(twitterTimeseries[i].Username)
+				customFieldTwitterUsernameToValue[lowerCaseUsername] = twitterTimeseries[i]
+			}
+		}
+
+		for i := 0; i < len(contacts); i++ {
+			for x := 0; x < len(readOnlyPresent); x++ {
+				customField := models.CustomContactField{}
+				customField.Name = readOnlyPresent[x]
+
+				lowerCaseInstagramUsername := strings.ToLower(contacts[i].Instagram)
+				lowerCaseTwitterUsername := strings.ToLower(contacts[i].Twitter)
+
+				if lowerCaseInstagramUsername != "" {
+					if _, ok := customFieldInstagramUsernameToValue[lowerCaseInstagramUsername]; ok {
+						instagramProfile := customFieldInstagramUsernameToValue[lowerCaseInstagramUsername]
+
+						if customField.Name == "instagramfollowers" {
+							customField.Value = strconv.Itoa(instagramProfile.Followers)
+						} else if customField.Name == "instagramfollowing" {
+							customField.Value = strconv.Itoa(instagramProfile.Following)
+						} else if customField.Name == "instagramlikes" {
+							customField.Value = strconv.Itoa(instagramProfile.Likes)
+						} else if customField.Name == "instagramcomments" {
+							customField.Value = strconv.Itoa(instagramProfile.Comments)
+						} else if customField.Name == "instagramposts" {
+							customField.Value = strconv.Itoa(instagramProfile.Posts)
+						}
+					}
+				}
+
+				if lowerCaseTwitterUsername != "" {
+					if _, ok := customFieldTwitterUsernameToValue[lowerCaseTwitterUsername]; ok {
+						twitterProfile := customFieldTwitterUsernameToValue[lowerCaseTwitterUsername]
+
+						if customField.Name == "twitterfollowers" {
+							customField.Value = strconv.Itoa(twitterProfile.Followers)
+						} else if customField.Name == "twitterfollowing" {
+							customField.Value = strconv.Itoa(twitterProfile.Following)
+						} else if customField.Name == "twitterlikes" {
+							customField.Value = strconv.Itoa(twitterProfile.Likes)
+						} else if customField.Name == "twitterretweets" {
+							customField.Value = strconv.Itoa(twitterProfile.Retweets)
+						} else if customField.Name == "twitterposts" {
+							customField.Value = strconv.Itoa(twitterProfile.Posts)
+						}
+					}
+				}
+
+				// Kept up to date as the feeds are polled
+				if customField.Name == "latestheadline" {
+					if headline, ok := latestHeadlines[contacts[i].Id]; ok {
+						customField.Value = headline.Title
+					}
+				}
+
+				// Kept up to date as emails to the contact are sent
+				if customField.Name == "lastcontacted" && !contacts[i].LastContacted.IsZero() {
+					customField.Value = contacts[i].LastContacted.Format(time.RFC3339)
+				}
+
+				if customField.Value != "" {
+					contacts[i].CustomFields = append(contacts[i].CustomFields, customField)
+				}
+			}
+		}
+	}
+
+	return contacts, nil
+}
 
 // func GetTweetsForContact(r *http.Request, id string) (interface{}, interface{}, int, int, error) {
 // 	// Get the details of the current user
@@ -1201,33 +1199,36 @@ package controllers
 // 	return mediaLists, nil, len(mediaLists), 0, nil
 // }
 
-// func GetHeadlinesForContactById(r *http.Request, currentId int64) ([]apiSearch.Headline, interface{}, int, int, error) {
-// 	// Get the details of the current user
-// 	feeds, err := GetFeedsByResourceId(r, "ContactId", currentId)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return nil, nil, 0, 0, err
-// 	}
-
-// 	headlines, total, err := apiSearch.SearchHeadlinesByResourceId(r, feeds, []string{})
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return nil, nil, 0, 0, err
-// 	}
-
-// 	return headlines, nil, len(headlines), total, nil
-// }
-
-// func GetHeadlinesForContact(r *http.Request, id string) (interface{}, interface{}, int, int, error) {
-// 	// Get the details of the current user
-// 	currentId, err := utilities.StringIdToInt(id)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return nil, nil, 0, 0, err
-// 	}
-
-// 	return GetHeadlinesForContactById(r, currentId)
-// }
+func GetHeadlinesForContactById(r *http.Request, currentId int64) ([]models.Headline, interface{}, int, int, error) {
+	contact, err := getContact(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Headline{}, nil, 0, 0, err
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	headlines, total, err := models.GetHeadlinesForContact(contact.Id, offset, limit)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Headline{}, nil, 0, 0, err
+	}
+
+	return headlines, nil, len(headlines), total, nil
+}
+
+// Entries of the feeds of a contact and of publication feeds with the
+// contact in the byline, newest first
+func GetHeadlinesForContact(r *http.Request, id string) ([]models.Headline, interface{}, int, int, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Headline{}, nil, 0, 0, err
+	}
+
+	return GetHeadlinesForContactById(r, currentId)
+}
 
 // func GetFeedForContact(r *http.Request, id string) (interface{}, interface{}, int, int, error) {
 // 	// Get the details of the current user
@@ -1275,15 +1276,50 @@ package controllers
 // 	return feeds, nil, len(feeds), 0, nil
 // }
 
-// func FilterContacts(r *http.Request, queryType, query string) ([]models.Contact, error) {
-// 	// User has to be logged in
-// 	_, err := controllers.GetCurrentUser(r)
-// 	if err != nil {
-// 		return []models.Contact{}, err
-// 	}
-
-// 	return filterContacts(r, queryType, query)
-// }
+func FilterContacts(r *http.Request, queryType, query string) ([]models.Contact, error) {
+	// User has to be logged in
+	_, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		return []models.Contact{}, err
+	}
+
+	return filterContacts(r, queryType, query)
+}
+
+// Gets the contacts of the current user that have one of the given emails.
+// Emails are compared the way Normalize stores them.
+func FilterContactsByEmailsForUser(r *http.Request, emails []string) ([]models.Contact, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+
+	normalizedEmails := []string{}
+	for i := 0; i < len(emails); i++ {
+		email := strings.ToLower(strings.TrimSpace(emails[i]))
+		if email != "" {
+			normalizedEmails = append(normalizedEmails, email)
+		}
+	}
+
+	if len(normalizedEmails) == 0 {
+		return []models.Contact{}, nil
+	}
+
+	contacts := []models.Contact{}
+	err = db.DB.Model(&contacts).Where("created_by = ?", user.Id).Where("is_deleted = ?", false).Where("email IN (?)", pg.In(normalizedEmails)).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		contacts[i].Type = "contacts"
+	}
+
+	return contacts, nil
+}
 
 // /*
 // * Create methods
@@ -1412,67 +1448,62 @@ package controllers
 // }
 
 // // Does a ES sync in parse package & Twitter sync here
-// func BatchCreateContactsForExcelUpload(r *http.Request, contacts []models.Contact, mediaListId int64) ([]int64, []int64, error) {
-// 	var keys []*datastore.Key
-// 	var contactIds []int64
-// 	var publicationIds []int64
-// 	var selectedContacts []models.Contact
+func BatchCreateContactsForExcelUpload(r *http.Request, contacts []models.Contact, mediaListId int64) ([]int64, []int64, error) {
+	contactIds := []int64{}
+	publicationIds := []int64{}
+
+	if len(contacts) == 0 {
+		return contactIds, publicationIds, nil
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []int64{}, []int64{}, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		contacts[i].CreatedBy = currentUser.Id
+		contacts[i].Created = time.Now()
+		contacts[i].Updated = time.Now()
+		contacts[i].ListId = mediaListId
+		contacts[i].Normalize()
+		contacts[i].FormatName()
+
+		publicationIds = append(publicationIds, contacts[i].Employers...)
+		publicationIds = append(publicationIds, contacts[i].PastEmployers...)
+	}
+
+	// Contacts are inserted together with their employments, so none are
+	// left without a job history
+	err = db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Model(&contacts).Returning("*").Insert()
+		if err != nil {
+			return err
+		}
+
+		employments := newContactsEmployments(contacts, models.EmploymentSourceImport)
+		if len(employments) == 0 {
+			return nil
+		}
+
+		_, err = tx.Model(&employments).Insert()
+		return err
+	})
+	if err != nil {
+		log.Printf("%v", err)
+		return []int64{}, []int64{}, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		contactIds = append(contactIds, contacts[i].Id)
+	}
+
+	discoverNewContactsFeedsLater(currentUser.Id, contacts)
+
+	return contactIds, publicationIds, nil
+}
 
-// 	currentUser, err := controllers.GetCurrentUser(r)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []int64{}, []int64{}, err
-// 	}
-
-// 	for i := 0; i < len(contacts); i++ {
-// 		contacts[i].CreatedBy = currentUser.Id
-// 		contacts[i].Created = time.Now()
-// 		contacts[i].Updated = time.Now()
-// 		contacts[i].ListId = mediaListId
-// 		contacts[i].Normalize()
-// 		contacts[i].FormatName()
-// 		keys = append(keys, contacts[i].Key())
-
-// 		for x := 0; x < len(contacts[i].Employers); x++ {
-// 			publicationIds = append(publicationIds, contacts[i].Employers[x])
-// 		}
-
-// 		for x := 0; x < len(contacts[i].PastEmployers); x++ {
-// 			publicationIds = append(publicationIds, contacts[i].PastEmployers[x])
-// 		}
-
-// 		selectedContacts = append(selectedContacts, contacts[i])
-// 	}
-
-// 	ks := []*datastore.Key{}
-
-// 	err = nds.RunInTransaction(func(ctx context.Context) error {
-// 		contextWithTimeout, _ := context.WithTimeout(time.Second * 1000)
-// 		ks, err = nds.PutMulti(contextWithTimeout, keys, selectedContacts)
-// 		if err != nil {
-// 			log.Printf("%v", err)
-// 			return err
-// 		}
-// 		return nil
-// 	}, nil)
-
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return []int64{}, []int64{}, err
-// 	}
-
-// 	for i := 0; i < len(ks); i++ {
-// 		contactIds = append(contactIds, ks[i].IntID())
-// 	}
-
-// 	return contactIds, publicationIds, nil
-// }
-
-// /*
-// * Update methods
-//  */
-
-// // Function to save a new contact into App Engine
 // func Save(r *http.Request, ct *models.Contact) (*models.Contact, error) {
 // 	ct.Normalize()
 
@@ -1562,41 +1593,29 @@ package controllers
 // 	return contact, nil, nil
 // }
 
-// func UpdateSingleContact(r *http.Request, id string) (models.Contact, interface{}, error) {
-// 	// Get the details of the current contact
-// 	contact, _, err := GetContact(r, id)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, err
-// 	}
-
-// 	user, err := controllers.GetCurrentUser(r)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, errors.New("Could not get user")
-// 	}
-
-// 	mediaList, err := getMediaList(r, contact.ListId)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, err
-// 	}
-
-// 	if mediaList.TeamId != user.Data.TeamId && !permissions.AccessToObject(contact.CreatedBy, user.Id) && !user.Data.IsAdmin {
-// 		return models.Contact{}, nil, errors.New("You don't have permissions to edit these objects")
-// 	}
-
-// 	buf, _ := ioutil.ReadAll(r.Body)
-// 	decoder := ffjson.NewDecoder()
-// 	var updatedContact models.Contact
-// 	err = decoder.Decode(buf, &updatedContact)
-// 	if err != nil {
-// 		log.Printf("%v", err)
-// 		return models.Contact{}, nil, err
-// 	}
-
-// 	return updateContact(r, &contact, updatedContact)
-// }
+func UpdateSingleContact(r *http.Request, id string) (models.Contact, interface{}, error) {
+	// Get the details of the current contact
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.ReadOnly {
+		return models.Contact{}, nil, errors.New("You don't have permissions to edit these objects")
+	}
+
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var updatedContact models.Contact
+	err = decoder.Decode(buf, &updatedContact)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	return updateContact(r, &contact, updatedContact)
+}
 
 // func UpdateBatchContact(r *http.Request) ([]models.Contact, interface{}, int, int, error) {
 // 	buf, _ := ioutil.ReadAll(r.Body)
@@ -0,0 +1,465 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+
+	gcontext "github.com/gorilla/context"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/verify"
+)
+
+// Where the addresses of a list stand. Contacts is every contact whose
+// address isn't known to be valid, Job the last time the list was checked.
+type ListEmailVerification struct {
+	ListId int64 `json:"listid"`
+
+	Unverified int `json:"unverified"`
+	Valid      int `json:"valid"`
+	Invalid    int `json:"invalid"`
+	Risky      int `json:"risky"`
+	Unknown    int `json:"unknown"`
+
+	Contacts []EmailVerificationWarning `json:"contacts"`
+
+	Job *models.EmailVerificationJob `json:"job"`
+}
+
+// A recipient whose address is not known to be good
+type EmailVerificationWarning struct {
+	EmailId   int64  `json:"emailid,omitempty"`
+	ContactId int64  `json:"contactid"`
+	Email     string `json:"email"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+const (
+	// Addresses checked since then are not checked again unless asked to
+	emailVerificationMaxAge = 30 * 24 * time.Hour
+
+	// Addresses of a list checked at the same time
+	emailVerificationWorkers = 8
+
+	// Progress of a job is saved after this many addresses
+	emailVerificationProgressEvery = 25
+
+	// Jobs that haven't saved progress in this long are taken to have died
+	// with the process running them, and the list can be checked again
+	emailVerificationJobStaleAfter = 10 * time.Minute
+)
+
+// Lists checked at the same time
+var emailVerificationJobSlots = make(chan struct{}, 2)
+
+/*
+* Private methods
+ */
+
+// Checks each address once, a few at a time, and stores the results on the
+// contacts given for it. progress, if given, is called with the number of
+// addresses checked so far.
+func verifyEmails(r *http.Request, emailContactIds map[string][]int64, progress func(checked int)) map[string]verify.Result {
+	verifier := verify.NewVerifier()
+	results := map[string]verify.Result{}
+
+	// Results are recorded as changes made for the user that asked
+	var actorId int64
+	user, err := controllers.GetCurrentUser(r)
+	if err == nil {
+		actorId = user.Id
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < emailVerificationWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for email := range queue {
+				result := verifier.Verify(r.Context(), email)
+				err := models.SaveEmailVerification(actorId, emailContactIds[email], result.Status, result.Reason)
+				if err != nil {
+					log.Printf("%v", err)
+				}
+
+				mutex.Lock()
+				results[email] = result
+				if progress != nil {
+					progress(len(results))
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for email := range emailContactIds {
+		queue <- email
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+// Why a recipient should be looked at before sending, or "" if they are fine
+func emailVerificationWarning(contact models.Contact) string {
+	if contact.EmailBounced {
+		return "Email to this address has bounced before"
+	}
+
+	switch contact.EmailVerification {
+	case verify.StatusValid:
+		return ""
+	case "":
+		return "Email address has not been verified"
+	}
+
+	return contact.EmailVerificationReason
+}
+
+// The list the user wants to check, if they are allowed to check it
+func getVerifiableMediaList(r *http.Request, id string) (models.MediaList, error) {
+	mediaList, _, err := GetMediaList(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, err
+	}
+
+	// Public lists can be read by anyone but only checked by their team
+	if mediaList.CreatedBy != user.Id && !user.Data.IsAdmin {
+		if mediaList.TeamId == 0 || mediaList.TeamId != user.Data.TeamId {
+			return models.MediaList{}, errors.New("Forbidden")
+		}
+	}
+
+	return mediaList, nil
+}
+
+func getListContactsWithEmail(mediaList models.MediaList) ([]models.Contact, error) {
+	contacts := []models.Contact{}
+	if len(mediaList.Contacts) == 0 {
+		return contacts, nil
+	}
+
+	err := db.DB.Model(&contacts).Where("id IN (?)", pg.In(mediaList.Contacts)).Where("is_deleted = ?", false).Where("email != ?", "").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, err
+	}
+	return contacts, nil
+}
+
+// The contacts of each address that needs checking. Addresses checked in
+// the last 30 days are skipped unless forced, apart from the ones that could
+// not be checked.
+func emailsToVerify(contacts []models.Contact, force bool) map[string][]int64 {
+	contactIds := map[string][]int64{}
+	due := map[string]bool{}
+	for i := 0; i < len(contacts); i++ {
+		email := contacts[i].Email
+		if email == "" {
+			continue
+		}
+		contactIds[email] = append(contactIds[email], contacts[i].Id)
+
+		checked := contacts[i].EmailVerification != "" && contacts[i].EmailVerification != verify.StatusUnknown
+		if force || !checked || time.Since(contacts[i].EmailVerified) >= emailVerificationMaxAge {
+			due[email] = true
+		}
+	}
+
+	emailContactIds := map[string][]int64{}
+	for email := range due {
+		emailContactIds[email] = contactIds[email]
+	}
+	return emailContactIds
+}
+
+func getLatestEmailVerificationJob(listId int64) (models.EmailVerificationJob, error) {
+	emailVerificationJob := models.EmailVerificationJob{}
+	err := db.DB.Model(&emailVerificationJob).Where("list_id = ?", listId).Order("id DESC").Limit(1).Select()
+	if err != nil {
+		return models.EmailVerificationJob{}, err
+	}
+
+	emailVerificationJob.Type = "emailverificationjobs"
+	return emailVerificationJob, nil
+}
+
+func runEmailVerificationJob(r *http.Request, emailVerificationJob *models.EmailVerificationJob) error {
+	mediaList, err := getVerifiableMediaList(r, strconv.FormatInt(emailVerificationJob.ListId, 10))
+	if err != nil {
+		return err
+	}
+
+	contacts, err := getListContactsWithEmail(mediaList)
+	if err != nil {
+		return err
+	}
+
+	emailContactIds := emailsToVerify(contacts, emailVerificationJob.Force)
+	emailVerificationJob.Status = models.ImportJobStatusRunning
+	emailVerificationJob.Started = time.Now()
+	emailVerificationJob.Total = len(emailContactIds)
+	_, err = emailVerificationJob.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	verifyEmails(r, emailContactIds, func(checked int) {
+		if checked%emailVerificationProgressEvery != 0 {
+			return
+		}
+
+		emailVerificationJob.Checked = checked
+		_, err := emailVerificationJob.Save()
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	})
+
+	emailVerificationJob.Checked = len(emailContactIds)
+	return nil
+}
+
+// Runs the job after the response has gone out, a few lists at a time
+func runEmailVerificationJobInBackground(emailVerificationJob models.EmailVerificationJob) {
+	go func() {
+		emailVerificationJobSlots <- struct{}{}
+		defer func() { <-emailVerificationJobSlots }()
+
+		r, err := UserRequest("/lists/"+strconv.FormatInt(emailVerificationJob.ListId, 10)+"/verify", emailVerificationJob.CreatedBy)
+		if err != nil {
+			log.Printf("%v", err)
+			return
+		}
+		defer gcontext.Clear(r)
+
+		err = runEmailVerificationJob(r, &emailVerificationJob)
+		emailVerificationJob.Status = models.ImportJobStatusCompleted
+		if err != nil {
+			emailVerificationJob.Status = models.ImportJobStatusFailed
+			emailVerificationJob.Error = err.Error()
+		}
+		emailVerificationJob.Finished = time.Now()
+
+		_, err = emailVerificationJob.Save()
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}()
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Warns about the recipients of a campaign's unsent emails whose addresses
+// are not known to be good. Meant to be shown before sending.
+func GetCampaignEmailWarnings(r *http.Request, id string) ([]EmailVerificationWarning, interface{}, int, int, error) {
+	campaign, _, err := GetCampaign(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []EmailVerificationWarning{}, nil, 0, 0, err
+	}
+
+	emails, err := filterEmailsByCampaignId(campaign.Id)
+	if err != nil {
+		return []EmailVerificationWarning{}, nil, 0, 0, err
+	}
+
+	contactIds := []int64{}
+	for i := 0; i < len(emails); i++ {
+		if !emails[i].IsSent && emails[i].ContactId != 0 {
+			contactIds = appendUniqueIds(contactIds, emails[i].ContactId)
+		}
+	}
+
+	contactIdToContact := map[int64]models.Contact{}
+	if len(contactIds) > 0 {
+		contacts := []models.Contact{}
+		err = db.DB.Model(&contacts).Where("id IN (?)", pg.In(contactIds)).Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return []EmailVerificationWarning{}, nil, 0, 0, err
+		}
+
+		for i := 0; i < len(contacts); i++ {
+			contactIdToContact[contacts[i].Id] = contacts[i]
+		}
+	}
+
+	warnings := []EmailVerificationWarning{}
+	for i := 0; i < len(emails); i++ {
+		if emails[i].IsSent {
+			continue
+		}
+
+		// Emails to an address the user typed in have no contact to check
+		contact, ok := contactIdToContact[emails[i].ContactId]
+		if !ok || !strings.EqualFold(contact.Email, emails[i].To) {
+			contact = models.Contact{Email: emails[i].To}
+		}
+
+		reason := emailVerificationWarning(contact)
+		if reason == "" {
+			continue
+		}
+
+		status := contact.EmailVerification
+		if contact.EmailBounced {
+			status = verify.StatusInvalid
+		}
+
+		warnings = append(warnings, EmailVerificationWarning{
+			EmailId:   emails[i].Id,
+			ContactId: contact.Id,
+			Email:     emails[i].To,
+			Status:    status,
+			Reason:    reason,
+		})
+	}
+
+	return warnings, nil, len(warnings), len(warnings), nil
+}
+
+// Where the addresses of the contacts of a list stand, along with the last
+// job that checked them
+func GetEmailVerificationForList(r *http.Request, id string) (ListEmailVerification, interface{}, error) {
+	mediaList, err := getVerifiableMediaList(r, id)
+	if err != nil {
+		return ListEmailVerification{}, nil, err
+	}
+
+	contacts, err := getListContactsWithEmail(mediaList)
+	if err != nil {
+		return ListEmailVerification{}, nil, err
+	}
+
+	summary := ListEmailVerification{ListId: mediaList.Id, Contacts: []EmailVerificationWarning{}}
+	for i := 0; i < len(contacts); i++ {
+		switch contacts[i].EmailVerification {
+		case verify.StatusValid:
+			summary.Valid++
+			continue
+		case verify.StatusInvalid:
+			summary.Invalid++
+		case verify.StatusRisky:
+			summary.Risky++
+		case verify.StatusUnknown:
+			summary.Unknown++
+		default:
+			summary.Unverified++
+		}
+
+		summary.Contacts = append(summary.Contacts, EmailVerificationWarning{
+			ContactId: contacts[i].Id,
+			Email:     contacts[i].Email,
+			Status:    contacts[i].EmailVerification,
+			Reason:    emailVerificationWarning(contacts[i]),
+		})
+	}
+
+	emailVerificationJob, err := getLatestEmailVerificationJob(mediaList.Id)
+	if err == nil {
+		summary.Job = &emailVerificationJob
+	}
+
+	return summary, nil, nil
+}
+
+/*
+* Action methods
+ */
+
+// Checks the email address of a contact
+func VerifyContactEmail(r *http.Request, id string) (models.Contact, interface{}, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, nil, err
+	}
+
+	if contact.ReadOnly {
+		return models.Contact{}, nil, errors.New("Forbidden")
+	}
+
+	if contact.Email == "" {
+		return models.Contact{}, nil, errors.New("Contact has no email address")
+	}
+
+	result := verifyEmails(r, map[string][]int64{contact.Email: {contact.Id}}, nil)[contact.Email]
+	contact.EmailVerification = result.Status
+	contact.EmailVerificationReason = result.Reason
+	contact.EmailVerified = time.Now()
+
+	return contact, nil, nil
+}
+
+// Queues a job to check the email addresses of every contact in a list.
+// Addresses checked in the last 30 days are skipped unless ?force=true,
+// apart from the ones that could not be checked. Results are on the
+// verify action of the list once the job has finished.
+func VerifyEmailsForList(r *http.Request, id string) (models.EmailVerificationJob, interface{}, error) {
+	mediaList, err := getVerifiableMediaList(r, id)
+	if err != nil {
+		return models.EmailVerificationJob{}, nil, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.EmailVerificationJob{}, nil, err
+	}
+
+	// A list is only checked by one job at a time
+	latestJob, err := getLatestEmailVerificationJob(mediaList.Id)
+	if err == nil && !latestJob.IsFinished() {
+		lastActive := latestJob.Created
+		if latestJob.Updated.After(lastActive) {
+			lastActive = latestJob.Updated
+		}
+		if time.Since(lastActive) < emailVerificationJobStaleAfter {
+			return latestJob, nil, nil
+		}
+	}
+
+	emailVerificationJob := models.EmailVerificationJob{}
+	emailVerificationJob.ListId = mediaList.Id
+	emailVerificationJob.Force = r.URL.Query().Get("force") == "true"
+
+	_, err = emailVerificationJob.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.EmailVerificationJob{}, nil, err
+	}
+
+	runEmailVerificationJobInBackground(emailVerificationJob)
+
+	emailVerificationJob.Type = "emailverificationjobs"
+	return emailVerificationJob, nil, nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/news-ai/tabulae-v1/models"
 	"github.com/news-ai/tabulae-v1/search"
 	"github.com/news-ai/tabulae-v1/sync"
+	"github.com/news-ai/tabulae-v1/verify"
 
 	"github.com/news-ai/web/permissions"
 	"github.com/news-ai/web/utilities"
@@ -746,6 +747,13 @@ func CreateEmailTransition(r *http.Request) ([]models.Email, interface{}, error)
 			keys = append(keys, emails[i].Key(c))
 		}
 
+		// Group the emails of this send into a campaign
+		err = assignCampaignToEmails(r, currentUser, emails)
+		if err != nil {
+			log.Errorf("%v", err)
+			return []models.Email{}, nil, err
+		}
+
 		if len(keys) < 300 {
 			ks := []*datastore.Key{}
 			err = nds.RunInTransaction(func(ctx context.Context) error {
@@ -879,6 +887,14 @@ func CreateEmailTransition(r *http.Request) ([]models.Email, interface{}, error)
 	email.TeamId = currentUser.TeamId
 	email.IsSent = false
 
+	singleEmail := []models.Email{email}
+	err = assignCampaignToEmails(r, currentUser, singleEmail)
+	if err != nil {
+		log.Errorf("%v", err)
+		return []models.Email{}, nil, err
+	}
+	email.CampaignId = singleEmail[0].CampaignId
+
 	// Create email
 	_, err = email.Create(r, currentUser)
 	sync.ResourceSync(r, email.Id, "Email", "create")
@@ -1203,12 +1219,20 @@ func BulkSendEmail(r *http.Request) ([]models.Email, interface{}, int, int, erro
 			return nil
 		}, nil)
 
-		// Delete a single memcache key since the emails should all have
-		// the same subject (or baseSubject)
+		// Delete a single memcache key since the emails should all be
+		// part of the same campaign
 		if memcacheKey != "" {
 			memcache.Delete(memcacheKey)
 		}
 
+		// Only sends that were saved count as sent
+		if err == nil {
+			markCampaignsSent(updatedEmails)
+			for i := 0; i < len(updatedEmails); i++ {
+				recordEmailEvent(&updatedEmails[i], models.EmailEventSent)
+			}
+		}
+
 		if len(emailIds) > 0 {
 			sync.SendEmailsToEmailService(r, emailIds)
 		}
@@ -1229,7 +1253,13 @@ func SendEmail(r *http.Request, id string) (models.Email, interface{}, error) {
 		log.Errorf("%v", err)
 		return models.Email{}, nil, err
 	}
-	singleEmail.Save(c)
+	_, err = singleEmail.Save(c)
+	if err != nil {
+		log.Errorf("%v", err)
+		return models.Email{}, nil, err
+	}
+	markCampaignsSent([]models.Email{singleEmail})
+	recordEmailEvent(&singleEmail, models.EmailEventSent)
 
 	// Check if email has been scheduled or not
 	if email.SendAt.IsZero() || email.SendAt.Before(time.Now()) {
@@ -1247,6 +1277,16 @@ func SendEmail(r *http.Request, id string) (models.Email, interface{}, error) {
 	return singleEmail, nil, nil
 }
 
+func recordEmailEvent(e *models.Email, event string) {
+	emailEvent := models.NewEmailEvent(*e, event)
+	_, err := emailEvent.Create()
+	if err != nil {
+		log.Errorf("%v", err)
+	}
+
+	updateContactEngagement(*e, emailEvent)
+}
+
 func MarkBounced(r *http.Request, e *models.Email, reason string) (*models.Email, error) {
 	controllers.SetUser(r, e.CreatedBy)
 
@@ -1257,46 +1297,84 @@ func MarkBounced(r *http.Request, e *models.Email, reason string) (*models.Email
 
 	for i := 0; i < len(contacts); i++ {
 		contacts[i].EmailBounced = true
-		contacts[i].Save(r)
+		contacts[i].EmailVerification = verify.StatusInvalid
+		contacts[i].EmailVerificationReason = "Email to this address bounced: " + reason
+		contacts[i].EmailVerified = time.Now()
+		contacts[i].SaveWithHistory(r, 0, models.ContactChangeSourceEmail)
 	}
 
 	_, err = e.MarkBounced(reason)
-	return e, err
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventBounce)
+	return e, nil
 }
 
 func MarkSpam(r *http.Request, e *models.Email) (*models.Email, error) {
 	controllers.SetUser(r, e.CreatedBy)
-	_, err := e.MarkSpam(c)
-	return e, err
+	_, err := e.MarkSpam()
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventSpam)
+	return e, nil
 }
 
 func MarkClicked(r *http.Request, e *models.Email) (*models.Email, error) {
 	controllers.SetUser(r, e.CreatedBy)
-	_, err := e.MarkClicked(c)
-	return e, err
+	_, err := e.MarkClicked()
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventClick)
+	return e, nil
 }
 
 func MarkDelivered(r *http.Request, e *models.Email) (*models.Email, error) {
-	_, err := e.MarkDelivered(c)
-	return e, err
+	_, err := e.MarkDelivered()
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventDelivered)
+	return e, nil
 }
 
 func MarkOpened(r *http.Request, e *models.Email) (*models.Email, error) {
 	controllers.SetUser(r, e.CreatedBy)
-	_, err := e.MarkOpened(c)
-	return e, err
+	_, err := e.MarkOpened()
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventOpen)
+	return e, nil
 }
 
 func MarkSendgridOpen(r *http.Request, e *models.Email) (*models.Email, error) {
 	controllers.SetUser(r, e.CreatedBy)
-	_, err := e.MarkSendgridOpened(c)
-	return e, err
+	_, err := e.MarkSendgridOpened()
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventOpen)
+	return e, nil
 }
 
 func MarkSendgridDrop(r *http.Request, e *models.Email) (*models.Email, error) {
 	controllers.SetUser(r, e.CreatedBy)
-	_, err := e.MarkSendgridDropped(c)
-	return e, err
+	_, err := e.MarkSendgridDropped()
+	if err != nil {
+		return e, err
+	}
+
+	recordEmailEvent(e, models.EmailEventDropped)
+	return e, nil
 }
 
 func GetEmailLogs(r *http.Request, id string) (interface{}, interface{}, error) {
@@ -1329,104 +1407,19 @@ func GetEmailSearch(r *http.Request) (interface{}, interface{}, int, int, error)
 		return nil, nil, 0, 0, err
 	}
 
-	if strings.Contains(queryField, "date:") || strings.Contains(queryField, "subject:") || strings.Contains(queryField, "filter:") || strings.Contains(queryField, "baseSubject:") {
-		emailFilters := strings.Split(queryField, ",")
-		emailDate := ""
-		emailSubject := ""
-		emailBaseSubject := ""
-		emailFilter := ""
-
-		for i := 0; i < len(emailFilters); i++ {
-			if strings.Contains(emailFilters[i], "date:") {
-				emailDateArray := strings.Split(emailFilters[i], ":")
-				if len(emailDateArray) > 1 {
-					emailDate = strings.Join(emailDateArray[1:], ":")
-					emailDate = strings.Replace(emailDate, "\\", "", -1)
-
-					if last := len(emailDate) - 1; last >= 0 && emailDate[last] == '"' {
-						emailDate = emailDate[:last]
-					}
-
-					if emailDate[0] == '"' {
-						emailDate = emailDate[1:]
-					}
-				}
-			} else if strings.Contains(emailFilters[i], "filter:") {
-				emailFilterArray := strings.Split(emailFilters[i], ":")
-				if len(emailFilterArray) > 1 {
-					emailFilter = strings.Join(emailFilterArray[1:], ":")
-					emailFilter = strings.Replace(emailFilter, "\\", "", -1)
-
-					if last := len(emailFilter) - 1; last >= 0 && emailFilter[last] == '"' {
-						emailFilter = emailFilter[:last]
-					}
-
-					if emailFilter[0] == '"' {
-						emailFilter = emailFilter[1:]
-					}
-				}
-			} else if strings.Contains(emailFilters[i], "subject:") {
-				if len(emailFilters) > 2 {
-					emailSubjectSplit := strings.Split(queryField, "subject:")
-					emailFilters[i] = "subject:" + emailSubjectSplit[len(emailSubjectSplit)-1]
-				}
-				emailSubjectArray := strings.Split(emailFilters[i], ":")
-				if len(emailSubjectArray) > 1 {
-					log.Infof("%v", emailSubjectArray)
-					// Recover the pieces when split by colon
-					emailSubject = strings.Join(emailSubjectArray[1:], ":")
-					emailSubject = strings.Replace(emailSubject, "\\", "", -1)
-
-					if last := len(emailSubject) - 1; last >= 0 && emailSubject[last] == '"' {
-						emailSubject = emailSubject[:last]
-					}
-
-					if emailSubject[0] == '"' {
-						emailSubject = emailSubject[1:]
-					}
-
-					log.Infof("%v", emailSubject)
-				}
-			} else if strings.Contains(emailFilters[i], "baseSubject:") {
-				emailBaseSubjectArray := strings.Split(emailFilters[i], ":")
-				if len(emailBaseSubjectArray) > 1 {
-					// Recover the pieces when split by colon
-					emailBaseSubject = strings.Join(emailBaseSubjectArray[1:], ":")
-					emailBaseSubject = strings.Replace(emailBaseSubject, "\\", "", -1)
-
-					if last := len(emailBaseSubject) - 1; last >= 0 && emailBaseSubject[last] == '"' {
-						emailBaseSubject = emailBaseSubject[:last]
-					}
-
-					if emailBaseSubject[0] == '"' {
-						emailBaseSubject = emailBaseSubject[1:]
-					}
-				}
-			}
-		}
-
-		if emailDate != "" || emailSubject != "" || emailFilter != "" || emailBaseSubject != "" {
-			emails, count, total, err := search.SearchEmailsByQueryFields(r, user, emailDate, emailSubject, emailBaseSubject, emailFilter)
-
-			// Add includes
-			mediaLists := emailsToLists(r, emails)
-			contacts := emailsToContacts(r, emails)
-			includes := make([]interface{}, len(mediaLists)+len(contacts))
-			for i := 0; i < len(mediaLists); i++ {
-				includes[i] = mediaLists[i]
-			}
-
-			for i := 0; i < len(contacts); i++ {
-				includes[i+len(mediaLists)] = contacts[i]
-			}
-
-			return emails, includes, count, total, err
-		} else {
-			return nil, nil, 0, 0, errors.New("Please enter a valid date or subject")
-		}
+	emailQuery, err := search.ParseEmailQuery(queryField)
+	if err != nil {
+		log.Errorf("%v", err)
+		return nil, nil, 0, 0, err
 	}
 
-	emails, count, total, err := search.SearchEmailsByQuery(r, user, queryField)
+	var emails []models.Email
+	var count, total int
+	if emailQuery.HasFields() {
+		emails, count, total, err = search.SearchEmailsByQueryFields(r, user, emailQuery)
+	} else {
+		emails, count, total, err = search.SearchEmailsByQuery(r, user, emailQuery.Text)
+	}
 
 	// Add includes
 	mediaLists := emailsToLists(r, emails)
@@ -1450,7 +1443,13 @@ func GetEmailCampaigns(r *http.Request) (interface{}, interface{}, int, int, err
 		return nil, nil, 0, 0, err
 	}
 
-	emails, count, total, err := search.SearchEmailCampaignsByDate(r, user)
+	campaigns, total, err := getCampaignsForUser(r, user.Id)
+	if err != nil {
+		log.Errorf("%v", err)
+		return nil, nil, 0, 0, err
+	}
+
+	emails, count, total, err := search.SearchEmailCampaignsByDate(r, user, campaigns, total)
 	return emails, nil, count, total, err
 }
 
@@ -1490,7 +1489,13 @@ func GetEmailCampaignsForUser(r *http.Request, id string) (interface{}, interfac
 		return []models.Email{}, nil, 0, 0, err
 	}
 
-	emails, count, total, err := search.SearchEmailCampaignsByDate(r, user)
+	campaigns, total, err := getCampaignsForUser(r, user.Id)
+	if err != nil {
+		log.Errorf("%v", err)
+		return []models.Email{}, nil, 0, 0, err
+	}
+
+	emails, count, total, err := search.SearchEmailCampaignsByDate(r, user, campaigns, total)
 	return emails, nil, count, total, err
 }
 
@@ -1525,6 +1530,11 @@ func GetEmailProviderLimits(r *http.Request) (interface{}, interface{}, error) {
 }
 
 func GetEmailCampaignKey(email models.Email) string {
+	if email.CampaignId != 0 {
+		return "campaign-" + strconv.FormatInt(email.CampaignId, 10)
+	}
+
+	// Emails sent before campaigns existed are grouped by day and subject
 	emailSubject := email.Subject
 	if email.BaseSubject != "" {
 		emailSubject = email.BaseSubject
@@ -0,0 +1,590 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+type endEmploymentDetails struct {
+	EndDate time.Time `json:"enddate"`
+}
+
+// Contacts gone through at a time when backfilling employments
+var employmentBackfillBatch = 500
+
+/*
+* Private methods
+ */
+
+/*
+* Get methods
+ */
+
+func getEmployment(r *http.Request, id int64) (models.Employment, models.Contact, error) {
+	if id == 0 {
+		return models.Employment{}, models.Contact{}, errors.New("datastore: no such entity")
+	}
+
+	employment := models.Employment{}
+	err := db.DB.Model(&employment).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, models.Contact{}, err
+	}
+
+	if employment.Created.IsZero() {
+		return models.Employment{}, models.Contact{}, errors.New("No employment by this id")
+	}
+
+	// Anyone that can see the contact can see its jobs
+	contact, err := getContact(r, employment.ContactId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, models.Contact{}, err
+	}
+
+	employment.Type = "employments"
+	return employment, contact, nil
+}
+
+// Gets a contact whose employments can be changed. Children get theirs from
+// their master.
+func getContactForEmployment(r *http.Request, contactId int64) (models.Contact, error) {
+	contact, err := getContact(r, contactId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Contact{}, err
+	}
+
+	if contact.ReadOnly {
+		return models.Contact{}, errors.New("Forbidden")
+	}
+
+	if contact.ParentContact != 0 {
+		return models.Contact{}, errors.New("Employments come from the master contact")
+	}
+
+	// Jobs from before employments were kept have to be there before any
+	// are changed
+	_, err = syncEmployments(contact, models.EmploymentSourceLegacy)
+	if err != nil {
+		return models.Contact{}, err
+	}
+
+	return contact, nil
+}
+
+// Current jobs first, then the most recent
+func filterEmploymentsForContact(contactId int64) ([]models.Employment, error) {
+	employments := []models.Employment{}
+	err := db.DB.Model(&employments).Where("contact_id = ?", contactId).Order("current DESC", "start_date DESC NULLS LAST", "id DESC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Employment{}, err
+	}
+
+	for i := 0; i < len(employments); i++ {
+		employments[i].Type = "employments"
+	}
+
+	return employments, nil
+}
+
+/*
+* Update methods
+ */
+
+// Brings the employments of a contact in line with its Employers and
+// PastEmployers. Jobs no longer in Employers are closed, new ones are opened
+// and past employers nothing is known about get a closed employment without
+// dates.
+func syncEmployments(contact models.Contact, source string) ([]models.Employment, error) {
+	employments, err := filterEmploymentsForContact(contact.Id)
+	if err != nil {
+		return []models.Employment{}, err
+	}
+
+	now := time.Now()
+	current := map[int64]bool{}
+	for i := 0; i < len(contact.Employers); i++ {
+		current[contact.Employers[i]] = true
+	}
+
+	open := map[int64]bool{}
+	known := map[int64]bool{}
+	for i := 0; i < len(employments); i++ {
+		known[employments[i].PublicationId] = true
+		if !employments[i].Current {
+			continue
+		}
+
+		if current[employments[i].PublicationId] {
+			open[employments[i].PublicationId] = true
+			continue
+		}
+
+		employments[i].Close(now)
+		_, err = employments[i].Save()
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Employment{}, err
+		}
+	}
+
+	newEmployments := []models.Employment{}
+	for _, publicationId := range contact.Employers {
+		if open[publicationId] {
+			continue
+		}
+		open[publicationId] = true
+
+		employment := models.Employment{
+			ContactId:     contact.Id,
+			PublicationId: publicationId,
+			Current:       true,
+			Source:        source,
+		}
+		// Only changes seen as they happen have a known date
+		if source != models.EmploymentSourceLegacy {
+			employment.StartDate = now
+		}
+		newEmployments = append(newEmployments, employment)
+	}
+
+	for _, publicationId := range contact.PastEmployers {
+		if known[publicationId] || open[publicationId] {
+			continue
+		}
+		known[publicationId] = true
+
+		newEmployments = append(newEmployments, models.Employment{
+			ContactId:     contact.Id,
+			PublicationId: publicationId,
+			Source:        models.EmploymentSourceLegacy,
+		})
+	}
+
+	if len(newEmployments) == 0 {
+		return employments, nil
+	}
+
+	for i := 0; i < len(newEmployments); i++ {
+		newEmployments[i].CreatedBy = contact.CreatedBy
+		newEmployments[i].Created = now
+		newEmployments[i].Updated = now
+	}
+
+	_, err = db.DB.Model(&newEmployments).Returning("*").Insert()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Employment{}, err
+	}
+
+	return filterEmploymentsForContact(contact.Id)
+}
+
+// Employments for contacts that have just been inserted, from their
+// Employers and PastEmployers. When the jobs started isn't known, so they
+// have no dates. Children are left out, their jobs are their master's.
+func newContactsEmployments(contacts []models.Contact, source string) []models.Employment {
+	now := time.Now()
+	employments := []models.Employment{}
+	for i := 0; i < len(contacts); i++ {
+		if contacts[i].ParentContact != 0 {
+			continue
+		}
+
+		known := map[int64]bool{}
+		addEmployment := func(publicationId int64, current bool) {
+			if known[publicationId] {
+				return
+			}
+			known[publicationId] = true
+
+			employment := models.Employment{
+				ContactId:     contacts[i].Id,
+				PublicationId: publicationId,
+				Current:       current,
+				Source:        source,
+			}
+			employment.CreatedBy = contacts[i].CreatedBy
+			employment.Created = now
+			employment.Updated = now
+			employments = append(employments, employment)
+		}
+
+		for j := 0; j < len(contacts[i].Employers); j++ {
+			addEmployment(contacts[i].Employers[j], true)
+		}
+		for j := 0; j < len(contacts[i].PastEmployers); j++ {
+			addEmployment(contacts[i].PastEmployers[j], false)
+		}
+	}
+	return employments
+}
+
+// Sets Employers and PastEmployers of the contact from its employments
+func updateEmployersFromEmployments(r *http.Request, contact *models.Contact) error {
+	employments, err := filterEmploymentsForContact(contact.Id)
+	if err != nil {
+		return err
+	}
+
+	employers := []int64{}
+	pastEmployers := []int64{}
+	for i := 0; i < len(employments); i++ {
+		if employments[i].Current {
+			employers = appendUniqueIds(employers, employments[i].PublicationId)
+		}
+	}
+	for i := 0; i < len(employments); i++ {
+		if !employments[i].Current {
+			isCurrent := false
+			for x := 0; x < len(employers); x++ {
+				if employers[x] == employments[i].PublicationId {
+					isCurrent = true
+				}
+			}
+			if !isCurrent {
+				pastEmployers = appendUniqueIds(pastEmployers, employments[i].PublicationId)
+			}
+		}
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	contact.Employers = employers
+	contact.PastEmployers = pastEmployers
+	_, err = contact.SaveWithHistory(r, user.Id, models.ContactChangeSourceManual)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	if contact.IsMasterContact {
+		err = contact.PropagateToChildren(user.Id)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	return nil
+}
+
+// Fills in the title of the current job of each contact for list views.
+// Children show the title of their master.
+func setCurrentTitles(contacts []models.Contact) {
+	contactIds := []int64{}
+	for i := 0; i < len(contacts); i++ {
+		if contacts[i].ParentContact != 0 {
+			contactIds = append(contactIds, contacts[i].ParentContact)
+		} else {
+			contactIds = append(contactIds, contacts[i].Id)
+		}
+	}
+
+	if len(contactIds) == 0 {
+		return
+	}
+
+	employments := []models.Employment{}
+	err := db.DB.Model(&employments).Where("contact_id IN (?)", pg.In(contactIds)).Where("current = ?", true).Where("title != ''").Order("start_date DESC NULLS LAST", "id DESC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+
+	titles := map[int64]string{}
+	for i := 0; i < len(employments); i++ {
+		if _, ok := titles[employments[i].ContactId]; !ok {
+			titles[employments[i].ContactId] = employments[i].Title
+		}
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		if contacts[i].ParentContact != 0 {
+			contacts[i].CurrentTitle = titles[contacts[i].ParentContact]
+		} else {
+			contacts[i].CurrentTitle = titles[contacts[i].Id]
+		}
+	}
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Gets the jobs of a contact, current ones first. Children show the timeline
+// of their master.
+func GetEmploymentsForContact(r *http.Request, id string) ([]models.Employment, interface{}, int, int, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Employment{}, nil, 0, 0, err
+	}
+
+	if contact.ParentContact != 0 {
+		contact, err = getContact(r, contact.ParentContact)
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Employment{}, nil, 0, 0, err
+		}
+	}
+
+	employments, err := filterEmploymentsForContact(contact.Id)
+	if err != nil {
+		return []models.Employment{}, nil, 0, 0, err
+	}
+
+	publicationIds := []int64{}
+	for i := 0; i < len(employments); i++ {
+		publicationIds = appendUniqueIds(publicationIds, employments[i].PublicationId)
+	}
+	publications := contactsToPublications([]models.Contact{{Employers: publicationIds}})
+
+	return employments, publications, len(employments), len(employments), nil
+}
+
+func GetEmployment(r *http.Request, id string) (models.Employment, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	employment, _, err := getEmployment(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	return employment, nil, nil
+}
+
+/*
+* Create methods
+ */
+
+// Adds a job to a contact. Jobs without an end date are current.
+func CreateEmployment(r *http.Request) (models.Employment, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var employment models.Employment
+	err := decoder.Decode(buf, &employment)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	contact, err := getContactForEmployment(r, employment.ContactId)
+	if err != nil {
+		return models.Employment{}, nil, err
+	}
+
+	// A duplicate that has been merged is recorded as the publication it
+	// was merged into
+	publication, err := getPublication(employment.PublicationId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, errors.New("No publication by this id")
+	}
+	employment.PublicationId = publication.Id
+
+	employment.Current = employment.EndDate.IsZero()
+	if employment.Current {
+		employments, err := filterEmploymentsForContact(contact.Id)
+		if err != nil {
+			return models.Employment{}, nil, err
+		}
+		for i := 0; i < len(employments); i++ {
+			if employments[i].Current && employments[i].PublicationId == employment.PublicationId {
+				return models.Employment{}, nil, errors.New("Contact already works at this publication")
+			}
+		}
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	_, err = employment.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	err = updateEmployersFromEmployments(r, &contact)
+	if err != nil {
+		return models.Employment{}, nil, err
+	}
+
+	employment.Type = "employments"
+	return employment, nil, nil
+}
+
+/*
+* Update methods
+ */
+
+// Opens employments for the jobs contacts had before employments were kept.
+// Children are left out, their jobs are their master's.
+func BackfillEmployments() error {
+	lastId := int64(0)
+	for {
+		contacts := []models.Contact{}
+		err := db.DB.Model(&contacts).Where("id > ?", lastId).Where("is_deleted = ?", false).Order("id ASC").Limit(employmentBackfillBatch).Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		if len(contacts) == 0 {
+			return nil
+		}
+
+		for i := 0; i < len(contacts); i++ {
+			lastId = contacts[i].Id
+			if contacts[i].ParentContact != 0 || (len(contacts[i].Employers) == 0 && len(contacts[i].PastEmployers) == 0) {
+				continue
+			}
+
+			_, err = syncEmployments(contacts[i], models.EmploymentSourceLegacy)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func UpdateEmployment(r *http.Request, id string) (models.Employment, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	employment, _, err := getEmployment(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	contact, err := getContactForEmployment(r, employment.ContactId)
+	if err != nil {
+		return models.Employment{}, nil, err
+	}
+
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var updatedEmployment models.Employment
+	err = decoder.Decode(buf, &updatedEmployment)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	utilities.UpdateIfNotBlank(&employment.Title, updatedEmployment.Title)
+	utilities.UpdateIfNotBlank(&employment.Beat, updatedEmployment.Beat)
+
+	if !updatedEmployment.StartDate.IsZero() {
+		employment.StartDate = updatedEmployment.StartDate
+	}
+
+	// Giving a current job an end date closes it
+	closed := false
+	if !updatedEmployment.EndDate.IsZero() {
+		closed = employment.Current
+		employment.Close(updatedEmployment.EndDate)
+	}
+
+	_, err = employment.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	if closed {
+		err = updateEmployersFromEmployments(r, &contact)
+		if err != nil {
+			return models.Employment{}, nil, err
+		}
+	}
+
+	return employment, nil, nil
+}
+
+/*
+* Action methods
+ */
+
+// Ends a current job, moving the publication to the contact's past employers
+func EndEmployment(r *http.Request, id string) (models.Employment, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	employment, _, err := getEmployment(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	if !employment.Current {
+		return models.Employment{}, nil, errors.New("Employment has already ended")
+	}
+
+	contact, err := getContactForEmployment(r, employment.ContactId)
+	if err != nil {
+		return models.Employment{}, nil, err
+	}
+
+	var endDetails endEmploymentDetails
+	buf, _ := ioutil.ReadAll(r.Body)
+	if len(buf) > 0 {
+		decoder := ffjson.NewDecoder()
+		err = decoder.Decode(buf, &endDetails)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Employment{}, nil, err
+		}
+	}
+
+	employment.Close(endDetails.EndDate)
+	_, err = employment.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Employment{}, nil, err
+	}
+
+	err = updateEmployersFromEmployments(r, &contact)
+	if err != nil {
+		return models.Employment{}, nil, err
+	}
+
+	return employment, nil, nil
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/analytics"
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+/*
+* Private methods
+ */
+
+// Applies a tracking event to the contact the email was sent to
+func updateContactEngagement(email models.Email, emailEvent models.EmailEvent) {
+	if email.ContactId == 0 {
+		return
+	}
+
+	contact := models.Contact{}
+	err := db.DB.Model(&contact).Where("id = ?", email.ContactId).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+
+	analytics.ApplyEngagementEvent(&contact, email, emailEvent)
+	analytics.SaveContactEngagement(&contact)
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Update methods
+ */
+
+// Recomputes the engagement of every contact in a list from scratch. Used to
+// backfill lists with emails sent before scores were tracked.
+func CalculateEngagementForList(r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
+	mediaList, _, err := GetMediaList(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	if mediaList.ReadOnly {
+		return []models.Contact{}, nil, 0, 0, errors.New("Forbidden")
+	}
+
+	if len(mediaList.Contacts) == 0 {
+		return []models.Contact{}, nil, 0, 0, nil
+	}
+
+	contacts := []models.Contact{}
+	err = db.DB.Model(&contacts).Where("id IN (?)", pg.In(mediaList.Contacts)).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		err = analytics.CalculateContactEngagement(&contacts[i])
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Contact{}, nil, 0, 0, err
+		}
+
+		err = analytics.SaveContactEngagement(&contacts[i])
+		if err != nil {
+			return []models.Contact{}, nil, 0, 0, err
+		}
+
+		contacts[i].Type = "contacts"
+	}
+
+	return contacts, nil, len(contacts), len(contacts), nil
+}
@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+	gcontext "github.com/gorilla/context"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/feeds"
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+var (
+	// How long looking through the sites of one contact or publication may
+	// take, since every candidate feed is fetched
+	feedDiscoveryTimeout = 2 * time.Minute
+
+	// Discoveries running in the background at once, so a bulk update
+	// doesn't crawl hundreds of sites together
+	feedDiscoverySlots = make(chan struct{}, 4)
+
+	// Contacts made in bulk, like by imports, wait here and are gone through
+	// by a single worker of their own, so they never hold up discovery for
+	// contacts saved by hand. Batches that don't fit are dropped.
+	bulkFeedDiscoveryQueue = make(chan bulkFeedDiscovery, 100)
+	bulkFeedDiscoveryOnce  sync.Once
+)
+
+type bulkFeedDiscovery struct {
+	userId   int64
+	contacts []models.Contact
+}
+
+/*
+* Private methods
+ */
+
+func contactSites(contact models.Contact) []string {
+	sites := []string{}
+	if contact.Blog != "" {
+		sites = append(sites, contact.Blog)
+	}
+	if contact.Website != "" && contact.Website != contact.Blog {
+		sites = append(sites, contact.Website)
+	}
+	return sites
+}
+
+// Sites of the contact that are their own. The website of a contact is
+// often just the site of where they work, and its feed has everyone's
+// stories in it, so sites on the domain of one of their employers are left
+// to the publication.
+func contactFeedSites(contact models.Contact) ([]string, error) {
+	sites := contactSites(contact)
+	employerIds := append(append([]int64{}, contact.Employers...), contact.PastEmployers...)
+	if len(sites) == 0 || len(employerIds) == 0 {
+		return sites, nil
+	}
+
+	employers := []models.Publication{}
+	err := db.DB.Model(&employers).Column("id", "url", "website").Where("id IN (?)", pg.In(employerIds)).Select()
+	if err != nil {
+		return []string{}, err
+	}
+
+	employerDomains := map[string]bool{}
+	for i := 0; i < len(employers); i++ {
+		domain := normalizePublicationDomain(employers[i])
+		if domain != "" {
+			employerDomains[domain] = true
+		}
+	}
+
+	ownSites := []string{}
+	for i := 0; i < len(sites); i++ {
+		if !employerDomains[normalizeSiteDomain(sites[i])] {
+			ownSites = append(ownSites, sites[i])
+		}
+	}
+	return ownSites, nil
+}
+
+// Candidates for every site, leaving out feeds the user already has for the
+// contact or publication
+func findFeedCandidates(ctx context.Context, userId int64, queryType string, resourceId int64, sites []string) [][]feeds.Candidate {
+	candidates := [][]feeds.Candidate{}
+	for i := 0; i < len(sites); i++ {
+		siteCandidates, err := feeds.Discover(ctx, sites[i])
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+
+		newCandidates := []feeds.Candidate{}
+		for j := 0; j < len(siteCandidates); j++ {
+			count, err := db.DB.Model(&models.Feed{}).Where("feed_url = ?", siteCandidates[j].Url).Where("created_by = ?", userId).Where(queryType+" = ?", resourceId).Count()
+			if err != nil {
+				log.Printf("%v", err)
+				continue
+			}
+			if count == 0 {
+				newCandidates = append(newCandidates, siteCandidates[j])
+			}
+		}
+		candidates = append(candidates, newCandidates)
+	}
+	return candidates
+}
+
+// Keeps the first candidate of every site as a feed. Sites that list more
+// than one feed mostly list the main one first, and comment feeds after it.
+func createDiscoveredFeeds(r *http.Request, candidates [][]feeds.Candidate, feed models.Feed) ([]models.Feed, error) {
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		return []models.Feed{}, err
+	}
+
+	newFeeds := []models.Feed{}
+	for i := 0; i < len(candidates); i++ {
+		if len(candidates[i]) == 0 {
+			continue
+		}
+
+		newFeed := feed
+		newFeed.FeedURL = candidates[i][0].Url
+		newFeed.Title = candidates[i][0].Title
+		newFeed.Source = models.FeedSourceDiscovered
+
+		if newFeed.PublicationId == 0 {
+			publication, err := feedPublication(r, newFeed.FeedURL)
+			if err != nil {
+				log.Printf("%v", err)
+			} else {
+				newFeed.PublicationId = publication.Id
+			}
+		}
+
+		_, err = newFeed.Create(r, currentUser)
+		if err != nil {
+			return newFeeds, err
+		}
+		newFeed.Type = "feeds"
+		newFeeds = append(newFeeds, newFeed)
+	}
+
+	// New feeds are due right away
+	if len(newFeeds) > 0 {
+		feeds.WakeFeedPollers()
+	}
+
+	return newFeeds, nil
+}
+
+func discoverContactFeeds(r *http.Request, ctx context.Context, contact models.Contact) ([]models.Feed, error) {
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		return []models.Feed{}, err
+	}
+
+	sites, err := contactFeedSites(contact)
+	if err != nil {
+		return []models.Feed{}, err
+	}
+
+	candidates := findFeedCandidates(ctx, currentUser.Id, "contact_id", contact.Id, sites)
+	return createDiscoveredFeeds(r, candidates, models.Feed{ContactId: contact.Id, ListId: contact.ListId})
+}
+
+func discoverPublicationFeeds(r *http.Request, ctx context.Context, publication models.Publication) ([]models.Feed, error) {
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		return []models.Feed{}, err
+	}
+
+	candidates := findFeedCandidates(ctx, currentUser.Id, "publication_id", publication.Id, []string{publication.Url})
+	return createDiscoveredFeeds(r, candidates, models.Feed{PublicationId: publication.Id})
+}
+
+// Runs discovery after the response has gone out. Failures are only
+// logged, the feeds can be looked for again through the discover actions.
+func discoverFeedsInBackground(userId int64, discover func(r *http.Request, ctx context.Context) ([]models.Feed, error)) {
+	go func() {
+		feedDiscoverySlots <- struct{}{}
+		defer func() { <-feedDiscoverySlots }()
+
+		r, err := UserRequest("/feeds/discover", userId)
+		if err != nil {
+			log.Printf("%v", err)
+			return
+		}
+		defer gcontext.Clear(r)
+
+		ctx, cancel := context.WithTimeout(context.Background(), feedDiscoveryTimeout)
+		defer cancel()
+
+		_, err = discover(r, ctx)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}()
+}
+
+// Called when a contact is saved with a new website or blog
+func discoverContactFeedsLater(userId int64, contact models.Contact) {
+	if len(contactSites(contact)) == 0 {
+		return
+	}
+
+	discoverFeedsInBackground(userId, func(r *http.Request, ctx context.Context) ([]models.Feed, error) {
+		return discoverContactFeeds(r, ctx, contact)
+	})
+}
+
+func bulkFeedDiscoveryWorker() {
+	for discovery := range bulkFeedDiscoveryQueue {
+		r, err := UserRequest("/feeds/discover", discovery.userId)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+
+		for i := 0; i < len(discovery.contacts); i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), feedDiscoveryTimeout)
+			_, err = discoverContactFeeds(r, ctx, discovery.contacts[i])
+			cancel()
+			if err != nil {
+				log.Printf("%v", err)
+			}
+		}
+		gcontext.Clear(r)
+	}
+}
+
+// Called when contacts are created, like by an import. They are queued for
+// the bulk worker rather than taking up the slots.
+func discoverNewContactsFeedsLater(userId int64, contacts []models.Contact) {
+	withSites := []models.Contact{}
+	for i := 0; i < len(contacts); i++ {
+		if len(contactSites(contacts[i])) > 0 {
+			withSites = append(withSites, contacts[i])
+		}
+	}
+	if len(withSites) == 0 {
+		return
+	}
+
+	bulkFeedDiscoveryOnce.Do(func() {
+		go bulkFeedDiscoveryWorker()
+	})
+
+	select {
+	case bulkFeedDiscoveryQueue <- bulkFeedDiscovery{userId: userId, contacts: withSites}:
+	default:
+		log.Printf("Feed discovery queue is full, skipped %d contacts", len(withSites))
+	}
+}
+
+// Called when a publication is saved with a new url
+func discoverPublicationFeedsLater(userId int64, publication models.Publication) {
+	if publication.Url == "" {
+		return
+	}
+
+	discoverFeedsInBackground(userId, func(r *http.Request, ctx context.Context) ([]models.Feed, error) {
+		return discoverPublicationFeeds(r, ctx, publication)
+	})
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Feeds found on the website and blog of a contact, without keeping them.
+// Sites of the contact's employers are left out.
+func GetFeedCandidatesForContact(r *http.Request, id string) ([]feeds.Candidate, interface{}, int, int, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []feeds.Candidate{}, nil, 0, 0, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []feeds.Candidate{}, nil, 0, 0, err
+	}
+
+	sites, err := contactFeedSites(contact)
+	if err != nil {
+		log.Printf("%v", err)
+		return []feeds.Candidate{}, nil, 0, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedDiscoveryTimeout)
+	defer cancel()
+
+	candidates := []feeds.Candidate{}
+	for _, siteCandidates := range findFeedCandidates(ctx, currentUser.Id, "contact_id", contact.Id, sites) {
+		candidates = append(candidates, siteCandidates...)
+	}
+
+	return candidates, nil, len(candidates), 0, nil
+}
+
+// Feeds found on the site of a publication, without keeping them
+func GetFeedCandidatesForPublication(r *http.Request, id string) ([]feeds.Candidate, interface{}, int, int, error) {
+	publication, _, err := GetPublication(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []feeds.Candidate{}, nil, 0, 0, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []feeds.Candidate{}, nil, 0, 0, err
+	}
+
+	candidates := []feeds.Candidate{}
+	if publication.Url == "" {
+		return candidates, nil, 0, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedDiscoveryTimeout)
+	defer cancel()
+
+	for _, siteCandidates := range findFeedCandidates(ctx, currentUser.Id, "publication_id", publication.Id, []string{publication.Url}) {
+		candidates = append(candidates, siteCandidates...)
+	}
+
+	return candidates, nil, len(candidates), 0, nil
+}
+
+/*
+* Action methods
+ */
+
+// Looks for feeds on the website and blog of a contact now and keeps the
+// ones found
+func DiscoverFeedsForContact(r *http.Request, id string) ([]models.Feed, interface{}, int, int, error) {
+	contact, _, err := GetContact(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Feed{}, nil, 0, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedDiscoveryTimeout)
+	defer cancel()
+
+	newFeeds, err := discoverContactFeeds(r, ctx, contact)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Feed{}, nil, 0, 0, err
+	}
+
+	return newFeeds, nil, len(newFeeds), 0, nil
+}
+
+// Looks for feeds on the site of a publication now and keeps the ones found
+func DiscoverFeedsForPublication(r *http.Request, id string) ([]models.Feed, interface{}, int, int, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Feed{}, nil, 0, 0, err
+	}
+
+	publication, err := getPublication(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Feed{}, nil, 0, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedDiscoveryTimeout)
+	defer cancel()
+
+	newFeeds, err := discoverPublicationFeeds(r, ctx, publication)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Feed{}, nil, 0, 0, err
+	}
+
+	return newFeeds, nil, len(newFeeds), 0, nil
+}
@@ -6,17 +6,21 @@ import (
 	"log"
 	"net/http"
 
+	gcontext "github.com/gorilla/context"
 	"github.com/pquerna/ffjson/ffjson"
 
 	"github.com/news-ai/api-v1/controllers"
 	"github.com/news-ai/api-v1/db"
 
+	"github.com/news-ai/tabulae-v1/feeds"
 	"github.com/news-ai/tabulae-v1/models"
-	// "github.com/news-ai/tabulae-v1/sync"
 
 	"github.com/news-ai/web/utilities"
 )
 
+// Headlines of a feed gone through again when attributing, newest first
+var headlineAttributionLimit = 1000
+
 /*
 * Private methods
  */
@@ -45,6 +49,58 @@ func getFeed(id int64) (models.Feed, error) {
 	return models.Feed{}, errors.New("No feed by this id")
 }
 
+// The publication a feed comes from, going by the site it is on
+func feedPublication(r *http.Request, feedURL string) (models.Publication, error) {
+	baseDomain, err := utilities.NormalizeUrl(feedURL)
+	if err != nil {
+		return models.Publication{}, err
+	}
+
+	publicationName, err := utilities.GetTitleFromHTTPRequest(baseDomain)
+	if err != nil {
+		publicationName, err = utilities.GetDomainName(baseDomain)
+		if err != nil {
+			return models.Publication{}, err
+		}
+	}
+
+	publication, err := FindOrCreatePublication(r, publicationName, baseDomain)
+	if err != nil {
+		return models.Publication{}, err
+	}
+
+	// A publication found by its name keeps the url it already has
+	if publication.Url == "" {
+		publication.Url = baseDomain
+		_, err = publication.Save()
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Publication{}, err
+		}
+	}
+
+	return publication, nil
+}
+
+// Headlines of a feed or publication, newest first
+func getHeadlines(r *http.Request, queryType string, resourceId int64) ([]models.Headline, interface{}, int, int, error) {
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	headlines := []models.Headline{}
+	total, err := db.DB.Model(&headlines).Where(queryType+" = ?", resourceId).Order("published DESC", "id DESC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Headline{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(headlines); i++ {
+		headlines[i].Type = "headlines"
+	}
+
+	return headlines, nil, len(headlines), total, nil
+}
+
 func filterFeeds(r *http.Request, queryType, query string) ([]models.Feed, error) {
 	feeds := []models.Feed{}
 	err := db.DB.Model(&feeds).Where(queryType+" = ?", query).Select()
@@ -130,6 +186,23 @@ func GetFeedsByResourceId(r *http.Request, resouceName string, resourceId int64)
 	return feeds, nil
 }
 
+// Entries of a feed, newest first
+func GetHeadlinesForFeed(r *http.Request, id string) ([]models.Headline, interface{}, int, int, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Headline{}, nil, 0, 0, err
+	}
+
+	feed, err := getFeed(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Headline{}, nil, 0, 0, err
+	}
+
+	return getHeadlines(r, "feed_id", feed.Id)
+}
+
 func FilterFeeds(r *http.Request, queryType, query string) ([]models.Feed, error) {
 	// User has to be logged in
 	_, err := controllers.GetCurrentUser(r)
@@ -160,54 +233,133 @@ func CreateFeed(r *http.Request) (models.Feed, interface{}, error) {
 		return feed, nil, err
 	}
 
-	feeds := []models.Feed{}
-	err = db.DB.Model(&feeds).Where("feed_url = ?", feed.FeedURL).Where("created_by = ?", currentUser.Id).Where("contact_id = ?", feed.ContactId).Select()
+	presentFeeds := []models.Feed{}
+	err = db.DB.Model(&presentFeeds).Where("feed_url = ?", feed.FeedURL).Where("created_by = ?", currentUser.Id).Where("contact_id = ?", feed.ContactId).Select()
 	if err != nil {
 		log.Printf("%v", err)
 		return models.Feed{}, nil, err
 	}
 
-	if len(feeds) > 0 {
+	if len(presentFeeds) > 0 {
 		return models.Feed{}, nil, errors.New("Feed already exits for the contact")
 	}
 
-	baseDomain, err := utilities.NormalizeUrl(feed.FeedURL)
+	publication, err := feedPublication(r, feed.FeedURL)
 	if err != nil {
 		log.Printf("%v", err)
 		return models.Feed{}, nil, err
 	}
 
-	publicationName, err := utilities.GetTitleFromHTTPRequest(baseDomain)
+	feed.PublicationId = publication.Id
+	feed.Source = models.FeedSourceManual
+
+	// Create feed
+	_, err = feed.Create(r, currentUser)
 	if err != nil {
-		publicationName, err = utilities.GetDomainName(baseDomain)
-		if err != nil {
-			log.Printf("%v", err)
-			return models.Feed{}, nil, err
-		}
+		log.Printf("%v", err)
+		return models.Feed{}, nil, err
 	}
 
-	publication, err := FindOrCreatePublication(r, publicationName, baseDomain)
+	// New feeds are due right away
+	feeds.WakeFeedPollers()
+
+	return feed, nil, nil
+}
+
+/*
+* Action methods
+ */
+
+// Fetches a feed now instead of waiting for the poller. Feeds that stopped
+// being polled after failing are started again.
+func FetchFeed(r *http.Request, id string) (models.Feed, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
 	if err != nil {
 		log.Printf("%v", err)
 		return models.Feed{}, nil, err
 	}
 
-	publication.Url = baseDomain
-	publication.Save()
+	feed, err := getFeed(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Feed{}, nil, err
+	}
 
-	feed.PublicationId = publication.Id
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Feed{}, nil, err
+	}
 
-	// Create feed
-	_, err = feed.Create(r, currentUser)
+	if feed.CreatedBy != user.Id && !user.Data.IsAdmin {
+		return models.Feed{}, nil, errors.New("Forbidden")
+	}
+
+	feed.Running = true
+	feed.Failures = 0
+	headlines, err := feeds.PollFeed(r.Context(), &feed)
 	if err != nil {
 		log.Printf("%v", err)
 		return models.Feed{}, nil, err
 	}
 
-	// Run new feed through pub/sub
-	// sync.NewRSSFeedSync(r, feed.FeedURL, feed.PublicationId)
+	for i := 0; i < len(headlines); i++ {
+		headlines[i].Type = "headlines"
+	}
 
-	return feed, nil, nil
+	return feed, headlines, nil
+}
+
+// Goes through the headlines of the user's feeds of a publication again,
+// for contacts added or moved there since they came in
+func AttributeHeadlinesForPublication(r *http.Request, id string) ([]models.HeadlineAttribution, interface{}, int, int, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.HeadlineAttribution{}, nil, 0, 0, err
+	}
+
+	publication, err := getPublication(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.HeadlineAttribution{}, nil, 0, 0, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.HeadlineAttribution{}, nil, 0, 0, err
+	}
+
+	publicationFeeds := []models.Feed{}
+	err = db.DB.Model(&publicationFeeds).Where("publication_id = ?", publication.Id).Where("contact_id = ?", 0).Where("created_by = ?", user.Id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.HeadlineAttribution{}, nil, 0, 0, err
+	}
+
+	attributions := []models.HeadlineAttribution{}
+	for i := 0; i < len(publicationFeeds); i++ {
+		headlines := []models.Headline{}
+		err = db.DB.Model(&headlines).Where("feed_id = ?", publicationFeeds[i].Id).Order("published DESC").Limit(headlineAttributionLimit).Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.HeadlineAttribution{}, nil, 0, 0, err
+		}
+
+		newAttributions, err := feeds.AttributeHeadlines(publicationFeeds[i], headlines)
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.HeadlineAttribution{}, nil, 0, 0, err
+		}
+		attributions = append(attributions, newAttributions...)
+	}
+
+	for i := 0; i < len(attributions); i++ {
+		attributions[i].Type = "headlineattributions"
+	}
+
+	return attributions, nil, len(attributions), 0, nil
 }
 
 /*
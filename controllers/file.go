@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
 
@@ -142,7 +141,7 @@ func GetFileByIdUnauthorized(r *http.Request, id int64) (models.File, interface{
 
 func FilterFileByImported(r *http.Request) ([]models.File, error) {
 	files := []models.File{}
-	err := db.DB.Model(&files).Where("imported = ?", true).Select()
+	err := db.DB.Model(&files).Where("status = ?", models.FileStatusImported).Select()
 	if err != nil {
 		log.Printf("%v", err)
 		return []models.File{}, err
@@ -167,6 +166,29 @@ func FilterFileByImported(r *http.Request) ([]models.File, error) {
 	return nonImageFiles, nil
 }
 
+// Gets the most recent files the user has mapped the headers of, to learn
+// how they usually map them
+func FilterFilesWithHeadersForUser(r *http.Request, limit int) ([]models.File, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.File{}, err
+	}
+
+	files := []models.File{}
+	err = db.DB.Model(&files).Where("created_by = ?", user.Id).Where("header_names IS NOT NULL").Where("\"order\" IS NOT NULL").Order("id DESC").Limit(limit).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.File{}, err
+	}
+
+	for i := 0; i < len(files); i++ {
+		files[i].Type = "files"
+	}
+
+	return files, nil
+}
+
 /*
 * Create methods
  */
@@ -216,7 +238,7 @@ func CreateFile(r *http.Request, fileName string, listid string, createdby strin
 	return file, nil
 }
 
-func CreateImageFile(r *http.Request, originalFilename string, fileName string, createdby string, bucket string) (models.File, error) {
+func CreateImageFile(r *http.Request, originalFilename string, fileName string, createdby string, url string) (models.File, error) {
 	// Convert listId and createdById from string to int64
 	createdBy, err := utilities.StringIdToInt(createdby)
 	if err != nil {
@@ -224,15 +246,13 @@ func CreateImageFile(r *http.Request, originalFilename string, fileName string,
 		return models.File{}, err
 	}
 
-	publicURL := "https://storage.googleapis.com/%s/%s"
-
 	// Initialize file
 	file := models.File{}
 	file.OriginalName = originalFilename
 	file.FileName = fileName
 	file.CreatedBy = createdBy
 	file.FileExists = true
-	file.Url = fmt.Sprintf(publicURL, bucket, fileName)
+	file.Url = url
 
 	currentUser, err := controllers.GetCurrentUser(r)
 	if err != nil {
@@ -0,0 +1,454 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// Returned when a job was cancelled while a chunk of it was being imported
+var ErrImportJobCancelled = errors.New("Import job was cancelled")
+
+// Returned to a worker whose job was taken over by another worker after it
+// went too long without a heartbeat
+var ErrImportJobTakenOver = errors.New("Import job was taken over by another worker")
+
+// Errors kept on a job. RowsFailed keeps counting past this.
+var maxImportJobErrors = 1000
+
+/*
+* Private methods
+ */
+
+/*
+* Get methods
+ */
+
+func getImportJob(r *http.Request, id int64) (models.ImportJob, error) {
+	if id == 0 {
+		return models.ImportJob{}, errors.New("datastore: no such entity")
+	}
+
+	importJob := models.ImportJob{}
+	err := db.DB.Model(&importJob).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, err
+	}
+
+	if !importJob.Created.IsZero() {
+		importJob.Type = "importjobs"
+
+		user, err := controllers.GetCurrentUser(r)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.ImportJob{}, errors.New("Could not get user")
+		}
+
+		if importJob.CreatedBy != user.Id && !user.Data.IsAdmin {
+			return models.ImportJob{}, errors.New("Forbidden")
+		}
+
+		return importJob, nil
+	}
+
+	return models.ImportJob{}, errors.New("No import job by this id")
+}
+
+// Tells why a job is no longer the worker's to write to
+func lostImportJobError(status string, workerId string, importJob *models.ImportJob) error {
+	if status != models.ImportJobStatusRunning {
+		return ErrImportJobCancelled
+	}
+	if workerId != importJob.WorkerId {
+		return ErrImportJobTakenOver
+	}
+	return nil
+}
+
+/*
+* Update methods
+ */
+
+func setFileStatus(fileId int64, status string) error {
+	file := models.File{}
+	file.Id = fileId
+	file.Status = status
+	file.Updated = time.Now()
+	_, err := db.DB.Model(&file).Column("status", "updated").Update()
+	return err
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+func GetImportJob(r *http.Request, id string) (models.ImportJob, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	importJob, err := getImportJob(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	return importJob, nil, nil
+}
+
+func GetImportJobById(r *http.Request, id int64) (models.ImportJob, interface{}, error) {
+	importJob, err := getImportJob(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	return importJob, nil, nil
+}
+
+// Gets the latest import job of a file
+func GetImportJobForFile(r *http.Request, id string) (models.ImportJob, interface{}, error) {
+	fileId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	importJob := models.ImportJob{}
+	err = db.DB.Model(&importJob).Where("file_id = ?", fileId).Order("id DESC").Limit(1).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	return GetImportJobById(r, importJob.Id)
+}
+
+/*
+* Create methods
+ */
+
+// Queues a file to be imported into its list with the given header mapping
+func CreateImportJob(r *http.Request, fileId int64, fileOrder models.FileOrder) (models.ImportJob, interface{}, error) {
+	file, err := getFile(r, fileId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	if file.IsImported() {
+		return models.ImportJob{}, nil, errors.New("File has already been imported")
+	}
+
+	if len(fileOrder.HeaderNames) != len(fileOrder.Order) {
+		return models.ImportJob{}, nil, errors.New("Length of headers does not match length of header names")
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	// Uploads that don't say how to transform their columns get the
+	// transforms of the profile for files like them
+	if fileOrder.Transforms == nil {
+		importProfile, _, err := GetImportProfileForHeaders(r, fileOrder.HeaderNames)
+		if err == nil {
+			fileOrder.Transforms = profileTransforms(importProfile, fileOrder)
+		}
+	}
+
+	importJob := models.ImportJob{}
+	importJob.FileId = file.Id
+	importJob.ListId = file.ListId
+	importJob.HeaderNames = fileOrder.HeaderNames
+	importJob.Order = fileOrder.Order
+	importJob.Transforms = fileOrder.Transforms
+	importJob.Errors = []models.ImportJobError{}
+
+	_, err = importJob.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	file.HeaderNames = fileOrder.HeaderNames
+	file.Order = fileOrder.Order
+	file.Status = models.FileStatusQueued
+	_, err = file.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	importJob.Type = "importjobs"
+	return importJob, nil, nil
+}
+
+/*
+* Update methods
+ */
+
+// Stops a job after the chunk it is working on. Contacts that were already
+// imported stay in the list.
+func CancelImportJob(r *http.Request, id string) (models.ImportJob, interface{}, error) {
+	importJob, _, err := GetImportJob(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	if importJob.IsFinished() {
+		return models.ImportJob{}, nil, errors.New("Import job has already finished")
+	}
+
+	// Only the status is written so progress saved by the worker meanwhile
+	// isn't lost
+	importJob.Status = models.ImportJobStatusCancelled
+	importJob.Finished = time.Now()
+	importJob.Updated = importJob.Finished
+	_, err = db.DB.Model(&importJob).Column("status", "finished", "updated").Update()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	err = setFileStatus(importJob.FileId, models.FileStatusCancelled)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	return importJob, nil, nil
+}
+
+// Queues a cancelled or failed job again. It carries on from its checkpoint.
+func ResumeImportJob(r *http.Request, id string) (models.ImportJob, interface{}, error) {
+	importJob, _, err := GetImportJob(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	if importJob.Status != models.ImportJobStatusCancelled && importJob.Status != models.ImportJobStatusFailed {
+		return models.ImportJob{}, nil, errors.New("Only cancelled or failed import jobs can be resumed")
+	}
+
+	importJob.Status = models.ImportJobStatusQueued
+	importJob.Error = ""
+	importJob.Finished = time.Time{}
+	importJob.Updated = time.Now()
+	_, err = db.DB.Model(&importJob).Column("status", "error", "finished", "updated").Update()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, nil, err
+	}
+
+	err = setFileStatus(importJob.FileId, models.FileStatusQueued)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	return importJob, nil, nil
+}
+
+/*
+* Worker methods
+ */
+
+// Takes the oldest queued job, or a running job whose worker has stopped
+// sending heartbeats, and marks it as running by the worker. Returns false
+// if there is nothing to do.
+func ClaimImportJob(workerId string, staleAfter time.Duration) (models.ImportJob, bool, error) {
+	now := time.Now()
+
+	importJob := models.ImportJob{}
+	res, err := db.DB.Model(&importJob).
+		Set("status = ?", models.ImportJobStatusRunning).
+		Set("worker_id = ?", workerId).
+		Set("heartbeat = ?", now).
+		Set("updated = ?", now).
+		Where("id = (SELECT id FROM import_jobs WHERE status = ? OR (status = ? AND heartbeat < ?) ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED)",
+			models.ImportJobStatusQueued, models.ImportJobStatusRunning, now.Add(-staleAfter)).
+		Returning("*").
+		Update()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportJob{}, false, err
+	}
+
+	if res.RowsAffected() == 0 {
+		return models.ImportJob{}, false, nil
+	}
+
+	if importJob.Started.IsZero() {
+		importJob.Started = now
+		db.DB.Model(&importJob).Column("started").Where("worker_id = ?", workerId).Update()
+	}
+
+	err = setFileStatus(importJob.FileId, models.FileStatusImporting)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	return importJob, true, nil
+}
+
+// Records how many rows the file of a job has once the worker has read it
+func SetImportJobTotal(importJob *models.ImportJob, totalRows int) error {
+	importJob.TotalRows = totalRows
+	importJob.Heartbeat = time.Now()
+	importJob.Updated = importJob.Heartbeat
+	res, err := db.DB.Model(importJob).Column("total_rows", "heartbeat", "updated").Where("status = ?", models.ImportJobStatusRunning).Where("worker_id = ?", importJob.WorkerId).Update()
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrImportJobTakenOver
+	}
+	return nil
+}
+
+// Creates a chunk of contacts, adds them to the list and moves the job's
+// checkpoint forward in one transaction, so a chunk is either imported and
+// recorded or not at all. updateList can add fields to the list as well.
+func CheckpointImportJob(r *http.Request, importJob *models.ImportJob, contacts []models.Contact, rowErrors []models.ImportJobError, checkpoint int, rowsDone int, rowsFailed int, updateList func(*models.MediaList)) error {
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	mediaList, err := getMediaList(r, importJob.ListId)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	now := time.Now()
+	for i := 0; i < len(contacts); i++ {
+		contacts[i].CreatedBy = currentUser.Id
+		contacts[i].Created = now
+		contacts[i].Updated = now
+		contacts[i].ListId = importJob.ListId
+		contacts[i].Normalize()
+		contacts[i].FormatName()
+	}
+
+	err = db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		// The job might have been cancelled, or taken over by another
+		// worker, while the chunk was being read
+		current := models.ImportJob{}
+		err := tx.Model(&current).Column("status", "worker_id").Where("id = ?", importJob.Id).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+		err = lostImportJobError(current.Status, current.WorkerId, importJob)
+		if err != nil {
+			return err
+		}
+
+		// Get the list again within the transaction so changes made to it
+		// meanwhile are kept
+		err = tx.Model(&mediaList).Column("contacts", "fields_map").Where("id = ?", mediaList.Id).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+
+		if len(contacts) > 0 {
+			_, err = tx.Model(&contacts).Returning("*").Insert()
+			if err != nil {
+				return err
+			}
+
+			employments := newContactsEmployments(contacts, models.EmploymentSourceImport)
+			if len(employments) > 0 {
+				_, err = tx.Model(&employments).Insert()
+				if err != nil {
+					return err
+				}
+			}
+
+			for i := 0; i < len(contacts); i++ {
+				mediaList.Contacts = append(mediaList.Contacts, contacts[i].Id)
+			}
+		}
+
+		if updateList != nil {
+			updateList(&mediaList)
+		}
+
+		mediaList.Updated = now
+		_, err = tx.Model(&mediaList).Column("contacts", "fields_map", "updated").Update()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(rowErrors) && len(importJob.Errors) < maxImportJobErrors; i++ {
+			importJob.Errors = append(importJob.Errors, rowErrors[i])
+		}
+		importJob.Checkpoint = checkpoint
+		importJob.RowsDone += rowsDone
+		importJob.RowsFailed += rowsFailed
+		importJob.Heartbeat = now
+		importJob.Updated = now
+		_, err = tx.Model(importJob).Column("errors", "checkpoint", "rows_done", "rows_failed", "heartbeat", "updated").Update()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	discoverNewContactsFeedsLater(currentUser.Id, contacts)
+	return nil
+}
+
+// Marks a job as done, or as failed if err isn't nil
+func FinishImportJob(importJob *models.ImportJob, jobErr error) error {
+	importJob.Status = models.ImportJobStatusCompleted
+	fileStatus := models.FileStatusImported
+	if jobErr != nil {
+		importJob.Status = models.ImportJobStatusFailed
+		importJob.Error = jobErr.Error()
+		fileStatus = models.FileStatusFailed
+	}
+
+	importJob.Finished = time.Now()
+	importJob.Updated = importJob.Finished
+
+	// A job cancelled or taken over meanwhile is left to whoever has it now
+	res, err := db.DB.Model(importJob).Column("status", "error", "finished", "updated").Where("id = ?", importJob.Id).Where("status = ?", models.ImportJobStatusRunning).Where("worker_id = ?", importJob.WorkerId).Update()
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		current := models.ImportJob{}
+		err = db.DB.Model(&current).Column("status", "worker_id").Where("id = ?", importJob.Id).Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+		return lostImportJobError(current.Status, current.WorkerId, importJob)
+	}
+
+	return setFileStatus(importJob.FileId, fileStatus)
+}
@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+/*
+* Private methods
+ */
+
+/*
+* Get methods
+ */
+
+func getImportProfile(r *http.Request, id int64) (models.ImportProfile, error) {
+	if id == 0 {
+		return models.ImportProfile{}, errors.New("datastore: no such entity")
+	}
+
+	importProfile := models.ImportProfile{}
+	err := db.DB.Model(&importProfile).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, err
+	}
+
+	if importProfile.Created.IsZero() {
+		return models.ImportProfile{}, errors.New("No import profile by this id")
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, errors.New("Could not get user")
+	}
+
+	// Profiles are shared with the team of the user that made them
+	if importProfile.CreatedBy != user.Id && !user.Data.IsAdmin {
+		if importProfile.TeamId == 0 || user.Data.TeamId == 0 || importProfile.TeamId != user.Data.TeamId {
+			return models.ImportProfile{}, errors.New("Forbidden")
+		}
+	}
+
+	importProfile.Type = "importprofiles"
+	return importProfile, nil
+}
+
+func validateImportProfile(importProfile models.ImportProfile) error {
+	if strings.TrimSpace(importProfile.Name) == "" {
+		return errors.New("Import profile needs a name")
+	}
+
+	if len(importProfile.HeaderNames) == 0 || len(importProfile.HeaderNames) != len(importProfile.Order) {
+		return errors.New("Length of headers does not match length of header names")
+	}
+
+	for _, transform := range importProfile.Transforms {
+		switch transform.Transform {
+		case models.ImportTransformSplitName, models.ImportTransformStripAt, models.ImportTransformTags:
+		default:
+			return errors.New("Unknown transform " + transform.Transform)
+		}
+	}
+
+	return nil
+}
+
+// Transforms of a profile for the columns an upload maps onto the same
+// fields as the profile does
+func profileTransforms(importProfile models.ImportProfile, fileOrder models.FileOrder) []models.ImportTransform {
+	fields := map[string]string{}
+	for i := 0; i < len(importProfile.HeaderNames) && i < len(importProfile.Order); i++ {
+		fields[models.NormalizeHeaderName(importProfile.HeaderNames[i])] = importProfile.Order[i]
+	}
+
+	transforms := []models.ImportTransform{}
+	for _, transform := range importProfile.Transforms {
+		name := models.NormalizeHeaderName(transform.Column)
+		for i := 0; i < len(fileOrder.HeaderNames) && i < len(fileOrder.Order); i++ {
+			if models.NormalizeHeaderName(fileOrder.HeaderNames[i]) == name && fileOrder.Order[i] == fields[name] {
+				transforms = append(transforms, models.ImportTransform{Column: fileOrder.HeaderNames[i], Transform: transform.Transform})
+				break
+			}
+		}
+	}
+	return transforms
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Gets the profiles of the user and of their team
+func GetImportProfiles(r *http.Request) ([]models.ImportProfile, interface{}, int, int, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.ImportProfile{}, nil, 0, 0, err
+	}
+
+	importProfiles := []models.ImportProfile{}
+	query := db.DB.Model(&importProfiles)
+	if user.Data.TeamId != 0 {
+		query = query.Where("created_by = ? OR team_id = ?", user.Id, user.Data.TeamId)
+	} else {
+		query = query.Where("created_by = ?", user.Id)
+	}
+
+	err = query.Order("name ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.ImportProfile{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(importProfiles); i++ {
+		importProfiles[i].Type = "importprofiles"
+	}
+
+	return importProfiles, nil, len(importProfiles), 0, nil
+}
+
+func GetImportProfile(r *http.Request, id string) (models.ImportProfile, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	importProfile, err := getImportProfile(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	return importProfile, nil, nil
+}
+
+// Finds the profile for files with these headers. Profiles made by the user
+// win over the ones made by their team, and newer ones over older ones.
+func GetImportProfileForHeaders(r *http.Request, headerNames []string) (models.ImportProfile, interface{}, error) {
+	importProfiles, _, _, _, err := GetImportProfiles(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	fingerprint := models.HeaderFingerprint(headerNames)
+	matched := models.ImportProfile{}
+	for _, importProfile := range importProfiles {
+		if importProfile.Fingerprint != fingerprint {
+			continue
+		}
+
+		ownProfile := importProfile.CreatedBy == user.Id
+		matchedOwnProfile := matched.Id != 0 && matched.CreatedBy == user.Id
+		if matched.Id == 0 || (ownProfile && !matchedOwnProfile) || (ownProfile == matchedOwnProfile && importProfile.Updated.After(matched.Updated)) {
+			matched = importProfile
+		}
+	}
+
+	if matched.Id == 0 {
+		return models.ImportProfile{}, nil, errors.New("No import profile for these headers")
+	}
+
+	return matched, nil, nil
+}
+
+/*
+* Create methods
+ */
+
+func CreateImportProfile(r *http.Request) (models.ImportProfile, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var importProfile models.ImportProfile
+	err := decoder.Decode(buf, &importProfile)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	err = validateImportProfile(importProfile)
+	if err != nil {
+		return models.ImportProfile{}, nil, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	importProfile.TeamId = currentUser.Data.TeamId
+	_, err = importProfile.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	importProfile.Type = "importprofiles"
+	return importProfile, nil, nil
+}
+
+/*
+* Update methods
+ */
+
+func UpdateImportProfile(r *http.Request, id string) (models.ImportProfile, interface{}, error) {
+	importProfile, _, err := GetImportProfile(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	decoder := ffjson.NewDecoder()
+	buf, _ := ioutil.ReadAll(r.Body)
+	var updatedImportProfile models.ImportProfile
+	err = decoder.Decode(buf, &updatedImportProfile)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	utilities.UpdateIfNotBlank(&importProfile.Name, updatedImportProfile.Name)
+
+	// The mapping is replaced as a whole
+	if len(updatedImportProfile.HeaderNames) > 0 {
+		importProfile.HeaderNames = updatedImportProfile.HeaderNames
+		importProfile.Order = updatedImportProfile.Order
+		importProfile.Transforms = updatedImportProfile.Transforms
+	} else if updatedImportProfile.Transforms != nil {
+		importProfile.Transforms = updatedImportProfile.Transforms
+	}
+
+	err = validateImportProfile(importProfile)
+	if err != nil {
+		return models.ImportProfile{}, nil, err
+	}
+
+	_, err = importProfile.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.ImportProfile{}, nil, err
+	}
+
+	return importProfile, nil, nil
+}
+
+/*
+* Delete methods
+ */
+
+func DeleteImportProfile(r *http.Request, id string) (interface{}, interface{}, error) {
+	importProfile, _, err := GetImportProfile(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	_, err = importProfile.Delete()
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	return nil, nil, nil
+}
@@ -1,37 +1,48 @@
 package controllers
 
-// import (
-// 	"errors"
-// 	"io/ioutil"
-// 	"net/http"
-// 	// "strconv"
-// 	"strings"
-// 	"time"
-
-// 	gcontext "github.com/gorilla/context"
-// 	"github.com/pquerna/ffjson/ffjson"
-
-// 	"github.com/news-ai/api-v1/controllers"
-// 	apiModels "github.com/news-ai/api-v1/models"
-// 	apiSearch "github.com/news-ai/api-v1/search"
-
-// 	"github.com/news-ai/tabulae-v1/models"
-// 	"github.com/news-ai/tabulae-v1/search"
-// 	"github.com/news-ai/tabulae-v1/sync"
-
-// 	"github.com/news-ai/web/permissions"
-// 	"github.com/news-ai/web/utilities"
-// )
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	// "io/ioutil"
+
+	"github.com/go-pg/pg"
+	gcontext "github.com/gorilla/context"
+	// "github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+	// apiModels "github.com/news-ai/api-v1/models"
+	// apiSearch "github.com/news-ai/api-v1/search"
+
+	"github.com/news-ai/tabulae-v1/analytics"
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/search"
+	"github.com/news-ai/tabulae-v1/sync"
+
+	// "github.com/news-ai/web/permissions"
+	"github.com/news-ai/web/utilities"
+)
 
 // /*
 // * Private
 //  */
 
-// var nonCustomHeaders = []string{"firstname", "lastname", "email", "employers", "pastemployers", "notes", "linkedin", "twitter", "instagram", "website", "blog", "phonenumber", "location"}
-// var nonCustomHeadersName = []string{"First Name", "Last Name", "Email", "Employers", "Past Employers", "Notes", "Linkedin", "Twitter", "Instagram", "Website", "Blog", "Phone #", "Location"}
+var nonCustomHeaders = []string{"firstname", "lastname", "email", "employers", "pastemployers", "notes", "linkedin", "twitter", "instagram", "website", "blog", "phonenumber", "location"}
+var nonCustomHeadersName = []string{"First Name", "Last Name", "Email", "Employers", "Past Employers", "Notes", "Linkedin", "Twitter", "Instagram", "Website", "Blog", "Phone #", "Location"}
 
-// var customHeaders = []string{"instagramfollowers", "instagramfollowing", "instagramlikes", "instagramcomments", "instagramposts", "twitterfollowers", "twitterfollowing", "twitterlikes", "twitterretweets", "twitterposts", "latestheadline", "lastcontacted", "publicationlastcontacted"}
-// var customHeadersName = []string{"Instagram Followers", "Instagram Following", "Instagram Likes", "Instagram Comments", "Instagram Posts", "Twitter Followers", "Twitter Following", "Twitter Likes", "Twitter Retweets", "Twitter Posts", "Latest Headline", "Last Contacted", "Publication Last Contacted"}
+var customHeaders = []string{"instagramfollowers", "instagramfollowing", "instagramlikes", "instagramcomments", "instagramposts", "twitterfollowers", "twitterfollowing", "twitterlikes", "twitterretweets", "twitterposts", "latestheadline", "lastcontacted", "publicationlastcontacted"}
+var customHeadersName = []string{"Instagram Followers", "Instagram Following", "Instagram Likes", "Instagram Comments", "Instagram Posts", "Twitter Followers", "Twitter Following", "Twitter Likes", "Twitter Retweets", "Twitter Posts", "Latest Headline", "Last Contacted", "Publication Last Contacted"}
+
+// Number of contacts fetched from search per page when refreshing a smart list
+var smartListPageSize = 500
+
+// How often smart lists are looked at for a scheduled refresh
+var smartListRefreshInterval = 10 * time.Minute
 
 // type duplicateListDetails struct {
 // 	Name string `json:"name"`
@@ -87,90 +98,308 @@ package controllers
 // 	return models.MediaList{}, errors.New("No media list by this id")
 // }
 
-// func getMediaList(c context.Context, r *http.Request, id int64) (models.MediaList, error) {
-// 	if id == 0 {
-// 		return models.MediaList{}, errors.New("datastore: no such entity")
-// 	}
-
-// 	// Get the MediaList by id
-// 	var mediaList models.MediaList
-// 	mediaListId := datastore.NewKey(c, "MediaList", "", id, nil)
-
-// 	err := nds.Get(c, mediaListId, &mediaList)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 		return models.MediaList{}, err
-// 	}
-
-// 	if !mediaList.Created.IsZero() {
-// 		mediaList.Format(mediaListId, "lists")
-// 		mediaList.AddNewCustomFieldsMapToOldLists(c)
-
-// 		if !mediaList.PublicList {
-// 			user, err := controllers.GetCurrentUser(c, r)
-// 			if err != nil {
-// 				log.Errorf(c, "%v", err)
-// 				return models.MediaList{}, errors.New("Could not get user")
-// 			}
-
-// 			// 3 ways to check if user can access media list:
-// 			// 1. If admin
-// 			// 2. If created by user
-// 			// 3. If is within the user's team
-// 			if mediaList.CreatedBy != user.Id && !user.IsAdmin {
-// 				if mediaList.TeamId == 0 || user.TeamId == 0 || mediaList.TeamId != user.TeamId {
-// 					return models.MediaList{}, errors.New("Forbidden")
-// 				}
-// 			}
-
-// 			// If it is empty but there are still contacts by this list then populate them
-// 			// This is a data correction problem
-// 			if len(mediaList.Contacts) == 0 {
-// 				contacts, err := filterContactsForListId(c, r, mediaList.Id)
-// 				if err != nil {
-// 					return mediaList, nil
-// 				}
-
-// 				contactIds := []int64{}
-// 				for i := 0; i < len(contacts); i++ {
-// 					contactIds = append(contactIds, contacts[i].Id)
-// 				}
-
-// 				mediaList.Contacts = contactIds
-// 				mediaList.Save(c)
-// 			}
-// 		}
-
-// 		return mediaList, nil
-// 	}
-// 	return models.MediaList{}, errors.New("No media list by this id")
-// }
-
-// func getFieldsMap() []models.CustomFieldsMap {
-// 	fieldsmap := []models.CustomFieldsMap{}
-
-// 	for i := 0; i < len(nonCustomHeaders); i++ {
-// 		field := models.CustomFieldsMap{
-// 			Name:        nonCustomHeadersName[i],
-// 			Value:       nonCustomHeaders[i],
-// 			CustomField: false,
-// 			Hidden:      false,
-// 		}
-// 		fieldsmap = append(fieldsmap, field)
-// 	}
-
-// 	for i := 0; i < len(customHeaders); i++ {
-// 		field := models.CustomFieldsMap{
-// 			Name:        customHeadersName[i],
-// 			Value:       customHeaders[i],
-// 			CustomField: true,
-// 			Hidden:      true,
-// 		}
-// 		fieldsmap = append(fieldsmap, field)
-// 	}
-
-// 	return fieldsmap
-// }
+func getMediaList(r *http.Request, id int64) (models.MediaList, error) {
+	if id == 0 {
+		return models.MediaList{}, errors.New("datastore: no such entity")
+	}
+
+	// Get the MediaList by id
+	mediaList := models.MediaList{}
+	err := db.DB.Model(&mediaList).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, err
+	}
+
+	if !mediaList.Created.IsZero() {
+		mediaList.Format("lists")
+		mediaList.AddNewCustomFieldsMapToOldLists()
+
+		if !mediaList.PublicList {
+			user, err := controllers.GetCurrentUser(r)
+			if err != nil {
+				log.Printf("%v", err)
+				return models.MediaList{}, errors.New("Could not get user")
+			}
+
+			// 3 ways to check if user can access media list:
+			// 1. If admin
+			// 2. If created by user
+			// 3. If is within the user's team
+			if mediaList.CreatedBy != user.Id && !user.Data.IsAdmin {
+				if mediaList.TeamId == 0 || user.Data.TeamId == 0 || mediaList.TeamId != user.Data.TeamId {
+					return models.MediaList{}, errors.New("Forbidden")
+				}
+			}
+
+			// If it is empty but there are still contacts by this list then populate them
+			// This is a data correction problem. Smart lists are only populated by
+			// their saved search.
+			if len(mediaList.Contacts) == 0 && !mediaList.SmartList {
+				contacts, err := filterContactsForListId(r, mediaList.Id)
+				if err != nil {
+					return mediaList, nil
+				}
+
+				contactIds := []int64{}
+				for i := 0; i < len(contacts); i++ {
+					contactIds = append(contactIds, contacts[i].Id)
+				}
+
+				mediaList.Contacts = contactIds
+				mediaList.Save()
+			}
+		}
+
+		return mediaList, nil
+	}
+	return models.MediaList{}, errors.New("No media list by this id")
+}
+
+// Replaces the contacts of a smart list with the current results of its saved search
+func refreshSmartMediaList(r *http.Request, mediaList *models.MediaList) error {
+	if !mediaList.SmartList || mediaList.SavedSearchId == 0 {
+		return errors.New("Media list is not a smart list")
+	}
+
+	savedSearch, err := getSavedSearchUnauthorized(mediaList.SavedSearchId)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	contactIds := []int64{}
+	offset := 0
+	for {
+		contacts, total, err := search.SearchContactsBySavedSearch(savedSearch.Query, mediaList.CreatedBy, offset, smartListPageSize)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		for i := 0; i < len(contacts); i++ {
+			if !contacts[i].IsDeleted {
+				contactIds = append(contactIds, contacts[i].Id)
+			}
+		}
+
+		offset += len(contacts)
+		if len(contacts) == 0 || offset >= total {
+			break
+		}
+	}
+
+	mediaList.Contacts = contactIds
+	mediaList.LastRefreshed = time.Now()
+	_, err = mediaList.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+
+	sync.ListUploadResourceBulkSync(r, mediaList.Id, mediaList.Contacts, []int64{})
+	return nil
+}
+
+// Refreshes smart lists until none are due. A list that fails to refresh is
+// tried again after its next interval.
+func refreshDueSmartMediaLists() {
+	for {
+		mediaList, ok, err := models.ClaimDueSmartMediaList()
+		if err != nil {
+			log.Printf("%v", err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		r, err := UserRequest("/lists/"+strconv.FormatInt(mediaList.Id, 10)+"/refresh", mediaList.CreatedBy)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+
+		err = refreshSmartMediaList(r, &mediaList)
+		gcontext.Clear(r)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+}
+
+func getFieldsMap() []models.CustomFieldsMap {
+	fieldsmap := []models.CustomFieldsMap{}
+
+	for i := 0; i < len(nonCustomHeaders); i++ {
+		field := models.CustomFieldsMap{
+			Name:        nonCustomHeadersName[i],
+			Value:       nonCustomHeaders[i],
+			CustomField: false,
+			Hidden:      false,
+		}
+		fieldsmap = append(fieldsmap, field)
+	}
+
+	for i := 0; i < len(customHeaders); i++ {
+		field := models.CustomFieldsMap{
+			Name:        customHeadersName[i],
+			Value:       customHeaders[i],
+			CustomField: true,
+			Hidden:      true,
+		}
+		fieldsmap = append(fieldsmap, field)
+	}
+
+	return fieldsmap
+}
+
+// Gets a page of the contacts of a list in the order they were added
+func getContactsForListByPosition(mediaList models.MediaList, offset int, limit int) ([]models.Contact, error) {
+	startPosition := offset
+	endPosition := startPosition + limit
+
+	if len(mediaList.Contacts) < startPosition {
+		return []models.Contact{}, nil
+	}
+
+	if len(mediaList.Contacts) < endPosition {
+		endPosition = len(mediaList.Contacts)
+	}
+
+	subsetIds := mediaList.Contacts[startPosition:endPosition]
+	if len(subsetIds) == 0 {
+		return []models.Contact{}, nil
+	}
+
+	contacts := []models.Contact{}
+	err := db.DB.Model(&contacts).Where("id IN (?)", pg.In(subsetIds)).Select()
+	if err != nil {
+		return []models.Contact{}, err
+	}
+
+	contactIdToContact := map[int64]models.Contact{}
+	for i := 0; i < len(contacts); i++ {
+		contactIdToContact[contacts[i].Id] = contacts[i]
+	}
+
+	orderedContacts := []models.Contact{}
+	for i := 0; i < len(subsetIds); i++ {
+		if contact, ok := contactIdToContact[subsetIds[i]]; ok {
+			orderedContacts = append(orderedContacts, contact)
+		}
+	}
+
+	setMasterFields(orderedContacts)
+	setCurrentTitles(orderedContacts)
+	return orderedContacts, nil
+}
+
+// Gets a page of the contacts of a list with the most engaged ones first.
+// Stored scores decay at different rates so the whole list has to be sorted.
+func getContactsForListByEngagement(mediaList models.MediaList, offset int, limit int) ([]models.Contact, error) {
+	if len(mediaList.Contacts) == 0 {
+		return []models.Contact{}, nil
+	}
+
+	contacts := []models.Contact{}
+	err := db.DB.Model(&contacts).Where("id IN (?)", pg.In(mediaList.Contacts)).Select()
+	if err != nil {
+		return []models.Contact{}, err
+	}
+
+	now := time.Now()
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return analytics.CurrentEngagementScore(contacts[i], now) > analytics.CurrentEngagementScore(contacts[j], now)
+	})
+
+	if len(contacts) < offset {
+		return []models.Contact{}, nil
+	}
+
+	endPosition := offset + limit
+	if len(contacts) < endPosition {
+		endPosition = len(contacts)
+	}
+
+	contacts = contacts[offset:endPosition]
+	setMasterFields(contacts)
+	setCurrentTitles(contacts)
+	return contacts, nil
+}
+
+// Gets a page of the contacts of a list that match, in the order they were
+// added, along with how many there are in all
+func getContactsForListMatching(mediaList models.MediaList, matches func(models.Contact) bool, offset int, limit int) ([]models.Contact, int, error) {
+	if len(mediaList.Contacts) == 0 {
+		return []models.Contact{}, 0, nil
+	}
+
+	contacts := []models.Contact{}
+	err := db.DB.Model(&contacts).Where("id IN (?)", pg.In(mediaList.Contacts)).Select()
+	if err != nil {
+		return []models.Contact{}, 0, err
+	}
+
+	contactIdToContact := map[int64]models.Contact{}
+	for i := 0; i < len(contacts); i++ {
+		contactIdToContact[contacts[i].Id] = contacts[i]
+	}
+
+	matching := []models.Contact{}
+	for i := 0; i < len(mediaList.Contacts); i++ {
+		if contact, ok := contactIdToContact[mediaList.Contacts[i]]; ok && matches(contact) {
+			matching = append(matching, contact)
+		}
+	}
+
+	if len(matching) < offset {
+		return []models.Contact{}, len(matching), nil
+	}
+
+	endPosition := offset + limit
+	if len(matching) < endPosition {
+		endPosition = len(matching)
+	}
+
+	page := matching[offset:endPosition]
+	setMasterFields(page)
+	setCurrentTitles(page)
+	return page, len(matching), nil
+}
+
+// Contacts of a list on one of the beats
+func getContactsForListByBeat(mediaList models.MediaList, beatIds []int64, offset int, limit int) ([]models.Contact, int, error) {
+	return getContactsForListMatching(mediaList, func(contact models.Contact) bool {
+		return models.HasBeat(contact.Beats, beatIds)
+	}, offset, limit)
+}
+
+// Contacts of a list working at the publication or one of its sections and
+// editions, the same as the publication: search
+func getContactsForListByPublication(mediaList models.MediaList, publication string, offset int, limit int) ([]models.Contact, int, error) {
+	publicationId, err := utilities.StringIdToInt(strings.TrimSpace(publication))
+	if err != nil {
+		return []models.Contact{}, 0, errors.New("Publication has to be given by its id")
+	}
+
+	publicationIds, err := models.PublicationWithDescendants(publicationId)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, 0, err
+	}
+
+	return getContactsForListMatching(mediaList, func(contact models.Contact) bool {
+		for i := 0; i < len(contact.Employers); i++ {
+			for x := 0; x < len(publicationIds); x++ {
+				if contact.Employers[i] == publicationIds[x] {
+					return true
+				}
+			}
+		}
+		return false
+	}, offset, limit)
+}
 
 // func duplicateList(c context.Context, r *http.Request, id string, name string) (models.MediaList, interface{}, error) {
 // 	// Get the details of the current media list
@@ -473,26 +702,26 @@ package controllers
 // 	return mediaListsOthers, nil, len(mediaListsOthers), 0, nil
 // }
 
-// func GetMediaList(c context.Context, r *http.Request, id string) (models.MediaList, interface{}, error) {
-// 	// Get the details of the current user
-// 	currentId, err := utilities.StringIdToInt(id)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 		return models.MediaList{}, nil, err
-// 	}
+func GetMediaList(r *http.Request, id string) (models.MediaList, interface{}, error) {
+	// Get the details of the current user
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
 
-// 	mediaList, err := getMediaList(c, r, currentId)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 		return models.MediaList{}, nil, err
-// 	}
+	mediaList, err := getMediaList(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
 
-// 	if mediaList.PublicList {
-// 		mediaList.ReadOnly = true
-// 	}
+	if mediaList.PublicList {
+		mediaList.ReadOnly = true
+	}
 
-// 	return mediaList, nil, nil
-// }
+	return mediaList, nil, nil
+}
 
 // /*
 // * Create methods
@@ -782,124 +1011,138 @@ package controllers
 // * Action methods
 //  */
 
-// func GetContactsForList(c context.Context, r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
-// 	// Get the details of the current media list
-// 	mediaList, _, err := GetMediaList(c, r, id)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 		return []models.Contact{}, nil, 0, 0, err
-// 	}
-
-// 	user, err := controllers.GetCurrentUser(c, r)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 		return []models.Contact{}, nil, 0, 0, err
-// 	}
-
-// 	queryField := gcontext.Get(r, "q").(string)
-// 	if queryField != "" {
-// 		contacts, total, err := search.SearchContactsByList(c, r, queryField, user, mediaList.CreatedBy, mediaList.Id)
-// 		if err != nil {
-// 			return []models.Contact{}, nil, 0, 0, err
-// 		}
-
-// 		publications := contactsToPublications(c, contacts)
-// 		return contacts, publications, len(contacts), total, nil
-// 	}
-
-// 	offset := gcontext.Get(r, "offset").(int)
-// 	limit := gcontext.Get(r, "limit").(int)
-
-// 	startPosition := offset
-// 	endPosition := startPosition + limit
-
-// 	if len(mediaList.Contacts) < startPosition {
-// 		return []models.Contact{}, nil, 0, 0, err
-// 	}
-
-// 	if len(mediaList.Contacts) < endPosition {
-// 		endPosition = len(mediaList.Contacts)
-// 	}
-
-// 	subsetIds := mediaList.Contacts[startPosition:endPosition]
-// 	subsetKeyIds := []*datastore.Key{}
-// 	for i := 0; i < len(subsetIds); i++ {
-// 		subsetKeyIds = append(subsetKeyIds, datastore.NewKey(c, "Contact", "", subsetIds[i], nil))
-// 	}
-
-// 	var contacts []models.Contact
-// 	contacts = make([]models.Contact, len(subsetIds))
-
-// 	// Check memcache of this cluster of contacts are in memcache
-// 	// Current case is if limit is 50 (as a prototype) so we don't
-// 	// have to look for expiration keys
-// 	// if limit == 50 {
-// 	// 	memcacheKey := id + "_" + strconv.Itoa(startPosition) + "_" + strconv.Itoa(endPosition)
-// 	// 	_, err := memcache.Gob.Get(c, memcacheKey, &contacts)
-
-// 	// 	if err != nil {
-// 	// 		err = nds.GetMulti(c, subsetKeyIds, contacts)
-// 	// 		if err != nil {
-// 	// 			log.Errorf(c, "%v", err)
-// 	// 			return []models.Contact{}, nil, 0, 0, err
-// 	// 		}
-
-// 	// 		for i := 0; i < len(contacts); i++ {
-// 	// 			if contacts[i].ListId == 0 {
-// 	// 				contacts[i].ListId = mediaList.Id
-// 	// 				contacts[i].Save(c, r)
-// 	// 			}
-
-// 	// 			contacts[i].Id = subsetIds[i]
-// 	// 			contacts[i].Type = "contacts"
-// 	// 		}
-
-// 	// 		contacts, err = ContactsToDefaultFields(c, r, contacts, mediaList)
-// 	// 		if err != nil {
-// 	// 			log.Errorf(c, "%v", err)
-// 	// 		}
-
-// 	// 		item1 := &memcache.Item{
-// 	// 			Key:    memcacheKey,
-// 	// 			Object: &contacts,
-// 	// 		}
-// 	// 		err = memcache.Gob.Set(c, item1)
-// 	// 		if err != nil {
-// 	// 			log.Errorf(c, "%v", err)
-// 	// 		}
-// 	// 	} else {
-// 	// 		log.Infof(c, "memcache: %v", contacts)
-// 	// 	}
-
-// 	// 	publications := contactsToPublications(c, contacts)
-// 	// 	return contacts, publications, len(contacts), len(mediaList.Contacts), nil
-// 	// }
-
-// 	err = nds.GetMulti(c, subsetKeyIds, contacts)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 		return []models.Contact{}, nil, 0, 0, err
-// 	}
-
-// 	for i := 0; i < len(contacts); i++ {
-// 		if contacts[i].ListId == 0 {
-// 			contacts[i].ListId = mediaList.Id
-// 			contacts[i].Save(c, r)
-// 		}
-
-// 		contacts[i].Id = subsetIds[i]
-// 		contacts[i].Type = "contacts"
-// 	}
-
-// 	contacts, err = ContactsToDefaultFields(c, r, contacts, mediaList)
-// 	if err != nil {
-// 		log.Errorf(c, "%v", err)
-// 	}
-
-// 	// Add includes
-// 	publications := contactsToPublications(c, contacts)
-// 	return contacts, publications, len(contacts), len(mediaList.Contacts), nil
-// }
+func GetContactsForList(r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
+	// Get the details of the current media list
+	mediaList, _, err := GetMediaList(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	// "publication:" is the same as ?publication=
+	queryField := gcontext.Get(r, "q").(string)
+	publication := r.URL.Query().Get("publication")
+	if strings.HasPrefix(queryField, "publication:") {
+		publication, queryField = strings.TrimPrefix(queryField, "publication:"), ""
+	}
+
+	if queryField != "" {
+		contacts, total, err := search.SearchContactsByList(r, queryField, user.Data, mediaList.CreatedBy, mediaList.Id)
+		if err != nil {
+			return []models.Contact{}, nil, 0, 0, err
+		}
+
+		publications := contactsToPublications(contacts)
+		return contacts, publications, len(contacts), total, nil
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	// ?beat= only gets the contacts on the beat or the beats under it, and
+	// ?publication= the ones at the publication or its sections and editions
+	total := len(mediaList.Contacts)
+	contacts := []models.Contact{}
+	if publication != "" {
+		contacts, total, err = getContactsForListByPublication(mediaList, publication, offset, limit)
+	} else if beat := r.URL.Query().Get("beat"); beat != "" {
+		var taxonomy models.BeatTaxonomy
+		taxonomy, err = models.GetBeatTaxonomy()
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Contact{}, nil, 0, 0, err
+		}
+
+		var beatIds []int64
+		beatIds, err = taxonomy.FindWithDescendants(beat)
+		if err != nil {
+			return []models.Contact{}, nil, 0, 0, err
+		}
+
+		contacts, total, err = getContactsForListByBeat(mediaList, beatIds, offset, limit)
+	} else if r.URL.Query().Get("sort") == "engagement" {
+		contacts, err = getContactsForListByEngagement(mediaList, offset, limit)
+	} else {
+		contacts, err = getContactsForListByPosition(mediaList, offset, limit)
+	}
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		// Contacts from before they kept their list are brought in line
+		if contacts[i].ListId == 0 {
+			contacts[i].ListId = mediaList.Id
+			_, err = contacts[i].SaveWithHistory(r, user.Id, models.ContactChangeSourceSync)
+			if err != nil {
+				log.Printf("%v", err)
+			}
+		}
+
+		contacts[i].Type = "contacts"
+	}
+
+	contacts, err = ContactsToDefaultFields(r, contacts, mediaList)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	// Add includes
+	publications := contactsToPublications(contacts)
+	return contacts, publications, len(contacts), total, nil
+}
+
+// Gets every contact in a list along with the publications they work for,
+// in the order they were added. Used when exporting a list.
+func GetAllContactsForList(r *http.Request, id string) (models.MediaList, []models.Contact, []models.Publication, error) {
+	mediaList, _, err := GetMediaList(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, []models.Contact{}, []models.Publication{}, err
+	}
+
+	contacts, err := getContactsForListByPosition(mediaList, 0, len(mediaList.Contacts))
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, []models.Contact{}, []models.Publication{}, err
+	}
+
+	publications := contactsToPublications(contacts)
+	return mediaList, contacts, publications, nil
+}
+
+// Goes through the contacts of a list a batch at a time in list order, so
+// exports don't have to hold the whole list. Read only fields that aren't
+// hidden in mediaList.FieldsMap are filled in on each batch.
+func ForEachContactInList(r *http.Request, mediaList models.MediaList, batchSize int, fn func([]models.Contact, []models.Publication) error) error {
+	for offset := 0; offset < len(mediaList.Contacts); offset += batchSize {
+		contacts, err := getContactsForListByPosition(mediaList, offset, batchSize)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		contacts, err = ContactsToDefaultFields(r, contacts, mediaList)
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		err = fn(contacts, contactsToPublications(contacts))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
 // func GetEmailsForList(c context.Context, r *http.Request, id string) ([]models.Email, interface{}, int, int, error) {
 // 	// Get the details of the current media list
@@ -1192,3 +1435,43 @@ package controllers
 
 // 	return nil, nil, nil
 // }
+
+/*
+* Smart list methods
+ */
+
+// Refreshes the contacts of a smart list on demand
+func RefreshSmartMediaList(r *http.Request, id string) (models.MediaList, interface{}, error) {
+	mediaList, _, err := GetMediaList(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	if mediaList.ReadOnly {
+		return models.MediaList{}, nil, errors.New("Forbidden")
+	}
+
+	err = refreshSmartMediaList(r, &mediaList)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	return mediaList, nil, nil
+}
+
+// Refreshes the smart lists whose refresh interval has passed, one by one as
+// the user who made them. Called once when the server starts, the lists are
+// looked at every smartListRefreshInterval.
+func StartSmartListRefresher() {
+	go func() {
+		ticker := time.NewTicker(smartListRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			refreshDueSmartMediaLists()
+			<-ticker.C
+		}
+	}()
+}
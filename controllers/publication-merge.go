@@ -0,0 +1,754 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pg/pg"
+	gcontext "github.com/gorilla/context"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/sync"
+
+	"github.com/news-ai/web/utilities"
+)
+
+type mergePublicationsDetails struct {
+	Publications []int64 `json:"publications"`
+}
+
+// Primary is optional and picks which publication of the pair is kept
+type mergePublicationDuplicateDetails struct {
+	Primary int64 `json:"primary"`
+}
+
+// How sure a match on each of these is
+var publicationDuplicateReasonConfidence = map[string]float64{
+	"domain":      0.95,
+	"name":        0.85,
+	"similarname": 0.6,
+}
+
+// Words that don't tell publications apart: "The Verge" and "Verge Inc" are
+// both "verge"
+var publicationNameSuffixes = map[string]bool{
+	"inc":  true,
+	"llc":  true,
+	"ltd":  true,
+	"corp": true,
+	"plc":  true,
+	"co":   true,
+}
+
+/*
+* Private methods
+ */
+
+/*
+* Normalization methods
+ */
+
+// Host of the publication's site without www, mobile or AMP prefixes, so
+// "https://m.nytimes.com/section" is "nytimes.com"
+func normalizePublicationDomain(publication models.Publication) string {
+	site := strings.TrimSpace(publication.Url)
+	if site == "" {
+		site = strings.TrimSpace(publication.Website)
+	}
+	return normalizeSiteDomain(site)
+}
+
+// Host of a site the same way as normalizePublicationDomain
+func normalizeSiteDomain(site string) string {
+	site = strings.TrimSpace(site)
+	if site == "" {
+		return ""
+	}
+
+	if !strings.Contains(site, "://") {
+		site = "http://" + site
+	}
+	siteUrl, err := url.Parse(site)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(siteUrl.Hostname())
+	for _, prefix := range []string{"www.", "m.", "amp."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// Lower case words of a name without punctuation, a leading "the" or a
+// company suffix
+func normalizePublicationName(name string) string {
+	name = strings.Replace(strings.ToLower(name), "&", " and ", -1)
+	words := strings.FieldsFunc(name, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	for len(words) > 1 && publicationNameSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// Names are one typo apart for every ten letters and start the same way
+func similarPublicationNames(a, b string) bool {
+	a, b = strings.Replace(a, " ", "", -1), strings.Replace(b, " ", "", -1)
+	if len(a) < 6 || len(b) < 6 || a[:3] != b[:3] {
+		return false
+	}
+
+	allowed := len(a) / 10
+	if allowed < 1 {
+		allowed = 1
+	}
+	return levenshtein(a, b) <= allowed
+}
+
+// Verified publications are kept over ones that aren't, then ones with a
+// site, then the oldest
+func preferredPublication(a, b models.Publication) bool {
+	if a.Verified != b.Verified {
+		return a.Verified
+	}
+	if (a.Url != "") != (b.Url != "") {
+		return a.Url != ""
+	}
+	return a.Id < b.Id
+}
+
+/*
+* Duplicate methods
+ */
+
+// Ancestors of each publication by id. Sections and editions usually share
+// the site of their parent, but they aren't the same publication.
+func publicationAncestors(publications []models.Publication) map[int64]map[int64]bool {
+	parents := map[int64]int64{}
+	for i := 0; i < len(publications); i++ {
+		parents[publications[i].Id] = publications[i].ParentPublication
+	}
+
+	ancestors := map[int64]map[int64]bool{}
+	for i := 0; i < len(publications); i++ {
+		seen := map[int64]bool{}
+		parentId := publications[i].ParentPublication
+		for parentId != 0 && !seen[parentId] && parentId != publications[i].Id {
+			seen[parentId] = true
+			parentId = parents[parentId]
+		}
+		ancestors[publications[i].Id] = seen
+	}
+	return ancestors
+}
+
+// Pairs of publications that look like the same one, with the publication
+// that would be kept first
+func findPublicationDuplicates(publications []models.Publication) []models.PublicationDuplicate {
+	domains := make([]string, len(publications))
+	names := make([]string, len(publications))
+	byDomain := map[string][]int{}
+	byName := map[string][]int{}
+	byPrefix := map[string][]int{}
+	for i := 0; i < len(publications); i++ {
+		domains[i] = normalizePublicationDomain(publications[i])
+		names[i] = normalizePublicationName(publications[i].Name)
+
+		if domains[i] != "" {
+			byDomain[domains[i]] = append(byDomain[domains[i]], i)
+		}
+		if names[i] != "" {
+			byName[names[i]] = append(byName[names[i]], i)
+
+			compact := strings.Replace(names[i], " ", "", -1)
+			if len(compact) >= 6 {
+				byPrefix[compact[:3]] = append(byPrefix[compact[:3]], i)
+			}
+		}
+	}
+
+	// A publication and its sections, or sections of the same publication,
+	// are never duplicates of each other
+	ancestors := publicationAncestors(publications)
+	related := func(a, b int) bool {
+		aId, bId := publications[a].Id, publications[b].Id
+		if ancestors[aId][bId] || ancestors[bId][aId] {
+			return true
+		}
+		return publications[a].ParentPublication != 0 && publications[a].ParentPublication == publications[b].ParentPublication
+	}
+
+	pairs := map[[2]int]*models.PublicationDuplicate{}
+	order := [][2]int{}
+	addLink := func(a, b int, reason string) {
+		if related(a, b) {
+			return
+		}
+		if !preferredPublication(publications[a], publications[b]) {
+			a, b = b, a
+		}
+
+		key := [2]int{a, b}
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &models.PublicationDuplicate{
+				PublicationId: publications[a].Id,
+				DuplicateId:   publications[b].Id,
+				Reasons:       []string{},
+			}
+			pairs[key] = pair
+			order = append(order, key)
+		}
+
+		for _, existing := range pair.Reasons {
+			if existing == reason {
+				return
+			}
+		}
+		pair.Reasons = append(pair.Reasons, reason)
+		if publicationDuplicateReasonConfidence[reason] > pair.Confidence {
+			pair.Confidence = publicationDuplicateReasonConfidence[reason]
+		}
+	}
+
+	// Publications on different sites share names, like local papers
+	differentSites := func(a, b int) bool {
+		return domains[a] != "" && domains[b] != "" && domains[a] != domains[b]
+	}
+
+	for _, indexes := range byDomain {
+		for x := 0; x < len(indexes); x++ {
+			for y := x + 1; y < len(indexes); y++ {
+				addLink(indexes[x], indexes[y], "domain")
+			}
+		}
+	}
+
+	for _, indexes := range byName {
+		for x := 0; x < len(indexes); x++ {
+			for y := x + 1; y < len(indexes); y++ {
+				if !differentSites(indexes[x], indexes[y]) {
+					addLink(indexes[x], indexes[y], "name")
+				}
+			}
+		}
+	}
+
+	for _, indexes := range byPrefix {
+		for x := 0; x < len(indexes); x++ {
+			for y := x + 1; y < len(indexes); y++ {
+				a, b := indexes[x], indexes[y]
+				if names[a] == names[b] || differentSites(a, b) {
+					continue
+				}
+				if similarPublicationNames(names[a], names[b]) {
+					addLink(a, b, "similarname")
+				}
+			}
+		}
+	}
+
+	duplicates := []models.PublicationDuplicate{}
+	for _, key := range order {
+		duplicates = append(duplicates, *pairs[key])
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Confidence > duplicates[j].Confidence
+	})
+	return duplicates
+}
+
+func getPublicationDuplicate(id int64) (models.PublicationDuplicate, error) {
+	if id == 0 {
+		return models.PublicationDuplicate{}, errors.New("datastore: no such entity")
+	}
+
+	duplicate := models.PublicationDuplicate{}
+	err := db.DB.Model(&duplicate).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.PublicationDuplicate{}, err
+	}
+
+	if !duplicate.Created.IsZero() {
+		duplicate.Type = "publicationduplicates"
+		return duplicate, nil
+	}
+
+	return models.PublicationDuplicate{}, errors.New("No publication duplicate by this id")
+}
+
+// Publications are shared by everyone, so only admins merge them
+func checkPublicationAdmin(r *http.Request) (apiModels.UserPostgres, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return apiModels.UserPostgres{}, err
+	}
+
+	if !user.Data.IsAdmin {
+		return apiModels.UserPostgres{}, errors.New("Forbidden")
+	}
+
+	return user, nil
+}
+
+// Swaps the duplicates in a list of employers for the primary publication,
+// keeping the place of the first one
+func replaceEmployers(employers []int64, primaryId int64, duplicates map[int64]bool) []int64 {
+	replaced := []int64{}
+	for i := 0; i < len(employers); i++ {
+		id := employers[i]
+		if duplicates[id] {
+			id = primaryId
+		}
+		replaced = appendUniqueIds(replaced, id)
+	}
+	return replaced
+}
+
+// Fills in the primary publication with what the duplicate has that it
+// doesn't
+func mergePublicationFields(primary *models.Publication, duplicate models.Publication) {
+	fillIfBlank(&primary.Url, duplicate.Url)
+	fillIfBlank(&primary.LinkedIn, duplicate.LinkedIn)
+	fillIfBlank(&primary.Twitter, duplicate.Twitter)
+	fillIfBlank(&primary.Instagram, duplicate.Instagram)
+	fillIfBlank(&primary.MuckRack, duplicate.MuckRack)
+	fillIfBlank(&primary.Website, duplicate.Website)
+	fillIfBlank(&primary.Blog, duplicate.Blog)
+	fillIfBlank(&primary.Language, duplicate.Language)
+	fillIfBlank(&primary.Country, duplicate.Country)
+	fillIfBlank(&primary.Region, duplicate.Region)
+	fillIfBlank(&primary.Frequency, duplicate.Frequency)
+	if primary.AudienceSize == 0 {
+		primary.AudienceSize = duplicate.AudienceSize
+	}
+
+	for _, mediaType := range duplicate.MediaTypes {
+		exists := false
+		for i := 0; i < len(primary.MediaTypes); i++ {
+			exists = exists || primary.MediaTypes[i] == mediaType
+		}
+		if !exists {
+			primary.MediaTypes = append(primary.MediaTypes, mediaType)
+		}
+	}
+
+	primary.Beats = appendUniqueIds(primary.Beats, duplicate.Beats...)
+	primary.Verified = primary.Verified || duplicate.Verified
+}
+
+// Merges the duplicates into the primary publication. Contacts working or
+// having worked at a duplicate, employments and feeds move over to the
+// primary, and the duplicates point to it from then on.
+func mergePublications(r *http.Request, user apiModels.UserPostgres, primary models.Publication, duplicateIds []int64) (models.Publication, error) {
+	if primary.MergedInto != 0 {
+		return models.Publication{}, errors.New("Publication has been merged into another one")
+	}
+
+	duplicateIdMap := map[int64]bool{}
+	mergeIds := []int64{}
+	for _, duplicateId := range duplicateIds {
+		if duplicateId == primary.Id || duplicateIdMap[duplicateId] {
+			continue
+		}
+
+		duplicate, err := getPublicationRow(duplicateId)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Publication{}, err
+		}
+
+		if duplicate.MergedInto != 0 {
+			return models.Publication{}, errors.New("Publication has already been merged")
+		}
+
+		mergePublicationFields(&primary, duplicate)
+		mergeIds = append(mergeIds, duplicate.Id)
+		duplicateIdMap[duplicate.Id] = true
+	}
+
+	if len(mergeIds) == 0 {
+		return models.Publication{}, errors.New("No publications to merge")
+	}
+
+	// The primary can't end up as its own section
+	if duplicateIdMap[primary.ParentPublication] {
+		primary.ParentPublication = 0
+		primary.PublicationType = models.PublicationTypeOutlet
+	}
+
+	// Employers are stored as JSON arrays
+	conditions := []string{}
+	for _, duplicateId := range mergeIds {
+		id := strconv.FormatInt(duplicateId, 10)
+		conditions = append(conditions, "employers::jsonb @> '["+id+"]'::jsonb", "past_employers::jsonb @> '["+id+"]'::jsonb")
+	}
+
+	now := time.Now()
+	err := db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		contacts := []models.Contact{}
+		err := tx.Model(&contacts).Where(strings.Join(conditions, " OR ")).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+
+		changes := []models.ContactChange{}
+		for i := 0; i < len(contacts); i++ {
+			previous := contacts[i]
+
+			contacts[i].Employers = replaceEmployers(contacts[i].Employers, primary.Id, duplicateIdMap)
+			pastEmployers := []int64{}
+			for _, id := range replaceEmployers(contacts[i].PastEmployers, primary.Id, duplicateIdMap) {
+				current := false
+				for x := 0; x < len(contacts[i].Employers); x++ {
+					if contacts[i].Employers[x] == id {
+						current = true
+						break
+					}
+				}
+				if !current {
+					pastEmployers = append(pastEmployers, id)
+				}
+			}
+			contacts[i].PastEmployers = pastEmployers
+			contacts[i].Updated = now
+
+			_, err = tx.Model(&contacts[i]).Column("employers", "past_employers", "updated").Update()
+			if err != nil {
+				return err
+			}
+
+			changes = append(changes, models.DiffContacts(previous, contacts[i], user.Id, models.ContactChangeSourceMerge)...)
+		}
+		if len(changes) > 0 {
+			_, err = tx.Model(&changes).Insert()
+			if err != nil {
+				return err
+			}
+		}
+
+		repoint := []interface{}{&models.Feed{}, &models.Employment{}, &models.Headline{}, &models.HeadlineAttribution{}}
+		for _, model := range repoint {
+			_, err = tx.Model(model).Set("publication_id = ?", primary.Id).Where("publication_id IN (?)", pg.In(mergeIds)).Update()
+			if err != nil {
+				return err
+			}
+		}
+
+		// Sections and editions of the duplicates now belong to the primary
+		_, err = tx.Model(&models.Publication{}).Set("parent_publication = ?", primary.Id).Set("updated = ?", now).Where("parent_publication IN (?)", pg.In(mergeIds)).Where("id != ?", primary.Id).Update()
+		if err != nil {
+			return err
+		}
+
+		// Publications merged into a duplicate earlier follow it
+		_, err = tx.Model(&models.Publication{}).Set("merged_into = ?", primary.Id).Set("updated = ?", now).Where("merged_into IN (?)", pg.In(mergeIds)).Update()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model(&models.Publication{}).Set("merged_into = ?", primary.Id).Set("updated = ?", now).Where("id IN (?)", pg.In(mergeIds)).Update()
+		if err != nil {
+			return err
+		}
+
+		// Pairs still waiting for review now compare with the primary, and a
+		// pair of the primary and a duplicate is done
+		_, err = tx.Model(&models.PublicationDuplicate{}).Set("publication_id = ?", primary.Id).Set("updated = ?", now).Where("publication_id IN (?)", pg.In(mergeIds)).Where("status = ?", models.PublicationDuplicateStatusPending).Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(&models.PublicationDuplicate{}).Set("duplicate_id = ?", primary.Id).Set("updated = ?", now).Where("duplicate_id IN (?)", pg.In(mergeIds)).Where("status = ?", models.PublicationDuplicateStatusPending).Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(&models.PublicationDuplicate{}).Set("status = ?", models.PublicationDuplicateStatusMerged).Set("reviewed_by = ?", user.Id).Set("reviewed = ?", now).Set("updated = ?", now).Where("publication_id = duplicate_id").Where("status = ?", models.PublicationDuplicateStatusPending).Update()
+		if err != nil {
+			return err
+		}
+
+		primary.Updated = now
+		_, err = tx.Model(&primary).Update()
+		return err
+	})
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, err
+	}
+
+	// Duplicates are only found through the primary from now on
+	for i := 0; i < len(mergeIds); i++ {
+		err = sync.ResourceSync(r, mergeIds[i], "Publication", "delete")
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	primary.Type = "publications"
+	return primary, nil
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+// Pairs of publications waiting for review, most likely first. ?status= gets
+// the merged or dismissed ones instead.
+func GetPublicationDuplicates(r *http.Request) ([]models.PublicationDuplicate, interface{}, int, int, error) {
+	_, err := checkPublicationAdmin(r)
+	if err != nil {
+		return []models.PublicationDuplicate{}, nil, 0, 0, err
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.PublicationDuplicateStatusPending
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	duplicates := []models.PublicationDuplicate{}
+	total, err := db.DB.Model(&duplicates).Where("status = ?", status).Order("confidence DESC", "id ASC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.PublicationDuplicate{}, nil, 0, 0, err
+	}
+
+	publicationIds := []int64{}
+	for i := 0; i < len(duplicates); i++ {
+		duplicates[i].Type = "publicationduplicates"
+		publicationIds = appendUniqueIds(publicationIds, duplicates[i].PublicationId, duplicates[i].DuplicateId)
+	}
+
+	publications := []models.Publication{}
+	if len(publicationIds) > 0 {
+		err = db.DB.Model(&publications).Where("id IN (?)", pg.In(publicationIds)).Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.PublicationDuplicate{}, nil, 0, 0, err
+		}
+		for i := 0; i < len(publications); i++ {
+			publications[i].Type = "publications"
+		}
+	}
+
+	return duplicates, publications, len(duplicates), total, nil
+}
+
+/*
+* Action methods
+ */
+
+// Looks for publications that look like the same one and adds the pairs
+// that haven't been looked at before to the review queue
+func ScanPublicationDuplicates(r *http.Request) ([]models.PublicationDuplicate, interface{}, int, int, error) {
+	user, err := checkPublicationAdmin(r)
+	if err != nil {
+		return []models.PublicationDuplicate{}, nil, 0, 0, err
+	}
+
+	publications := []models.Publication{}
+	err = db.DB.Model(&publications).Where("merged_into = 0 OR merged_into IS NULL").Order("id ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.PublicationDuplicate{}, nil, 0, 0, err
+	}
+
+	existing := []models.PublicationDuplicate{}
+	err = db.DB.Model(&existing).Column("publication_id", "duplicate_id").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.PublicationDuplicate{}, nil, 0, 0, err
+	}
+
+	// Pairs are recorded once whichever way round they were found
+	recorded := map[[2]int64]bool{}
+	for i := 0; i < len(existing); i++ {
+		recorded[[2]int64{existing[i].PublicationId, existing[i].DuplicateId}] = true
+		recorded[[2]int64{existing[i].DuplicateId, existing[i].PublicationId}] = true
+	}
+
+	now := time.Now()
+	newDuplicates := []models.PublicationDuplicate{}
+	for _, duplicate := range findPublicationDuplicates(publications) {
+		if recorded[[2]int64{duplicate.PublicationId, duplicate.DuplicateId}] {
+			continue
+		}
+
+		duplicate.Status = models.PublicationDuplicateStatusPending
+		duplicate.CreatedBy = user.Id
+		duplicate.Created = now
+		duplicate.Updated = now
+		newDuplicates = append(newDuplicates, duplicate)
+	}
+
+	if len(newDuplicates) > 0 {
+		_, err = db.DB.Model(&newDuplicates).Returning("*").Insert()
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.PublicationDuplicate{}, nil, 0, 0, err
+		}
+	}
+
+	for i := 0; i < len(newDuplicates); i++ {
+		newDuplicates[i].Type = "publicationduplicates"
+	}
+
+	return newDuplicates, nil, len(newDuplicates), len(newDuplicates), nil
+}
+
+// Merges a pair from the review queue. The publication picked when the pair
+// was found is kept unless "primary" says otherwise.
+func MergePublicationDuplicate(r *http.Request, id string) (models.Publication, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	user, err := checkPublicationAdmin(r)
+	if err != nil {
+		return models.Publication{}, nil, err
+	}
+
+	duplicate, err := getPublicationDuplicate(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	if duplicate.Status != models.PublicationDuplicateStatusPending {
+		return models.Publication{}, nil, errors.New("Publication duplicate has already been reviewed")
+	}
+
+	var mergeDetails mergePublicationDuplicateDetails
+	buf, _ := ioutil.ReadAll(r.Body)
+	if len(strings.TrimSpace(string(buf))) > 0 {
+		decoder := ffjson.NewDecoder()
+		err = decoder.Decode(buf, &mergeDetails)
+		if err != nil {
+			log.Printf("%v", err)
+			return models.Publication{}, nil, err
+		}
+	}
+
+	primaryId, duplicateId := duplicate.PublicationId, duplicate.DuplicateId
+	if mergeDetails.Primary != 0 {
+		if mergeDetails.Primary == duplicateId {
+			primaryId, duplicateId = duplicateId, primaryId
+		} else if mergeDetails.Primary != primaryId {
+			return models.Publication{}, nil, errors.New("Primary has to be one of the publications of the pair")
+		}
+	}
+
+	primary, err := getPublicationRow(primaryId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	// The pair is marked as merged along with the merge
+	publication, err := mergePublications(r, user, primary, []int64{duplicateId})
+	if err != nil {
+		return models.Publication{}, nil, err
+	}
+
+	return publication, nil, nil
+}
+
+// Takes a pair off the review queue as not being the same publication. It
+// isn't suggested again.
+func DismissPublicationDuplicate(r *http.Request, id string) (models.PublicationDuplicate, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.PublicationDuplicate{}, nil, err
+	}
+
+	user, err := checkPublicationAdmin(r)
+	if err != nil {
+		return models.PublicationDuplicate{}, nil, err
+	}
+
+	duplicate, err := getPublicationDuplicate(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.PublicationDuplicate{}, nil, err
+	}
+
+	if duplicate.Status != models.PublicationDuplicateStatusPending {
+		return models.PublicationDuplicate{}, nil, errors.New("Publication duplicate has already been reviewed")
+	}
+
+	_, err = duplicate.Review(models.PublicationDuplicateStatusDismissed, user.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.PublicationDuplicate{}, nil, err
+	}
+
+	return duplicate, nil, nil
+}
+
+// Merges the given publications into this one without going through the
+// review queue
+func MergePublications(r *http.Request, id string) (models.Publication, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var mergeDetails mergePublicationsDetails
+	err := decoder.Decode(buf, &mergeDetails)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	user, err := checkPublicationAdmin(r)
+	if err != nil {
+		return models.Publication{}, nil, err
+	}
+
+	primary, err := getPublicationRow(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	publication, err := mergePublications(r, user, primary, mergeDetails.Publications)
+	if err != nil {
+		return models.Publication{}, nil, err
+	}
+
+	return publication, nil, nil
+}
@@ -6,10 +6,14 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/go-pg/pg"
+
 	"github.com/news-ai/api-v1/controllers"
 	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
 	apiSearch "github.com/news-ai/api-v1/search"
 
 	gcontext "github.com/gorilla/context"
@@ -21,6 +25,30 @@ import (
 	"github.com/news-ai/web/utilities"
 )
 
+// Pointers so a publication can be moved to the top with "parent": 0 and
+// its audience size cleared with "audiencesize": 0
+type updatePublicationDetails struct {
+	Parent       *int64 `json:"parent"`
+	AudienceSize *int64 `json:"audiencesize"`
+}
+
+// The parts of a company profile that qualify a publication
+type publicationCompanyProfile struct {
+	LanguageLocale string `json:"languageLocale"`
+	Organization   struct {
+		ContactInfo struct {
+			Addresses []struct {
+				Region struct {
+					Name string `json:"name"`
+				} `json:"region"`
+				Country struct {
+					Code string `json:"code"`
+				} `json:"country"`
+			} `json:"addresses"`
+		} `json:"contactInfo"`
+	} `json:"organization"`
+}
+
 // /*
 // * Private methods
 //  */
@@ -29,7 +57,9 @@ import (
 // * Get methods
 //  */
 
-func getPublication(id int64) (models.Publication, error) {
+// The publication as it is stored, even when it has been merged into
+// another one
+func getPublicationRow(id int64) (models.Publication, error) {
 	if id == 0 {
 		return models.Publication{}, errors.New("datastore: no such entity")
 	}
@@ -49,6 +79,189 @@ func getPublication(id int64) (models.Publication, error) {
 	return models.Publication{}, errors.New("No publication by this id")
 }
 
+// Duplicates that have been merged stand for the publication they were
+// merged into
+func getPublication(id int64) (models.Publication, error) {
+	publication, err := getPublicationRow(id)
+	if err != nil {
+		return models.Publication{}, err
+	}
+
+	if publication.MergedInto != 0 {
+		return getPublicationRow(publication.MergedInto)
+	}
+	return publication, nil
+}
+
+// Search hits come from the index, which can still have publications that
+// have since been merged
+func withoutMergedPublications(publications []models.Publication) ([]models.Publication, error) {
+	if len(publications) == 0 {
+		return publications, nil
+	}
+
+	publicationIds := []int64{}
+	for i := 0; i < len(publications); i++ {
+		publicationIds = append(publicationIds, publications[i].Id)
+	}
+
+	mergedPublications := []models.Publication{}
+	err := db.DB.Model(&mergedPublications).Column("id").Where("id IN (?)", pg.In(publicationIds)).Where("merged_into != ?", 0).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Publication{}, err
+	}
+
+	merged := map[int64]bool{}
+	for i := 0; i < len(mergedPublications); i++ {
+		merged[mergedPublications[i].Id] = true
+	}
+
+	remaining := []models.Publication{}
+	for i := 0; i < len(publications); i++ {
+		if !merged[publications[i].Id] {
+			remaining = append(remaining, publications[i])
+		}
+	}
+	return remaining, nil
+}
+
+// Sections and editions sit under a publication that still exists, and a
+// publication can't sit under one of its own sections
+func checkPublicationParent(publication models.Publication) error {
+	if publication.ParentPublication == 0 {
+		return nil
+	}
+
+	parent, err := getPublicationRow(publication.ParentPublication)
+	if err != nil {
+		return errors.New("No parent publication by this id")
+	}
+
+	if parent.MergedInto != 0 {
+		return errors.New("Parent publication has been merged into another one")
+	}
+
+	if publication.Id == 0 {
+		return nil
+	}
+
+	descendantIds, err := models.PublicationWithDescendants(publication.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return err
+	}
+	for i := 0; i < len(descendantIds); i++ {
+		if descendantIds[i] == parent.Id {
+			return errors.New("Publication can not be moved under itself")
+		}
+	}
+
+	return nil
+}
+
+// Fills in the sections and editions under a publication, and theirs
+func getPublicationTree(root models.Publication) (models.Publication, error) {
+	publicationIds, err := models.PublicationWithDescendants(root.Id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, err
+	}
+
+	publications := []models.Publication{}
+	err = db.DB.Model(&publications).Where("id IN (?)", pg.In(publicationIds)).Where("merged_into = 0 OR merged_into IS NULL").Order("name ASC").Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, err
+	}
+
+	children := map[int64][]models.Publication{}
+	for i := 0; i < len(publications); i++ {
+		publications[i].Type = "publications"
+		if publications[i].Id != root.Id {
+			children[publications[i].ParentPublication] = append(children[publications[i].ParentPublication], publications[i])
+		}
+	}
+
+	var fill func(publication *models.Publication)
+	fill = func(publication *models.Publication) {
+		publication.Children = children[publication.Id]
+		for i := 0; i < len(publication.Children); i++ {
+			fill(&publication.Children[i])
+		}
+	}
+
+	root.Type = "publications"
+	fill(&root)
+	return root, nil
+}
+
+// Company profile of a publication, looked up by the host of its url
+func getCompanyProfile(r *http.Request, publication models.Publication) (interface{}, error) {
+	if publication.Url == "" {
+		return nil, errors.New("Publication has no URL")
+	}
+
+	publicationUrl, err := url.Parse(publication.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	publicationDetail, err := apiSearch.SearchCompanyDatabase(r, publicationUrl.Host)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, err
+	}
+
+	return publicationDetail.Data, nil
+}
+
+// Publications are shared between every user, so only admins and whoever
+// added one can change what it is. Verified ones are left to admins.
+func canEditPublication(user apiModels.UserPostgres, publication models.Publication) bool {
+	if user.Data.IsAdmin {
+		return true
+	}
+	return !publication.Verified && publication.CreatedBy == user.Id
+}
+
+// Fills in the language, country and region of a publication from its
+// company profile. Anything already set is kept.
+func applyCompanyProfile(publication *models.Publication, companyData interface{}) error {
+	data, err := ffjson.Marshal(companyData)
+	if err != nil {
+		return err
+	}
+
+	var profile publicationCompanyProfile
+	err = ffjson.Unmarshal(data, &profile)
+	if err != nil {
+		return err
+	}
+
+	// Locales look like "en" or "en-US"
+	language := strings.FieldsFunc(profile.LanguageLocale, func(c rune) bool { return c == '-' || c == '_' })
+	if len(language) > 0 && len(language[0]) == 2 {
+		utilities.UpdateIfNotBlank(&publication.Language, language[0])
+	}
+
+	if publication.Country == "" && len(profile.Organization.ContactInfo.Addresses) > 0 {
+		address := profile.Organization.ContactInfo.Addresses[0]
+		if len(address.Country.Code) == 2 {
+			publication.Country = address.Country.Code
+			utilities.UpdateIfNotBlank(&publication.Region, address.Region.Name)
+		}
+	}
+
+	_, err = publication.ValidateMetadata()
+	if err != nil {
+		return err
+	}
+
+	_, err = publication.Save()
+	return err
+}
+
 // /*
 // * Filter methods
 //  */
@@ -62,7 +275,11 @@ func filterPublication(queryType, query string) (models.Publication, error) {
 		return models.Publication{}, err
 	}
 
-	if publication.Created.IsZero() {
+	if publication.MergedInto != 0 {
+		return getPublication(publication.MergedInto)
+	}
+
+	if !publication.Created.IsZero() {
 		publication.Type = "publications"
 		return publication, nil
 	}
@@ -70,6 +287,117 @@ func filterPublication(queryType, query string) (models.Publication, error) {
 	return models.Publication{}, errors.New("No publication by this " + queryType)
 }
 
+// Publications on the beat or any beat under it, optionally with text after
+// the beat in their name. Beats are filtered in postgres since publications
+// aren't synced to the search index when they are updated.
+func filterPublicationsByBeat(r *http.Request, query string) ([]models.Publication, int, error) {
+	taxonomy, err := models.GetBeatTaxonomy()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Publication{}, 0, err
+	}
+
+	beatIds, text, err := taxonomy.FindInQuery(query)
+	if err != nil {
+		return []models.Publication{}, 0, err
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	publications := []models.Publication{}
+	selectQuery := db.DB.Model(&publications).Where("merged_into = 0 OR merged_into IS NULL").Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(beats::jsonb) AS beat WHERE beat::bigint IN (?))", pg.In(beatIds))
+	if text != "" {
+		selectQuery = selectQuery.Where("name ILIKE ?", "%"+text+"%")
+	}
+
+	total, err := selectQuery.Order("name ASC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Publication{}, 0, err
+	}
+
+	for i := 0; i < len(publications); i++ {
+		publications[i].Type = "publications"
+	}
+	return publications, total, nil
+}
+
+// Filters on publication metadata and the column each one matches
+var publicationFilterColumns = map[string]string{
+	"language":  "language",
+	"country":   "country",
+	"region":    "region",
+	"frequency": "frequency",
+}
+
+// Whether the request narrows publications down by their metadata
+func hasPublicationFilters(r *http.Request) bool {
+	for field := range publicationFilterColumns {
+		if r.URL.Query().Get(field) != "" {
+			return true
+		}
+	}
+	return r.URL.Query().Get("mediatype") != "" || r.URL.Query().Get("minaudience") != "" || r.URL.Query().Get("maxaudience") != ""
+}
+
+// Publications narrowed down by the ?mediatype=, ?language=, ?country=,
+// ?region=, ?frequency=, ?minaudience= and ?maxaudience= filters, and by
+// name if there is a search. Metadata is filtered in postgres since
+// publications aren't synced to the search index when they are updated.
+func filterPublicationsByMetadata(r *http.Request, name string) ([]models.Publication, int, error) {
+	publications := []models.Publication{}
+	selectQuery := db.DB.Model(&publications).Where("merged_into = 0 OR merged_into IS NULL")
+
+	if name = strings.TrimSpace(name); name != "" {
+		selectQuery = selectQuery.Where("name ILIKE ?", "%"+name+"%")
+	}
+
+	for field, column := range publicationFilterColumns {
+		if value := strings.TrimSpace(r.URL.Query().Get(field)); value != "" {
+			selectQuery = selectQuery.Where("lower("+column+") = lower(?)", value)
+		}
+	}
+
+	if mediaType := strings.TrimSpace(r.URL.Query().Get("mediatype")); mediaType != "" {
+		mediaTypes, err := ffjson.Marshal([]string{strings.ToLower(mediaType)})
+		if err != nil {
+			return []models.Publication{}, 0, err
+		}
+		selectQuery = selectQuery.Where("media_types::jsonb @> ?::jsonb", string(mediaTypes))
+	}
+
+	if minAudience := r.URL.Query().Get("minaudience"); minAudience != "" {
+		size, err := strconv.ParseInt(minAudience, 10, 64)
+		if err != nil || size < 0 {
+			return []models.Publication{}, 0, errors.New("Minimum audience size has to be a number")
+		}
+		selectQuery = selectQuery.Where("audience_size >= ?", size)
+	}
+
+	if maxAudience := r.URL.Query().Get("maxaudience"); maxAudience != "" {
+		size, err := strconv.ParseInt(maxAudience, 10, 64)
+		if err != nil || size < 0 {
+			return []models.Publication{}, 0, errors.New("Maximum audience size has to be a number")
+		}
+		selectQuery = selectQuery.Where("audience_size <= ?", size)
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	total, err := selectQuery.Order("name ASC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Publication{}, 0, err
+	}
+
+	for i := 0; i < len(publications); i++ {
+		publications[i].Type = "publications"
+	}
+	return publications, total, nil
+}
+
 /*
 * Public methods
  */
@@ -81,14 +409,56 @@ func filterPublication(queryType, query string) (models.Publication, error) {
 func GetPublications(r *http.Request) ([]models.Publication, interface{}, int, int, error) {
 	// If user is querying then it is not denied by the server
 	queryField := gcontext.Get(r, "q").(string)
+	if strings.HasPrefix(queryField, "beat:") {
+		publications, total, err := filterPublicationsByBeat(r, strings.TrimPrefix(queryField, "beat:"))
+		if err != nil {
+			return []models.Publication{}, nil, 0, 0, err
+		}
+		return publications, nil, len(publications), total, nil
+	}
+
+	if hasPublicationFilters(r) {
+		publications, total, err := filterPublicationsByMetadata(r, queryField)
+		if err != nil {
+			return []models.Publication{}, nil, 0, 0, err
+		}
+		return publications, nil, len(publications), total, nil
+	}
+
 	if queryField != "" {
 		publications, total, err := search.SearchPublication(r, queryField)
 		if err != nil {
 			return []models.Publication{}, nil, 0, 0, err
 		}
+
+		publications, err = withoutMergedPublications(publications)
+		if err != nil {
+			return []models.Publication{}, nil, 0, 0, err
+		}
 		return publications, nil, len(publications), total, nil
 	}
 
+	// Anyone can look at the sections and editions of a publication
+	if parent := r.URL.Query().Get("parent"); parent != "" {
+		parentId, err := utilities.StringIdToInt(parent)
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Publication{}, nil, 0, 0, err
+		}
+
+		publications := []models.Publication{}
+		err = db.DB.Model(&publications).Where("parent_publication = ?", parentId).Where("merged_into = 0 OR merged_into IS NULL").Order("name ASC").Select()
+		if err != nil {
+			log.Printf("%v", err)
+			return []models.Publication{}, nil, 0, 0, err
+		}
+
+		for i := 0; i < len(publications); i++ {
+			publications[i].Type = "publications"
+		}
+		return publications, nil, len(publications), len(publications), nil
+	}
+
 	// Now if user is not querying then check
 	user, err := controllers.GetCurrentUser(r)
 	if err != nil {
@@ -130,53 +500,96 @@ func GetPublication(id string) (models.Publication, interface{}, error) {
 	return publication, nil, nil
 }
 
-func GetHeadlinesForPublication(r *http.Request, id string) (interface{}, interface{}, int, int, error) {
-	// Get the details of the current user
+// The whole family a publication belongs to, from the top brand down
+// through its sections and editions
+func GetPublicationTree(id string) (models.Publication, interface{}, error) {
 	currentId, err := utilities.StringIdToInt(id)
 	if err != nil {
 		log.Printf("%v", err)
-		return nil, nil, 0, 0, err
+		return models.Publication{}, nil, err
+	}
+
+	publication, err := getPublication(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	// Stops if the parents loop
+	seen := map[int64]bool{publication.Id: true}
+	for publication.ParentPublication != 0 && !seen[publication.ParentPublication] {
+		parent, err := getPublication(publication.ParentPublication)
+		if err != nil {
+			log.Printf("%v", err)
+			break
+		}
+		seen[parent.Id] = true
+		publication = parent
+	}
+
+	tree, err := getPublicationTree(publication)
+	if err != nil {
+		return models.Publication{}, nil, err
 	}
 
-	headlines, total, err := apiSearch.SearchHeadlinesByPublicationId(r, currentId)
+	return tree, nil, nil
+}
+
+// Contacts of the user working at the publication or any of its sections
+// and editions
+func GetContactsForPublication(r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	contacts, total, err := search.SearchContactsByPublicationId(r, id, user.Id)
 	if err != nil {
 		log.Printf("%v", err)
-		return nil, nil, 0, 0, err
+		return []models.Contact{}, nil, 0, 0, err
 	}
 
-	return headlines, nil, len(headlines), total, nil
+	publications := contactsToPublications(contacts)
+	return contacts, publications, len(contacts), total, nil
 }
 
-func GetEnrichCompanyProfile(r *http.Request, id string) (interface{}, interface{}, error) {
-	// Get the details of the current user
+func GetHeadlinesForPublication(r *http.Request, id string) ([]models.Headline, interface{}, int, int, error) {
 	currentId, err := utilities.StringIdToInt(id)
 	if err != nil {
 		log.Printf("%v", err)
-		return nil, nil, err
+		return []models.Headline{}, nil, 0, 0, err
 	}
 
 	publication, err := getPublication(currentId)
 	if err != nil {
 		log.Printf("%v", err)
-		return nil, nil, err
+		return []models.Headline{}, nil, 0, 0, err
 	}
 
-	if publication.Url == "" {
-		return nil, nil, errors.New("Publication has no URL")
-	}
+	return getHeadlines(r, "publication_id", publication.Id)
+}
 
-	publicationUrl, err := url.Parse(publication.Url)
+func GetEnrichCompanyProfile(r *http.Request, id string) (interface{}, interface{}, error) {
+	// Get the details of the current user
+	currentId, err := utilities.StringIdToInt(id)
 	if err != nil {
+		log.Printf("%v", err)
 		return nil, nil, err
 	}
 
-	publicationDetail, err := apiSearch.SearchCompanyDatabase(r, publicationUrl.Host)
+	publication, err := getPublication(currentId)
 	if err != nil {
 		log.Printf("%v", err)
 		return nil, nil, err
 	}
 
-	return publicationDetail.Data, nil, nil
+	companyData, err := getCompanyProfile(r, publication)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return companyData, nil, nil
 }
 
 // /*
@@ -215,6 +628,11 @@ func CreatePublication(w http.ResponseWriter, r *http.Request) (interface{}, int
 				return []models.Publication{}, nil, 0, 0, err
 			}
 
+			err = checkPublicationParent(publications[i])
+			if err != nil {
+				return []models.Publication{}, nil, 0, 0, err
+			}
+
 			presentPublication, _, err := FilterPublicationByNameAndUrl(publications[i].Name, publications[i].Url)
 			if err != nil {
 				_, err = publications[i].Create(r, currentUser)
@@ -223,6 +641,7 @@ func CreatePublication(w http.ResponseWriter, r *http.Request) (interface{}, int
 					return []models.Publication{}, nil, 0, 0, err
 				}
 				// sync.ResourceSync(r, publications[i].Id, "Publication", "create")
+				discoverPublicationFeedsLater(currentUser.Id, publications[i])
 				newPublications = append(newPublications, publications[i])
 			} else {
 				newPublications = append(newPublications, presentPublication)
@@ -237,6 +656,11 @@ func CreatePublication(w http.ResponseWriter, r *http.Request) (interface{}, int
 		return models.Publication{}, nil, 0, 0, err
 	}
 
+	err = checkPublicationParent(publication)
+	if err != nil {
+		return models.Publication{}, nil, 0, 0, err
+	}
+
 	presentPublication, _, err := FilterPublicationByNameAndUrl(publication.Name, publication.Url)
 	if err != nil {
 		currentUser, err := controllers.GetCurrentUser(r)
@@ -251,6 +675,7 @@ func CreatePublication(w http.ResponseWriter, r *http.Request) (interface{}, int
 			return models.Publication{}, nil, 0, 0, err
 		}
 		// sync.ResourceSync(r, publication.Id, "Publication", "create")
+		discoverPublicationFeedsLater(currentUser.Id, publication)
 		return publication, nil, 1, 0, nil
 	}
 	return presentPublication, nil, 1, 0, nil
@@ -274,6 +699,17 @@ func UpdatePublication(r *http.Request, id string) (models.Publication, interfac
 		return models.Publication{}, nil, err
 	}
 
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	// Only admins can change a publication once it has been verified
+	if publication.Verified && !currentUser.Data.IsAdmin {
+		return models.Publication{}, nil, errors.New("A verified publication can only be updated by an admin")
+	}
+
 	decoder := ffjson.NewDecoder()
 	buf, _ := ioutil.ReadAll(r.Body)
 	var updatedPublication models.Publication
@@ -283,20 +719,123 @@ func UpdatePublication(r *http.Request, id string) (models.Publication, interfac
 		return models.Publication{}, nil, err
 	}
 
-	if publication.Verified {
-		return models.Publication{}, nil, errors.New("Url of a verified publication can not be changed")
+	// Read again for the fields that can be set to 0
+	var updatedDetails updatePublicationDetails
+	err = ffjson.NewDecoder().Decode(buf, &updatedDetails)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
 	}
 
-	// If updated publication url is empty and the publication has not been verified
-	if updatedPublication.Url != "" && !publication.Verified {
+	urlChanged := false
+	if updatedPublication.Url != "" && updatedPublication.Url != publication.Url {
 		publication.Url = updatedPublication.Url
-		publication.Save()
+		urlChanged = true
+	}
+
+	// An empty list takes every beat off
+	if updatedPublication.Beats != nil {
+		publication.Beats, err = validateBeatIds(updatedPublication.Beats)
+		if err != nil {
+			return models.Publication{}, nil, err
+		}
+	}
+
+	reparented := updatedDetails.Parent != nil && *updatedDetails.Parent != publication.ParentPublication
+	retyped := updatedPublication.PublicationType != "" && updatedPublication.PublicationType != publication.PublicationType
+	if reparented || retyped {
+		// Moving a publication changes whose journalists count under a
+		// parent, so only admins and whoever added it can
+		if !canEditPublication(currentUser, publication) {
+			return models.Publication{}, nil, errors.New("Forbidden")
+		}
+
+		if updatedDetails.Parent != nil {
+			publication.ParentPublication = *updatedDetails.Parent
+		}
+		utilities.UpdateIfNotBlank(&publication.PublicationType, updatedPublication.PublicationType)
+
+		_, err = publication.Validate()
+		if err != nil {
+			return models.Publication{}, nil, err
+		}
+
+		err = checkPublicationParent(publication)
+		if err != nil {
+			return models.Publication{}, nil, err
+		}
+	}
+
+	// An empty list takes every media type off
+	if updatedPublication.MediaTypes != nil {
+		publication.MediaTypes = updatedPublication.MediaTypes
+	}
+	utilities.UpdateIfNotBlank(&publication.Language, updatedPublication.Language)
+	utilities.UpdateIfNotBlank(&publication.Country, updatedPublication.Country)
+	utilities.UpdateIfNotBlank(&publication.Region, updatedPublication.Region)
+	utilities.UpdateIfNotBlank(&publication.Frequency, updatedPublication.Frequency)
+	if updatedDetails.AudienceSize != nil {
+		publication.AudienceSize = *updatedDetails.AudienceSize
+	}
+
+	_, err = publication.ValidateMetadata()
+	if err != nil {
+		return models.Publication{}, nil, err
+	}
+
+	_, err = publication.Save()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	if urlChanged {
+		discoverPublicationFeedsLater(currentUser.Id, publication)
 	}
 
 	// sync.ResourceSync(r, publication.Id, "Publication", "create")
 	return publication, nil, nil
 }
 
+// Fills in the metadata the publication doesn't have yet from its company
+// profile
+func ApplyCompanyProfile(r *http.Request, id string) (models.Publication, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	publication, err := getPublication(currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	if !canEditPublication(currentUser, publication) {
+		return models.Publication{}, nil, errors.New("Forbidden")
+	}
+
+	companyData, err := getCompanyProfile(r, publication)
+	if err != nil {
+		return models.Publication{}, nil, err
+	}
+
+	err = applyCompanyProfile(&publication, companyData)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.Publication{}, nil, err
+	}
+
+	return publication, nil, nil
+}
+
 func VerifyPublication(r *http.Request, id string) (models.Publication, interface{}, error) {
 	// Get the details of the current user
 	currentId, err := utilities.StringIdToInt(id)
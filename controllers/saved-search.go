@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/api-v1/controllers"
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/search"
+	"github.com/news-ai/tabulae-v1/sync"
+
+	"github.com/news-ai/web/utilities"
+)
+
+type smartListDetails struct {
+	Name            string `json:"name"`
+	RefreshInterval int    `json:"refreshinterval"`
+}
+
+/*
+* Private methods
+ */
+
+/*
+* Get methods
+ */
+
+func getSavedSearchUnauthorized(id int64) (models.SavedSearch, error) {
+	if id == 0 {
+		return models.SavedSearch{}, errors.New("datastore: no such entity")
+	}
+
+	savedSearch := models.SavedSearch{}
+	err := db.DB.Model(&savedSearch).Where("id = ?", id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, err
+	}
+
+	if !savedSearch.Created.IsZero() {
+		savedSearch.Type = "savedsearches"
+		return savedSearch, nil
+	}
+
+	return models.SavedSearch{}, errors.New("No saved search by this id")
+}
+
+func getSavedSearch(r *http.Request, id int64) (models.SavedSearch, error) {
+	savedSearch, err := getSavedSearchUnauthorized(id)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, errors.New("Could not get user")
+	}
+
+	// Saved searches are visible to their creator, admins, and the team
+	// they have been shared with
+	if savedSearch.CreatedBy != user.Id && !user.Data.IsAdmin {
+		if savedSearch.TeamId == 0 || user.Data.TeamId == 0 || savedSearch.TeamId != user.Data.TeamId {
+			return models.SavedSearch{}, errors.New("Forbidden")
+		}
+	}
+
+	return savedSearch, nil
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Get methods
+ */
+
+func GetSavedSearch(r *http.Request, id string) (models.SavedSearch, interface{}, error) {
+	currentId, err := utilities.StringIdToInt(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	savedSearch, err := getSavedSearch(r, currentId)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	return savedSearch, nil, nil
+}
+
+// Gets the saved searches of the user along with the ones shared with their team
+func GetSavedSearches(r *http.Request) ([]models.SavedSearch, interface{}, int, int, error) {
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.SavedSearch{}, nil, 0, 0, err
+	}
+
+	savedSearches := []models.SavedSearch{}
+	query := db.DB.Model(&savedSearches).Where("archived = ?", false)
+	if user.Data.TeamId != 0 {
+		query = query.Where("(created_by = ? OR team_id = ?)", user.Id, user.Data.TeamId)
+	} else {
+		query = query.Where("created_by = ?", user.Id)
+	}
+
+	err = query.Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.SavedSearch{}, nil, 0, 0, err
+	}
+
+	for i := 0; i < len(savedSearches); i++ {
+		savedSearches[i].Type = "savedsearches"
+	}
+
+	return savedSearches, nil, len(savedSearches), 0, nil
+}
+
+// Runs the saved search for the current user
+func GetContactsForSavedSearch(r *http.Request, id string) ([]models.Contact, interface{}, int, int, error) {
+	savedSearch, _, err := GetSavedSearch(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	contacts, total, err := search.SearchContactsBySavedSearch(savedSearch.Query, user.Id, offset, limit)
+	if err != nil {
+		log.Printf("%v", err)
+		return []models.Contact{}, nil, 0, 0, err
+	}
+
+	return contacts, nil, len(contacts), total, nil
+}
+
+/*
+* Create methods
+ */
+
+func CreateSavedSearch(r *http.Request) (models.SavedSearch, interface{}, error) {
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var savedSearch models.SavedSearch
+	err := decoder.Decode(buf, &savedSearch)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return savedSearch, nil, err
+	}
+
+	savedSearch.Query = strings.TrimSpace(savedSearch.Query)
+	if savedSearch.Query == "" {
+		return models.SavedSearch{}, nil, errors.New("Saved search needs a query")
+	}
+
+	if savedSearch.Name == "" {
+		savedSearch.Name = savedSearch.Query
+	}
+
+	// Can only share a saved search with your own team
+	if savedSearch.TeamId != 0 && savedSearch.TeamId != currentUser.Data.TeamId {
+		return models.SavedSearch{}, nil, errors.New("Forbidden")
+	}
+
+	_, err = savedSearch.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	savedSearch.Type = "savedsearches"
+	return savedSearch, nil, nil
+}
+
+// Creates a smart media list whose contacts come from the saved search
+func CreateSmartMediaList(r *http.Request, id string) (models.MediaList, interface{}, error) {
+	savedSearch, _, err := GetSavedSearch(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	buf, _ := ioutil.ReadAll(r.Body)
+	decoder := ffjson.NewDecoder()
+	var details smartListDetails
+	err = decoder.Decode(buf, &details)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	currentUser, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	if details.RefreshInterval < 0 {
+		return models.MediaList{}, nil, errors.New("Refresh interval can not be negative")
+	}
+
+	mediaList := models.MediaList{}
+	mediaList.Name = details.Name
+	if mediaList.Name == "" {
+		mediaList.Name = savedSearch.Name
+	}
+
+	mediaList.FieldsMap = getFieldsMap()
+	mediaList.TeamId = currentUser.Data.TeamId
+	mediaList.SmartList = true
+	mediaList.SavedSearchId = savedSearch.Id
+	mediaList.RefreshInterval = details.RefreshInterval
+
+	_, err = mediaList.Create(r, currentUser)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	sync.ResourceSync(r, mediaList.Id, "List", "create")
+
+	err = refreshSmartMediaList(r, &mediaList)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.MediaList{}, nil, err
+	}
+
+	mediaList.Format("lists")
+	return mediaList, nil, nil
+}
+
+/*
+* Update methods
+ */
+
+func UpdateSavedSearch(r *http.Request, id string) (models.SavedSearch, interface{}, error) {
+	savedSearch, _, err := GetSavedSearch(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	// Team members can run a shared saved search but only the creator can edit it
+	if savedSearch.CreatedBy != user.Id && !user.Data.IsAdmin {
+		return models.SavedSearch{}, nil, errors.New("Forbidden")
+	}
+
+	decoder := ffjson.NewDecoder()
+	buf, _ := ioutil.ReadAll(r.Body)
+	var updatedSavedSearch models.SavedSearch
+	err = decoder.Decode(buf, &updatedSavedSearch)
+	if err != nil {
+		log.Printf("%v", err)
+		return models.SavedSearch{}, nil, err
+	}
+
+	utilities.UpdateIfNotBlank(&savedSearch.Name, updatedSavedSearch.Name)
+	utilities.UpdateIfNotBlank(&savedSearch.Query, strings.TrimSpace(updatedSavedSearch.Query))
+
+	if updatedSavedSearch.TeamId != 0 {
+		if updatedSavedSearch.TeamId != user.Data.TeamId {
+			return models.SavedSearch{}, nil, errors.New("Forbidden")
+		}
+		savedSearch.TeamId = updatedSavedSearch.TeamId
+	}
+
+	// If new saved search wants to be archived then archive it
+	if updatedSavedSearch.Archived == true {
+		savedSearch.Archived = true
+	}
+
+	// If they are already archived and you want to unarchive the saved search
+	if savedSearch.Archived == true && updatedSavedSearch.Archived == false {
+		savedSearch.Archived = false
+	}
+
+	savedSearch.Save()
+	return savedSearch, nil, nil
+}
+
+/*
+* Delete methods
+ */
+
+func DeleteSavedSearch(r *http.Request, id string) (interface{}, interface{}, error) {
+	savedSearch, _, err := GetSavedSearch(r, id)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	user, err := controllers.GetCurrentUser(r)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	if savedSearch.CreatedBy != user.Id && !user.Data.IsAdmin {
+		return nil, nil, errors.New("Forbidden")
+	}
+
+	// Smart lists built on this search can no longer be refreshed
+	mediaLists := []models.MediaList{}
+	err = db.DB.Model(&mediaLists).Where("saved_search_id = ?", savedSearch.Id).Select()
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	if len(mediaLists) > 0 {
+		return nil, nil, errors.New("Saved search is used by a smart list")
+	}
+
+	_, err = savedSearch.Delete()
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, nil, err
+	}
+
+	return nil, nil, nil
+}
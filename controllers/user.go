@@ -63,3 +63,22 @@ func RegisterUser(r *http.Request, user models.User) (models.UserPostgres, bool,
 
 	return existingUser, false, errors.New("User with the email already exists")
 }
+
+// Work that runs outside of a request, like background jobs and migrations,
+// is given a request of its own with the user it works for, like the request
+// the user would have made themselves. The user is cleared from it with
+// gcontext.Clear once the work is done.
+func UserRequest(path string, userId int64) (*http.Request, error) {
+	r, err := http.NewRequest("POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	user, _, err := controllers.GetUserById(r, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	gcontext.Set(r, "user", user)
+	return r, nil
+}
@@ -0,0 +1,101 @@
+package feeds
+
+import (
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+/*
+* Public methods
+ */
+
+// Puts headlines of a publication feed down to the contacts of the feed's
+// owner named in their bylines. A contact is only matched while they worked
+// at the publication, or one of its sections and editions, when the
+// headline came out. Bylines with an address are matched on the address,
+// the rest on the name.
+func AttributeHeadlines(feed models.Feed, headlines []models.Headline) ([]models.HeadlineAttribution, error) {
+	// Feeds of a contact are the contact's already
+	if feed.PublicationId == 0 || feed.ContactId != 0 {
+		return []models.HeadlineAttribution{}, nil
+	}
+
+	bylines := map[int64][]Byline{}
+	for i := 0; i < len(headlines); i++ {
+		if headlines[i].Author != "" {
+			bylines[headlines[i].Id] = ParseByline(headlines[i].Author)
+		}
+	}
+	if len(bylines) == 0 {
+		return []models.HeadlineAttribution{}, nil
+	}
+
+	publicationIds, err := models.PublicationWithDescendants(feed.PublicationId)
+	if err != nil {
+		return []models.HeadlineAttribution{}, err
+	}
+
+	employments, err := models.GetEmploymentsAtPublications(publicationIds)
+	if err != nil {
+		return []models.HeadlineAttribution{}, err
+	}
+
+	contactEmployments := map[int64][]models.Employment{}
+	contactIds := []int64{}
+	for i := 0; i < len(employments); i++ {
+		if _, ok := contactEmployments[employments[i].ContactId]; !ok {
+			contactIds = append(contactIds, employments[i].ContactId)
+		}
+		contactEmployments[employments[i].ContactId] = append(contactEmployments[employments[i].ContactId], employments[i])
+	}
+
+	contacts, err := models.GetContactsOfUser(feed.CreatedBy, contactIds)
+	if err != nil {
+		return []models.HeadlineAttribution{}, err
+	}
+
+	byName := map[string][]models.Contact{}
+	byEmail := map[string][]models.Contact{}
+	for i := 0; i < len(contacts); i++ {
+		if key := NameKey(contacts[i].FirstName + " " + contacts[i].LastName); key != "" {
+			byName[key] = append(byName[key], contacts[i])
+		}
+		if email := strings.ToLower(contacts[i].Email); email != "" {
+			byEmail[email] = append(byEmail[email], contacts[i])
+		}
+	}
+
+	attributions := []models.HeadlineAttribution{}
+	for i := 0; i < len(headlines); i++ {
+		for _, byline := range bylines[headlines[i].Id] {
+			matched := byline.Email
+			candidates := byEmail[byline.Email]
+			if len(candidates) == 0 {
+				matched = byline.Name
+				candidates = byName[NameKey(byline.Name)]
+			}
+
+			for j := 0; j < len(candidates); j++ {
+				employed := false
+				for _, employment := range contactEmployments[candidates[j].Id] {
+					employed = employed || employment.HeldAt(headlines[i].Published)
+				}
+				if !employed {
+					continue
+				}
+
+				attribution := models.HeadlineAttribution{
+					HeadlineId:    headlines[i].Id,
+					ContactId:     candidates[j].Id,
+					PublicationId: headlines[i].PublicationId,
+					Byline:        matched,
+				}
+				attribution.CreatedBy = feed.CreatedBy
+				attributions = append(attributions, attribution)
+			}
+		}
+	}
+
+	return models.SaveHeadlineAttributions(attributions)
+}
@@ -0,0 +1,106 @@
+package feeds
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A person named in the author field of an entry
+type Byline struct {
+	Name  string
+	Email string
+}
+
+var (
+	bylineEmail = regexp.MustCompile(`[^\s<>(),;]+@[^\s<>(),;]+\.[a-zA-Z]{2,}`)
+
+	// Between the people of a byline with more than one
+	bylineSeparator = regexp.MustCompile(`(?i)\s*(?:[,;&/]|\band\b|\bwith\b)\s*`)
+
+	// What follows the names, like "Jane Doe | Reuters"
+	bylineSuffix = regexp.MustCompile(`\s+(?:\||-|–|—)\s+`)
+
+	bylinePrefix = regexp.MustCompile(`(?i)^(?:written\s+)?by\s+`)
+)
+
+// Left out of names before they are compared
+var nameTitles = map[string]bool{
+	"dr":   true,
+	"mr":   true,
+	"mrs":  true,
+	"ms":   true,
+	"prof": true,
+	"jr":   true,
+	"sr":   true,
+	"ii":   true,
+	"iii":  true,
+}
+
+/*
+* Public methods
+ */
+
+// Reads the people out of an author field. It takes the forms feeds use,
+// like "By Jane Doe and John Roe", "Jane Doe | Reuters" and the
+// "jane@example.com (Jane Doe)" of RSS.
+func ParseByline(author string) []Byline {
+	emails := bylineEmail.FindAllString(author, -1)
+	author = bylineEmail.ReplaceAllString(author, " ")
+	author = strings.NewReplacer("(", " ", ")", " ", "<", " ", ">", " ").Replace(author)
+	author = strings.Join(strings.Fields(author), " ")
+	author = bylineSuffix.Split(author, 2)[0]
+
+	bylines := []Byline{}
+	for _, name := range bylineSeparator.Split(author, -1) {
+		name = strings.TrimSpace(bylinePrefix.ReplaceAllString(strings.TrimSpace(name), ""))
+		if name != "" {
+			bylines = append(bylines, Byline{Name: name})
+		}
+	}
+
+	// An address can only be told apart from the others when it is the
+	// one address of the one person
+	if len(emails) == 1 && len(bylines) <= 1 {
+		if len(bylines) == 0 {
+			bylines = append(bylines, Byline{})
+		}
+		bylines[0].Email = strings.ToLower(emails[0])
+		return bylines
+	}
+
+	for _, email := range emails {
+		bylines = append(bylines, Byline{Email: strings.ToLower(email)})
+	}
+	return bylines
+}
+
+// Key names are compared by: the first and last of their words, lower
+// cased without accents, so "Dr. José Q. Pérez" and "jose perez" are the
+// same. Names of one word have no key since too many people share them.
+func NameKey(name string) string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(norm.NFD.String(strings.ToLower(name)), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c) && !unicode.Is(unicode.Mn, c)
+	}) {
+		word = strings.Map(func(c rune) rune {
+			if unicode.Is(unicode.Mn, c) {
+				return -1
+			}
+			return c
+		}, word)
+
+		// Initials and titles are left out
+		if len([]rune(word)) < 2 || nameTitles[word] {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if len(words) < 2 {
+		return ""
+	}
+	return words[0] + " " + words[len(words)-1]
+}
@@ -0,0 +1,76 @@
+package feeds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseByline(t *testing.T) {
+	tests := []struct {
+		author  string
+		bylines []Byline
+	}{
+		{"Jane Doe", []Byline{{Name: "Jane Doe"}}},
+		{"By Jane Doe", []Byline{{Name: "Jane Doe"}}},
+		{"Written by Jane Doe", []Byline{{Name: "Jane Doe"}}},
+		{"By Jane Doe and John Roe", []Byline{{Name: "Jane Doe"}, {Name: "John Roe"}}},
+		{"by Jane Doe, John Roe & Jim Poe", []Byline{{Name: "Jane Doe"}, {Name: "John Roe"}, {Name: "Jim Poe"}}},
+		{"Jane Doe with John Roe", []Byline{{Name: "Jane Doe"}, {Name: "John Roe"}}},
+		{"Andrea Anderson", []Byline{{Name: "Andrea Anderson"}}},
+		{"jane@example.com (Jane Doe)", []Byline{{Name: "Jane Doe", Email: "jane@example.com"}}},
+		{"Jane Doe <Jane@Example.com>", []Byline{{Name: "Jane Doe", Email: "jane@example.com"}}},
+		{"jane@example.com", []Byline{{Email: "jane@example.com"}}},
+		{"Jane Doe | Reuters", []Byline{{Name: "Jane Doe"}}},
+		{"Jane Doe - The Times", []Byline{{Name: "Jane Doe"}}},
+		{"Jane Doe-Roe", []Byline{{Name: "Jane Doe-Roe"}}},
+		{"José Pérez", []Byline{{Name: "José Pérez"}}},
+		{"J. R. Doe", []Byline{{Name: "J. R. Doe"}}},
+		{
+			"jane@example.com, john@example.com",
+			[]Byline{{Email: "jane@example.com"}, {Email: "john@example.com"}},
+		},
+		{
+			"Jane Doe (jane@example.com) and John Roe",
+			[]Byline{{Name: "Jane Doe"}, {Name: "John Roe"}, {Email: "jane@example.com"}},
+		},
+		{"", []Byline{}},
+		{"  ", []Byline{}},
+	}
+
+	for _, test := range tests {
+		bylines := ParseByline(test.author)
+		if !reflect.DeepEqual(bylines, test.bylines) {
+			t.Errorf("ParseByline(%q) = %+v, want %+v", test.author, bylines, test.bylines)
+		}
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"Jane Doe", "jane doe"},
+		{"JANE DOE", "jane doe"},
+		{"Jane Q. Doe", "jane doe"},
+		{"J. Doe", ""},
+		{"J. R. R. Tolkien", ""},
+		{"Dr. José Q. Pérez", "jose perez"},
+		{"jose perez", "jose perez"},
+		{"Zoë Brontë Jr.", "zoe bronte"},
+		{"Jane Doe-Roe", "jane roe"},
+		{"Jane", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if key := NameKey(test.name); key != test.key {
+			t.Errorf("NameKey(%q) = %q, want %q", test.name, key, test.key)
+		}
+	}
+
+	// Decomposed accents are the same as composed ones
+	if NameKey("José Pérez") != NameKey("José Pérez") {
+		t.Errorf("NameKey of decomposed accents = %q, want %q", NameKey("José Pérez"), NameKey("José Pérez"))
+	}
+}
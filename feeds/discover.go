@@ -0,0 +1,223 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// From a <link rel="alternate"> tag on the page
+	CandidateSourceLink = "link"
+
+	// The page given is a feed itself
+	CandidateSourcePage = "page"
+
+	// A path feeds are often found at, like /feed
+	CandidateSourcePath = "path"
+
+	// The feed of an author page, like /author/jane/feed
+	CandidateSourceAuthor = "author"
+)
+
+// A feed found for a site, checked to be one we can read
+type Candidate struct {
+	Url    string `json:"url"`
+	Title  string `json:"title"`
+	Source string `json:"source"`
+	Items  int    `json:"items"`
+}
+
+// Paths tried on the site when its page doesn't point to a feed
+var commonFeedPaths = []string{"/feed", "/rss", "/rss.xml", "/feed.xml", "/atom.xml", "/index.xml", "/feed.json"}
+
+// Feed types announced in <link rel="alternate" type="...">
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/json":      true,
+	"application/rdf+xml":   true,
+	"text/xml":              true,
+}
+
+var (
+	htmlLinkTag   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	htmlAttribute = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	htmlBaseTag   = regexp.MustCompile(`(?is)<base\b[^>]*>`)
+)
+
+/*
+* Private methods
+ */
+
+func htmlAttributes(tag string) map[string]string {
+	attributes := map[string]string{}
+	for _, match := range htmlAttribute.FindAllStringSubmatch(tag, -1) {
+		value := match[2] + match[3] + match[4]
+		attributes[strings.ToLower(match[1])] = html.UnescapeString(strings.TrimSpace(value))
+	}
+	return attributes
+}
+
+// Feeds the page links to in its head
+func feedLinks(page string, base *url.URL) []string {
+	if tag := htmlBaseTag.FindString(page); tag != "" {
+		if href := htmlAttributes(tag)["href"]; href != "" {
+			if baseHref, err := base.Parse(href); err == nil {
+				base = baseHref
+			}
+		}
+	}
+
+	links := []string{}
+	for _, tag := range htmlLinkTag.FindAllString(page, -1) {
+		attributes := htmlAttributes(tag)
+		rels := strings.Fields(strings.ToLower(attributes["rel"]))
+		alternate := false
+		for _, rel := range rels {
+			alternate = alternate || rel == "alternate"
+		}
+
+		contentType := strings.ToLower(strings.TrimSpace(strings.Split(attributes["type"], ";")[0]))
+		if !alternate || !feedLinkTypes[contentType] || attributes["href"] == "" {
+			continue
+		}
+
+		link, err := base.Parse(attributes["href"])
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			continue
+		}
+		links = append(links, link.String())
+	}
+	return links
+}
+
+// Author pages have their own feed on most blogging platforms:
+// /author/jane/feed on WordPress and medium.com/feed/@jane on Medium
+func authorFeedURLs(page *url.URL) []string {
+	path := strings.TrimRight(page.Path, "/")
+	if path == "" {
+		return []string{}
+	}
+
+	if strings.HasSuffix(strings.ToLower(page.Hostname()), "medium.com") && strings.HasPrefix(path, "/@") {
+		return []string{page.Scheme + "://" + page.Host + "/feed" + path}
+	}
+
+	urls := []string{}
+	for _, feedPath := range []string{"/feed", "/rss", "/feed.xml"} {
+		urls = append(urls, page.Scheme+"://"+page.Host+path+feedPath)
+	}
+	return urls
+}
+
+// Gets a page that may or may not be a feed
+func (f *Fetcher) getPage(ctx context.Context, pageURL string) ([]byte, string, *url.URL, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", "text/html, application/xhtml+xml, application/rss+xml, application/atom+xml, */*;q=0.5")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		if isBlockedAddress(err) {
+			return nil, "", nil, errBlockedAddress
+		}
+		return nil, "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", nil, errors.New("Site answered " + resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.MaxSize))
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Redirects change what links are relative to
+	return body, resp.Header.Get("Content-Type"), resp.Request.URL, nil
+}
+
+/*
+* Public methods
+ */
+
+// Looks for the feeds of a site. The page itself is read for
+// <link rel="alternate"> tags, then if it has none, author page feeds and
+// common feed paths are tried. Every candidate is fetched to make sure it is
+// a feed.
+func (f *Fetcher) Discover(ctx context.Context, siteURL string) ([]Candidate, error) {
+	siteURL = strings.TrimSpace(siteURL)
+	if !strings.Contains(siteURL, "://") {
+		siteURL = "http://" + siteURL
+	}
+
+	page, err := url.Parse(siteURL)
+	if err != nil || page.Host == "" {
+		return []Candidate{}, errors.New("Not a valid site URL")
+	}
+
+	body, contentType, finalURL, err := f.getPage(ctx, page.String())
+	if err != nil {
+		return []Candidate{}, err
+	}
+
+	// The site URL can be a feed already
+	if document, err := Parse(body, contentType, finalURL.String()); err == nil {
+		return []Candidate{{Url: finalURL.String(), Title: document.Title, Source: CandidateSourcePage, Items: len(document.Items)}}, nil
+	}
+
+	candidates := []Candidate{}
+	tried := map[string]bool{}
+	try := func(feedURL string, source string) bool {
+		if tried[feedURL] || ctx.Err() != nil {
+			return false
+		}
+		tried[feedURL] = true
+
+		result, err := f.Fetch(ctx, feedURL, "", "")
+		if err != nil {
+			return false
+		}
+
+		candidates = append(candidates, Candidate{Url: feedURL, Title: result.Document.Title, Source: source, Items: len(result.Document.Items)})
+		return true
+	}
+
+	for _, link := range feedLinks(string(body), finalURL) {
+		try(link, CandidateSourceLink)
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, authorURL := range authorFeedURLs(finalURL) {
+		if try(authorURL, CandidateSourceAuthor) {
+			return candidates, nil
+		}
+	}
+
+	for _, feedPath := range commonFeedPaths {
+		if try(finalURL.Scheme+"://"+finalURL.Host+feedPath, CandidateSourcePath) {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// Discover with the fetcher the pollers use
+func Discover(ctx context.Context, siteURL string) ([]Candidate, error) {
+	return fetcher.Discover(ctx, siteURL)
+}
@@ -0,0 +1,242 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Outcome of fetching a feed
+type Result struct {
+	// The server said nothing changed since the last fetch
+	NotModified bool
+
+	// Validators to send with the next fetch
+	ETag         string
+	LastModified string
+
+	StatusCode int
+	Document   Document
+
+	// How long the server asked us to wait, if it did
+	RetryAfter time.Duration
+}
+
+// Fetch errors that mean the feed is not going to work by trying again,
+// like a 404 or a page that is not a feed
+type PermanentError struct {
+	Message string
+}
+
+func (e PermanentError) Error() string {
+	return e.Message
+}
+
+// Fetcher gets feeds over HTTP. It is set up from the environment:
+//
+//	TABULAE_FEED_USER_AGENT:    User-Agent sent to feed servers
+//	TABULAE_FEED_ALLOW_PRIVATE: "true" to fetch from private and loopback
+//	                            addresses, for testing locally
+type Fetcher struct {
+	Client    *http.Client
+	UserAgent string
+
+	// Feeds bigger than this are cut off and most likely fail to parse
+	MaxSize int64
+}
+
+// Feed and site urls come from users, so they can't be used to reach the
+// servers we run next to. Addresses are checked when connecting, after the
+// name has been resolved and for every redirect.
+var blockedNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+var errBlockedAddress = errors.New("Feed server is on a private network")
+
+/*
+* Private methods
+ */
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func isBlockedIP(ip net.IP) bool {
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Refuses connections to private, loopback and link-local addresses
+func refuseBlockedAddress(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isBlockedIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// Whether the request failed because the server is on a blocked address
+func isBlockedAddress(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	return err == errBlockedAddress
+}
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// Retry-After is either a number of seconds or a date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+/*
+* Public methods
+ */
+
+func NewFetcher() *Fetcher {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	if getEnv("TABULAE_FEED_ALLOW_PRIVATE", "false") != "true" {
+		dialer.Control = refuseBlockedAddress
+	}
+
+	// No proxy, since the addresses checked would be the proxy's
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConns:        100,
+	}
+
+	return &Fetcher{
+		Client:    &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		UserAgent: getEnv("TABULAE_FEED_USER_AGENT", "Tabulae Feed Reader (+https://newsai.co)"),
+		MaxSize:   5 << 20,
+	}
+}
+
+// Gets a feed, sending the validators from the last fetch so an unchanged
+// feed comes back as NotModified without a body. Errors that won't go away
+// by trying again are a PermanentError.
+func (f *Fetcher) Fetch(ctx context.Context, feedURL string, etag string, lastModified string) (Result, error) {
+	result := Result{ETag: etag, LastModified: lastModified}
+
+	req, err := http.NewRequest("GET", feedURL, nil)
+	if err != nil || (req.URL.Scheme != "http" && req.URL.Scheme != "https") {
+		return result, PermanentError{"Not a valid feed URL"}
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/json;q=0.9, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		if isBlockedAddress(err) {
+			return result, PermanentError{errBlockedAddress.Error()}
+		}
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		result.NotModified = true
+		return result, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return result, PermanentError{"Feed does not exist anymore"}
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return result, PermanentError{"Feed can not be read without logging in"}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return result, errors.New("Feed server answered " + resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.MaxSize))
+	if err != nil {
+		return result, err
+	}
+
+	document, err := Parse(body, resp.Header.Get("Content-Type"), resp.Request.URL.String())
+	if err != nil {
+		return result, PermanentError{err.Error()}
+	}
+
+	result.Document = document
+	if value := resp.Header.Get("ETag"); value != "" {
+		result.ETag = value
+	}
+	if value := resp.Header.Get("Last-Modified"); value != "" {
+		result.LastModified = value
+	}
+	return result, nil
+}
@@ -0,0 +1,444 @@
+package feeds
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// A feed read into the parts we keep, whatever format it came in
+type Document struct {
+	Title string
+	Link  string
+	Items []Item
+}
+
+type Item struct {
+	// Stays the same when the entry is edited, so entries are only kept once
+	GUID string
+
+	Title   string
+	Url     string
+	Summary string
+	Author  string
+
+	Published time.Time
+}
+
+// RSS 2.0 and 0.9x, and RSS 1.0 which keeps its items next to the channel
+type rssDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Title string    `xml:"title"`
+		Link  string    `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Encoded     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	About       string `xml:"about,attr"`
+}
+
+type atomDocument struct {
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Id        string     `xml:"id"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+type jsonFeedDocument struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	Id            interface{}      `json:"id"`
+	Url           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	Summary       string           `json:"summary"`
+	ContentText   string           `json:"content_text"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Author        *jsonFeedAuthor  `json:"author"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+}
+
+// Summaries are cut down to this many characters
+const maxSummaryLength = 500
+
+// Date formats seen in feeds, most common first
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"02 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+var htmlTags = regexp.MustCompile(`<[^>]*>`)
+
+/*
+* Private methods
+ */
+
+// Feeds declare their encoding in the XML header
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, errors.New("Feed is in an unknown encoding " + charset)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range feedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// Plain text of a bit of HTML, on one line
+func plainText(value string) string {
+	value = htmlTags.ReplaceAllString(value, " ")
+	value = html.UnescapeString(value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func summarize(value string) string {
+	value = plainText(value)
+	if utf8.RuneCountInString(value) <= maxSummaryLength {
+		return value
+	}
+
+	runes := []rune(value)[:maxSummaryLength]
+	summary := string(runes)
+	if i := strings.LastIndex(summary, " "); i > maxSummaryLength/2 {
+		summary = summary[:i]
+	}
+	return summary + "…"
+}
+
+// Links in feeds can be relative to the feed
+func resolveLink(base *url.URL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" || base == nil {
+		return link
+	}
+
+	reference, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(reference).String()
+}
+
+// Items without an id are told apart by their link, or by their title and
+// date when they have no link either
+func itemGUID(item Item) string {
+	if item.Url != "" {
+		return item.Url
+	}
+
+	hash := sha1.Sum([]byte(item.Title + "\n" + item.Published.Format(time.RFC3339)))
+	return "sha1:" + hex.EncodeToString(hash[:])
+}
+
+func finishItem(item Item, base *url.URL) (Item, bool) {
+	item.Title = plainText(item.Title)
+	item.Url = resolveLink(base, item.Url)
+	item.Author = plainText(item.Author)
+	item.GUID = strings.TrimSpace(item.GUID)
+	if item.GUID == "" {
+		item.GUID = itemGUID(item)
+	}
+
+	// An entry with nothing to show isn't worth keeping
+	return item, item.Title != "" || item.Url != ""
+}
+
+func parseRSS(body []byte, base *url.URL) (Document, error) {
+	rss := rssDocument{}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charsetReader
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	err := decoder.Decode(&rss)
+	if err != nil {
+		return Document{}, errors.New("Feed could not be read: " + err.Error())
+	}
+
+	document := Document{Title: plainText(rss.Channel.Title), Link: resolveLink(base, rss.Channel.Link), Items: []Item{}}
+	for _, rssItem := range append(rss.Channel.Items, rss.Items...) {
+		summary := rssItem.Description
+		if summary == "" {
+			summary = rssItem.Encoded
+		}
+
+		author := rssItem.Creator
+		if author == "" {
+			author = rssItem.Author
+		}
+
+		guid := rssItem.GUID
+		if guid == "" {
+			guid = rssItem.About
+		}
+
+		published := parseFeedDate(rssItem.PubDate)
+		if published.IsZero() {
+			published = parseFeedDate(rssItem.Date)
+		}
+
+		item, ok := finishItem(Item{
+			GUID:      guid,
+			Title:     rssItem.Title,
+			Url:       rssItem.Link,
+			Summary:   summarize(summary),
+			Author:    author,
+			Published: published,
+		}, base)
+		if ok {
+			document.Items = append(document.Items, item)
+		}
+	}
+	return document, nil
+}
+
+// The alternate link is the page of the entry. Links without a rel are
+// alternate links too.
+func atomAlternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func parseAtom(body []byte, base *url.URL) (Document, error) {
+	atom := atomDocument{}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charsetReader
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	err := decoder.Decode(&atom)
+	if err != nil {
+		return Document{}, errors.New("Feed could not be read: " + err.Error())
+	}
+
+	document := Document{Title: plainText(atom.Title), Link: resolveLink(base, atomAlternateLink(atom.Links)), Items: []Item{}}
+	for _, entry := range atom.Entries {
+		summary := entry.Summary
+		if summary == "" {
+			summary = entry.Content
+		}
+
+		authors := []string{}
+		for _, author := range entry.Authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				authors = append(authors, name)
+			}
+		}
+
+		published := parseFeedDate(entry.Published)
+		if published.IsZero() {
+			published = parseFeedDate(entry.Updated)
+		}
+
+		item, ok := finishItem(Item{
+			GUID:      entry.Id,
+			Title:     entry.Title,
+			Url:       atomAlternateLink(entry.Links),
+			Summary:   summarize(summary),
+			Author:    strings.Join(authors, ", "),
+			Published: published,
+		}, base)
+		if ok {
+			document.Items = append(document.Items, item)
+		}
+	}
+	return document, nil
+}
+
+func parseJSONFeed(body []byte, base *url.URL) (Document, error) {
+	jsonFeed := jsonFeedDocument{}
+	err := json.Unmarshal(body, &jsonFeed)
+	if err != nil {
+		return Document{}, errors.New("Feed could not be read: " + err.Error())
+	}
+
+	if !strings.HasPrefix(jsonFeed.Version, "https://jsonfeed.org/version/") {
+		return Document{}, errors.New("Not a feed")
+	}
+
+	document := Document{Title: plainText(jsonFeed.Title), Link: resolveLink(base, jsonFeed.HomePageURL), Items: []Item{}}
+	for _, jsonItem := range jsonFeed.Items {
+		summary := jsonItem.Summary
+		if summary == "" {
+			summary = jsonItem.ContentText
+		}
+		if summary == "" {
+			summary = jsonItem.ContentHTML
+		}
+
+		// Version 1.1 has a list of authors where 1.0 had one
+		authors := []string{}
+		if jsonItem.Author != nil && jsonItem.Author.Name != "" {
+			authors = append(authors, jsonItem.Author.Name)
+		}
+		for _, author := range jsonItem.Authors {
+			if author.Name != "" {
+				authors = append(authors, author.Name)
+			}
+		}
+
+		link := jsonItem.Url
+		if link == "" {
+			link = jsonItem.ExternalURL
+		}
+
+		// Ids should be strings but some feeds use numbers
+		guid := ""
+		switch id := jsonItem.Id.(type) {
+		case string:
+			guid = id
+		case float64:
+			guid = strconv.FormatFloat(id, 'f', -1, 64)
+		}
+
+		published := parseFeedDate(jsonItem.DatePublished)
+		if published.IsZero() {
+			published = parseFeedDate(jsonItem.DateModified)
+		}
+
+		item, ok := finishItem(Item{
+			GUID:      guid,
+			Title:     jsonItem.Title,
+			Url:       link,
+			Summary:   summarize(summary),
+			Author:    strings.Join(authors, ", "),
+			Published: published,
+		}, base)
+		if ok {
+			document.Items = append(document.Items, item)
+		}
+	}
+	return document, nil
+}
+
+// Name of the first element, which tells RSS from Atom
+func rootElement(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charsetReader
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", errors.New("Not a feed")
+		}
+		if element, ok := token.(xml.StartElement); ok {
+			return strings.ToLower(element.Name.Local), nil
+		}
+	}
+}
+
+/*
+* Public methods
+ */
+
+// Reads an RSS, Atom or JSON Feed document. Relative links are resolved
+// against feedURL.
+func Parse(body []byte, contentType string, feedURL string) (Document, error) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		base = nil
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return Document{}, errors.New("Feed is empty")
+	}
+
+	if trimmed[0] == '{' || strings.Contains(contentType, "json") {
+		return parseJSONFeed(trimmed, base)
+	}
+
+	root, err := rootElement(trimmed)
+	if err != nil {
+		return Document{}, err
+	}
+
+	switch root {
+	case "rss", "rdf":
+		return parseRSS(trimmed, base)
+	case "feed":
+		return parseAtom(trimmed, base)
+	}
+	return Document{}, errors.New("Not a feed")
+}
@@ -0,0 +1,205 @@
+package feeds
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+var (
+	// How often idle pollers look for feeds that are due
+	feedPollInterval = time.Minute
+
+	// How long a claimed feed is left to its poller before another one may
+	// take it, in case the first one crashed
+	feedFetchLease = 10 * time.Minute
+
+	// Time between fetches of a feed that is doing fine. Feeds with nothing
+	// new wait up to 8 times as long, feeds that fail up to feedMaxBackoff.
+	feedFetchInterval = time.Hour
+	feedMaxQuietSteps = 3
+	feedMaxBackoff    = 24 * time.Hour
+
+	// Feeds stop being polled after this many failed fetches in a row, or
+	// fewer if the feed looks gone for good
+	feedMaxFailures          = 10
+	feedMaxPermanentFailures = 3
+
+	feedPollersOnce sync.Once
+	feedWake        = make(chan struct{}, 1)
+	fetcher         = NewFetcher()
+)
+
+/*
+* Private methods
+ */
+
+// Doubles the wait for every step, up to the limit, with a bit of jitter so
+// feeds added together don't stay in step
+func backoff(steps int, limit time.Duration) time.Duration {
+	wait := feedFetchInterval
+	for i := 0; i < steps && wait < limit; i++ {
+		wait *= 2
+	}
+	if wait > limit {
+		wait = limit
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait/10)+1))
+}
+
+func toHeadlines(items []Item) []models.Headline {
+	headlines := []models.Headline{}
+	for i := 0; i < len(items); i++ {
+		headlines = append(headlines, models.Headline{
+			GUID:      items[i].GUID,
+			Title:     items[i].Title,
+			Url:       items[i].Url,
+			Summary:   items[i].Summary,
+			Author:    items[i].Author,
+			Published: items[i].Published,
+		})
+	}
+	return headlines
+}
+
+// Polls feeds until none are due
+func runDueFeeds() {
+	for {
+		feed, ok, err := models.ClaimDueFeed(feedFetchLease)
+		if err != nil {
+			log.Printf("%v", err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), feedFetchLease/2)
+		_, err = PollFeed(ctx, &feed)
+		cancel()
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+}
+
+func feedPoller() {
+	ticker := time.NewTicker(feedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		runDueFeeds()
+
+		select {
+		case <-feedWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+* Public methods
+ */
+
+// Fetches a feed once, keeps its new entries as headlines and works out when
+// to fetch it next. Fetch problems are recorded on the feed rather than
+// returned, the error is for when the feed couldn't be saved.
+func PollFeed(ctx context.Context, feed *models.Feed) ([]models.Headline, error) {
+	result, err := fetcher.Fetch(ctx, feed.FeedURL, feed.ETag, feed.LastModified)
+	now := time.Now()
+	feed.LastFetched = now
+
+	headlines := []models.Headline{}
+	var wait time.Duration
+	if err == nil {
+		feed.ValidFeed = true
+		feed.Failures = 0
+		feed.LastError = ""
+
+		saved := true
+		if !result.NotModified {
+			if result.Document.Title != "" {
+				feed.Title = result.Document.Title
+			}
+
+			headlines, err = models.SaveHeadlines(*feed, toHeadlines(result.Document.Items))
+			if err != nil {
+				log.Printf("%v", err)
+				feed.LastError = err.Error()
+				headlines = []models.Headline{}
+				saved = false
+			} else {
+				_, err = AttributeHeadlines(*feed, headlines)
+				if err != nil {
+					log.Printf("%v", err)
+				}
+			}
+		}
+
+		// The validators only move on once the headlines are saved, so
+		// headlines that failed to save are fetched again in full
+		if saved {
+			feed.ETag = result.ETag
+			feed.LastModified = result.LastModified
+		}
+
+		if len(headlines) > 0 {
+			feed.Unchanged = 0
+		} else if feed.Unchanged < feedMaxQuietSteps {
+			feed.Unchanged++
+		}
+		wait = backoff(feed.Unchanged, feedMaxBackoff)
+	} else {
+		feed.Failures++
+		feed.LastError = err.Error()
+
+		maxFailures := feedMaxFailures
+		if _, ok := err.(PermanentError); ok {
+			feed.ValidFeed = false
+			maxFailures = feedMaxPermanentFailures
+		}
+		if feed.Failures >= maxFailures {
+			feed.Running = false
+		}
+		wait = backoff(feed.Failures, feedMaxBackoff)
+	}
+
+	if result.RetryAfter > wait {
+		wait = result.RetryAfter
+	}
+	feed.NextFetch = now.Add(wait)
+
+	_, err = feed.SaveFetch()
+	if err != nil {
+		return headlines, err
+	}
+	return headlines, nil
+}
+
+// Starts the pollers that fetch feeds. Called once when the server starts,
+// TABULAE_FEED_WORKERS sets how many run at once.
+func StartFeedPollers() {
+	feedPollersOnce.Do(func() {
+		workers, err := strconv.Atoi(getEnv("TABULAE_FEED_WORKERS", "2"))
+		if err != nil || workers < 1 {
+			workers = 1
+		}
+
+		for i := 0; i < workers; i++ {
+			go feedPoller()
+		}
+	})
+}
+
+// Gets an idle poller to look for due feeds now, like when one is added
+func WakeFeedPollers() {
+	select {
+	case feedWake <- struct{}{}:
+	default:
+	}
+}
@@ -2,25 +2,22 @@ package files
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"google.golang.org/appengine/log"
+	apiControllers "github.com/news-ai/api-v1/controllers"
 
-	"golang.org/x/net/context"
-
-	apiControllers "github.com/news-ai/api/controllers"
-
-	"github.com/news-ai/tabulae/controllers"
-	"github.com/news-ai/tabulae/models"
-	"github.com/news-ai/tabulae/parse"
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/parse"
 
 	"github.com/news-ai/web/utilities"
 )
 
-func HandleBulkEmailAttachActionUpload(c context.Context, r *http.Request) (interface{}, interface{}, int, int, error) {
-	user, err := apiControllers.GetCurrentUser(c, r)
+func HandleBulkEmailAttachActionUpload(r *http.Request) (interface{}, interface{}, int, int, error) {
+	user, err := apiControllers.GetCurrentUser(r)
 	if err != nil {
 		return nil, nil, 0, 0, err
 	}
@@ -35,7 +32,7 @@ func HandleBulkEmailAttachActionUpload(c context.Context, r *http.Request) (inte
 		f, err := fh.Open()
 		defer f.Close()
 		if err != nil {
-			log.Errorf(c, "%v", err)
+			log.Printf("%v", err)
 			return nil, nil, 0, 0, err
 		}
 
@@ -47,7 +44,7 @@ func HandleBulkEmailAttachActionUpload(c context.Context, r *http.Request) (inte
 		fileName := strings.Join([]string{userId, utilities.RandToken(), noSpaceFileName}, "-")
 		val, err := UploadAttachment(r, fh.Filename, fileName, f, userId, "0", fh.Header.Get("Content-Type"))
 		if err != nil {
-			log.Errorf(c, "%v", err)
+			log.Printf("%v", err)
 			return nil, nil, 0, 0, err
 		}
 
@@ -57,8 +54,8 @@ func HandleBulkEmailAttachActionUpload(c context.Context, r *http.Request) (inte
 	return files, nil, len(files), 0, nil
 }
 
-func HandleEmailAttachActionUpload(c context.Context, r *http.Request, id string) (interface{}, interface{}, error) {
-	user, err := apiControllers.GetCurrentUser(c, r)
+func HandleEmailAttachActionUpload(r *http.Request, id string) (interface{}, interface{}, error) {
+	user, err := apiControllers.GetCurrentUser(r)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -73,7 +70,7 @@ func HandleEmailAttachActionUpload(c context.Context, r *http.Request, id string
 		f, err := fh.Open()
 		defer f.Close()
 		if err != nil {
-			log.Errorf(c, "%v", err)
+			log.Printf("%v", err)
 			return nil, nil, err
 		}
 
@@ -85,7 +82,7 @@ func HandleEmailAttachActionUpload(c context.Context, r *http.Request, id string
 		fileName := strings.Join([]string{userId, id, utilities.RandToken(), noSpaceFileName}, "-")
 		val, err := UploadAttachment(r, fh.Filename, fileName, f, userId, id, fh.Header.Get("Content-Type"))
 		if err != nil {
-			log.Errorf(c, "%v", err)
+			log.Printf("%v", err)
 			return nil, nil, err
 		}
 
@@ -95,8 +92,8 @@ func HandleEmailAttachActionUpload(c context.Context, r *http.Request, id string
 	return files, nil, nil
 }
 
-func HandleMediaListActionUpload(c context.Context, r *http.Request, id string) (interface{}, interface{}, error) {
-	user, err := apiControllers.GetCurrentUser(c, r)
+func HandleMediaListActionUpload(r *http.Request, id string) (interface{}, interface{}, error) {
+	user, err := apiControllers.GetCurrentUser(r)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -105,7 +102,7 @@ func HandleMediaListActionUpload(c context.Context, r *http.Request, id string)
 
 	file, handler, err := r.FormFile("file")
 	if err != nil {
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", err)
 		return nil, nil, err
 	}
 
@@ -117,15 +114,15 @@ func HandleMediaListActionUpload(c context.Context, r *http.Request, id string)
 	fileName := strings.Join([]string{userId, id, utilities.RandToken(), noSpaceFileName}, "-")
 	val, err := UploadFile(r, fileName, file, userId, id, handler.Header.Get("Content-Type"))
 	if err != nil {
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", err)
 		return nil, nil, err
 	}
 
 	return val, nil, nil
 }
 
-func HandleEmailImageActionUpload(c context.Context, r *http.Request) (interface{}, interface{}, error) {
-	user, err := apiControllers.GetCurrentUser(c, r)
+func HandleEmailImageActionUpload(r *http.Request) (interface{}, interface{}, error) {
+	user, err := apiControllers.GetCurrentUser(r)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -140,7 +137,7 @@ func HandleEmailImageActionUpload(c context.Context, r *http.Request) (interface
 		f, err := fh.Open()
 		defer f.Close()
 		if err != nil {
-			log.Errorf(c, "%v", err)
+			log.Printf("%v", err)
 			return nil, nil, err
 		}
 
@@ -152,7 +149,7 @@ func HandleEmailImageActionUpload(c context.Context, r *http.Request) (interface
 		fileName := strings.Join([]string{userId, utilities.RandToken(), noSpaceFileName}, "-")
 		val, err := UploadImage(r, fh.Filename, fileName, f, userId, fh.Header.Get("Content-Type"))
 		if err != nil {
-			log.Errorf(c, "%v", err)
+			log.Printf("%v", err)
 			return nil, nil, err
 		}
 
@@ -162,7 +159,9 @@ func HandleEmailImageActionUpload(c context.Context, r *http.Request) (interface
 	return files, nil, nil
 }
 
-func HandleFileUploadHeaders(c context.Context, r *http.Request, id string) (interface{}, interface{}, error) {
+// Queues the file to be imported with the header mapping the user chose. The
+// import job that is returned can be polled for progress.
+func HandleFileUploadHeaders(r *http.Request, id string) (interface{}, interface{}, error) {
 	decoder := json.NewDecoder(r.Body)
 	var fileOrder models.FileOrder
 	err := decoder.Decode(&fileOrder)
@@ -170,62 +169,95 @@ func HandleFileUploadHeaders(c context.Context, r *http.Request, id string) (int
 		return nil, nil, err
 	}
 
-	// Get & write file
-	file, _, err := controllers.GetFile(c, r, id)
+	return QueueFileImport(r, id, fileOrder)
+}
+
+// Parses the file with the header mapping the user chose and reports what the
+// import would do. Nothing is written and the file stays importable.
+func HandleFileDryRun(r *http.Request, id string) (interface{}, interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	var fileOrder models.FileOrder
+	err := decoder.Decode(&fileOrder)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if file.Imported {
+	file, err := getFile(r, id)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	file.HeaderNames = fileOrder.HeaderNames
-	file.Order = fileOrder.Order
-
-	// Read file
 	byteFile, contentType, err := ReadFile(r, id)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Import the file
-	_, err = parse.ExcelHeadersToListModel(r, byteFile, file.FileName, file.HeaderNames, file.Order, file.ListId, contentType)
+	var report parse.ImportReport
+	if parse.IsVCardFile(file.FileName, contentType) {
+		report, err = parse.VCardDryRun(r, byteFile)
+	} else if parse.IsDelimitedFile(file.FileName, contentType) {
+		report, err = parse.CSVDryRun(r, byteFile, fileOrder.HeaderNames, fileOrder.Order, fileOrder.Transforms)
+	} else {
+		report, err = parse.ExcelDryRun(r, byteFile, fileOrder.HeaderNames, fileOrder.Order, fileOrder.Transforms, contentType)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Return the file
-	file.Imported = true
-	val, err := file.Save(c)
+	return report, nil, nil
+}
+
+// Files the user mapped before that header suggestions are learned from
+var headerHistoryFiles = 50
+
+// Returns the headers of the file with a few values of each. Suggested
+// fields for each column, from a matching import profile if there is one,
+// are returned alongside them.
+func HandleFileGetHeaders(r *http.Request, id string) (interface{}, interface{}, error) {
+	file, contentType, err := ReadFile(r, id)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Return value
-	if err == nil {
-		return val, nil, nil
+	fileDetails, err := getFile(r, id)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, nil, err
-}
-
-func HandleFileGetHeaders(c context.Context, r *http.Request, id string) (interface{}, interface{}, error) {
-	file, contentType, err := ReadFile(r, id)
+	// Parse file headers and report to API
+	var val interface{}
+	columns := []parse.Column{}
+	if parse.IsDelimitedFile(fileDetails.FileName, contentType) {
+		columns, err = parse.FileToCSVHeader(r, file)
+		val = columns
+	} else {
+		excelColumns, excelErr := parse.FileToExcelHeader(r, file, contentType)
+		columns, err = parse.ExcelHeaderColumns(excelColumns), excelErr
+		val = excelColumns
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Parse file headers and report to API
-	val, err := parse.FileToExcelHeader(r, file, contentType)
+	// A profile the team saved for files like this one wins over guessing
+	headerNames := make([]string, len(columns))
+	for i := 0; i < len(columns); i++ {
+		headerNames[i] = columns[i].Name
+	}
+	importProfile, _, err := controllers.GetImportProfileForHeaders(r, headerNames)
 	if err == nil {
-		return val, nil, nil
+		return val, parse.ProfileHeaderMappings(columns, importProfile), nil
 	}
 
-	return nil, nil, err
+	pastFiles, err := controllers.FilterFilesWithHeadersForUser(r, headerHistoryFiles)
+	if err != nil {
+		pastFiles = []models.File{}
+	}
+
+	return val, parse.SuggestHeaderMappings(columns, pastFiles), nil
 }
 
-func HandleFileGetSheets(c context.Context, r *http.Request, id string) (interface{}, interface{}, error) {
+func HandleFileGetSheets(r *http.Request, id string) (interface{}, interface{}, error) {
 	file, contentType, err := ReadFile(r, id)
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,207 @@
+package files
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/parse"
+)
+
+var (
+	// Rows imported between checkpoints
+	importChunkSize = 200
+
+	// How often idle workers look for queued jobs
+	importPollInterval = 5 * time.Second
+
+	// Running jobs without a heartbeat for this long are taken over, since
+	// the worker running them has most likely crashed
+	importStaleAfter = 10 * time.Minute
+
+	importWorkersOnce sync.Once
+	importWake        = make(chan struct{}, 1)
+)
+
+/*
+* Private methods
+ */
+
+func toImportJobErrors(rowErrors []parse.RowError) []models.ImportJobError {
+	importJobErrors := []models.ImportJobError{}
+	for i := 0; i < len(rowErrors); i++ {
+		importJobErrors = append(importJobErrors, models.ImportJobError{
+			Row:     rowErrors[i].Row,
+			Column:  rowErrors[i].Column,
+			Message: rowErrors[i].Message,
+			Skipped: rowErrors[i].Skipped,
+		})
+	}
+	return importJobErrors
+}
+
+// Works through a job chunk by chunk from its checkpoint. Returns
+// controllers.ErrImportJobCancelled or controllers.ErrImportJobTakenOver if
+// the job stops being the worker's on the way.
+func runImportJob(r *http.Request, importJob *models.ImportJob) error {
+	file, _, err := controllers.GetFileById(r, importJob.FileId)
+	if err != nil {
+		return err
+	}
+
+	byteFile, contentType, err := ReadFile(r, strconv.FormatInt(file.Id, 10))
+	if err != nil {
+		return err
+	}
+
+	reader, err := parse.NewImportReader(r, byteFile, file.FileName, contentType, importJob.HeaderNames, importJob.Order, importJob.Transforms)
+	if err != nil {
+		return err
+	}
+
+	if importJob.TotalRows == 0 {
+		err = controllers.SetImportJobTotal(importJob, reader.Total())
+		if err != nil {
+			return err
+		}
+	}
+
+	reader.Skip(importJob.Checkpoint)
+
+	for {
+		contacts := []models.Contact{}
+		rowErrors := []parse.RowError{}
+		checkpoint, rowsDone, rowsFailed := importJob.Checkpoint, 0, 0
+
+		done := false
+		for len(contacts) < importChunkSize {
+			contact, rowNumber, contactErrors, err := reader.Next()
+			if err == io.EOF {
+				done = true
+				break
+			}
+
+			checkpoint = rowNumber
+			rowsDone++
+			rowErrors = append(rowErrors, contactErrors...)
+			if err != nil {
+				rowsFailed++
+				rowErrors = append(rowErrors, parse.RowError{Row: rowNumber, Message: err.Error(), Skipped: true})
+				continue
+			}
+
+			contacts = append(contacts, contact)
+		}
+
+		err = controllers.CheckpointImportJob(r, importJob, contacts, toImportJobErrors(rowErrors), checkpoint, rowsDone, rowsFailed, reader.AddCustomFields)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return nil
+		}
+	}
+}
+
+// Whether the job stopped being the worker's while it ran it
+func lostImportJob(err error) bool {
+	return err == controllers.ErrImportJobCancelled || err == controllers.ErrImportJobTakenOver
+}
+
+// Claims and runs jobs until there are none left
+func runQueuedImportJobs(workerId string) {
+	for {
+		importJob, ok, err := controllers.ClaimImportJob(workerId, importStaleAfter)
+		if err != nil || !ok {
+			return
+		}
+
+		r, err := controllers.UserRequest("/importjobs/"+strconv.FormatInt(importJob.Id, 10), importJob.CreatedBy)
+		if err == nil {
+			err = runImportJob(r, &importJob)
+			gcontext.Clear(r)
+		}
+
+		if lostImportJob(err) {
+			continue
+		}
+		if err != nil {
+			log.Printf("%v", err)
+		}
+
+		err = controllers.FinishImportJob(&importJob, err)
+		if err != nil && !lostImportJob(err) {
+			log.Printf("%v", err)
+		}
+	}
+}
+
+func importWorker(workerId string) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+
+	for {
+		runQueuedImportJobs(workerId)
+
+		select {
+		case <-importWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+* Public methods
+ */
+
+// Starts the workers that run import jobs. Called once when the server
+// starts, TABULAE_IMPORT_WORKERS sets how many run at once.
+func StartImportWorkers() {
+	importWorkersOnce.Do(func() {
+		workers, err := strconv.Atoi(getEnv("TABULAE_IMPORT_WORKERS", "2"))
+		if err != nil || workers < 1 {
+			workers = 1
+		}
+
+		// Workers are told apart across instances and restarts, so a worker
+		// that comes back can't write to a job that was taken over from it
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "localhost"
+		}
+		instance := hostname + "-" + strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+		for i := 0; i < workers; i++ {
+			go importWorker(instance + "-" + strconv.Itoa(i))
+		}
+	})
+}
+
+// Queues a file to be imported and wakes up a worker for it
+func QueueFileImport(r *http.Request, id string, fileOrder models.FileOrder) (interface{}, interface{}, error) {
+	file, err := getFile(r, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	importJob, _, err := controllers.CreateImportJob(r, file.Id, fileOrder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	select {
+	case importWake <- struct{}{}:
+	default:
+	}
+
+	return importJob, nil, nil
+}
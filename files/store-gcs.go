@@ -0,0 +1,80 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"cloud.google.com/go/storage"
+)
+
+// Google Cloud Storage
+type gcsBlobStore struct {
+	// Entity that owns every object that gets written
+	OwnerEntity string
+}
+
+func newGCSBlobStore() *gcsBlobStore {
+	return &gcsBlobStore{
+		OwnerEntity: getEnv("TABULAE_GCS_OWNER_ENTITY", "project-owners-newsai-1166"),
+	}
+}
+
+func (s *gcsBlobStore) Put(ctx context.Context, bucket string, name string, data []byte, options BlobOptions) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	wc := client.Bucket(bucket).Object(name).NewWriter(ctx)
+	wc.ContentType = options.ContentType
+	wc.CacheControl = options.CacheControl
+	wc.ContentDisposition = options.ContentDisposition
+	wc.Metadata = options.Metadata
+	wc.ACL = []storage.ACLRule{{Entity: storage.ACLEntity(s.OwnerEntity), Role: storage.RoleOwner}}
+	if options.Public {
+		wc.ACL = append(wc.ACL, storage.ACLRule{Entity: storage.AllUsers, Role: storage.RoleReader})
+	}
+
+	if _, err := wc.Write(data); err != nil {
+		wc.Close()
+		return err
+	}
+	return wc.Close()
+}
+
+func (s *gcsBlobStore) Get(ctx context.Context, bucket string, name string) ([]byte, string, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer client.Close()
+
+	rc, err := client.Bucket(bucket).Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, rc.ContentType(), nil
+}
+
+func (s *gcsBlobStore) PublicURL(bucket string, name string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, name)
+}
+
+func (s *gcsBlobStore) Delete(ctx context.Context, bucket string, name string) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Bucket(bucket).Object(name).Delete(ctx)
+}
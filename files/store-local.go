@@ -0,0 +1,107 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Keeps objects on the local disk under TABULAE_BLOB_DIR/<bucket>/<name> so
+// uploads work offline. Options are kept next to each object in a
+// "<name>.meta" file. TABULAE_BLOB_URL is the base URL public objects are
+// served from, if something serves the directory.
+type localBlobStore struct {
+	Root    string
+	BaseURL string
+}
+
+func newLocalBlobStore() (*localBlobStore, error) {
+	root := getEnv("TABULAE_BLOB_DIR", filepath.Join(os.TempDir(), "tabulae-blobs"))
+	err := os.MkdirAll(root, 0755)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := strings.TrimSuffix(getEnv("TABULAE_BLOB_URL", "file://"+filepath.ToSlash(root)), "/")
+	return &localBlobStore{Root: root, BaseURL: baseURL}, nil
+}
+
+// Keeps object names from escaping the bucket directory
+func (s *localBlobStore) path(bucket string, name string) (string, error) {
+	if bucket == "" || name == "" || strings.ContainsAny(bucket, `/\`) || bucket == "." || bucket == ".." {
+		return "", errors.New("Invalid bucket or object name")
+	}
+
+	cleanName := filepath.Clean("/" + filepath.FromSlash(name))
+	if cleanName == string(filepath.Separator) {
+		return "", errors.New("Invalid bucket or object name")
+	}
+
+	return filepath.Join(s.Root, bucket, cleanName), nil
+}
+
+func (s *localBlobStore) Put(ctx context.Context, bucket string, name string, data []byte, options BlobOptions) error {
+	path, err := s.path(bucket, name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path+".meta", meta, 0644)
+}
+
+func (s *localBlobStore) Get(ctx context.Context, bucket string, name string) ([]byte, string, error) {
+	path, err := s.path(bucket, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	options := BlobOptions{}
+	meta, err := ioutil.ReadFile(path + ".meta")
+	if err == nil {
+		json.Unmarshal(meta, &options)
+	}
+
+	return data, options.ContentType, nil
+}
+
+func (s *localBlobStore) PublicURL(bucket string, name string) string {
+	return s.BaseURL + "/" + bucket + "/" + name
+}
+
+func (s *localBlobStore) Delete(ctx context.Context, bucket string, name string) error {
+	path, err := s.path(bucket, name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		return err
+	}
+
+	os.Remove(path + ".meta")
+	return nil
+}
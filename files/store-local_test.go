@@ -0,0 +1,147 @@
+package files
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestLocalBlobStore(t *testing.T) (*localBlobStore, func()) {
+	root, err := ioutil.TempDir("", "tabulae-blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &localBlobStore{Root: root, BaseURL: "http://localhost/blobs"}, func() {
+		os.RemoveAll(root)
+	}
+}
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	store, cleanup := newTestLocalBlobStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{"list.csv", []byte("name,email\nJane Doe,jane@example.com\n"), "text/csv"},
+		{"12/images/logo.png", []byte{0x89, 'P', 'N', 'G'}, "image/png"},
+		{"empty.txt", []byte{}, ""},
+	}
+
+	for _, test := range tests {
+		err := store.Put(ctx, "files", test.name, test.data, BlobOptions{ContentType: test.contentType})
+		if err != nil {
+			t.Fatalf("Put(%q) returned error: %v", test.name, err)
+		}
+
+		data, contentType, err := store.Get(ctx, "files", test.name)
+		if err != nil {
+			t.Fatalf("Get(%q) returned error: %v", test.name, err)
+		}
+		if string(data) != string(test.data) {
+			t.Errorf("Get(%q) = %q, want %q", test.name, data, test.data)
+		}
+		if contentType != test.contentType {
+			t.Errorf("Get(%q) content type = %q, want %q", test.name, contentType, test.contentType)
+		}
+
+		err = store.Delete(ctx, "files", test.name)
+		if err != nil {
+			t.Fatalf("Delete(%q) returned error: %v", test.name, err)
+		}
+
+		_, _, err = store.Get(ctx, "files", test.name)
+		if err == nil {
+			t.Errorf("Get(%q) after Delete returned no error", test.name)
+		}
+
+		path, _ := store.path("files", test.name)
+		if _, err := os.Stat(path + ".meta"); !os.IsNotExist(err) {
+			t.Errorf("Delete(%q) left the options file behind", test.name)
+		}
+	}
+}
+
+func TestLocalBlobStoreOverwrite(t *testing.T) {
+	store, cleanup := newTestLocalBlobStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	err := store.Put(ctx, "files", "list.csv", []byte("first"), BlobOptions{ContentType: "text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(ctx, "files", "list.csv", []byte("second"), BlobOptions{ContentType: "text/csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, contentType, err := store.Get(ctx, "files", "list.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" || contentType != "text/csv" {
+		t.Errorf("Get after overwrite = %q, %q, want %q, %q", data, contentType, "second", "text/csv")
+	}
+}
+
+func TestLocalBlobStoreDeleteMissing(t *testing.T) {
+	store, cleanup := newTestLocalBlobStore(t)
+	defer cleanup()
+
+	err := store.Delete(context.Background(), "files", "missing.csv")
+	if err == nil {
+		t.Error("Delete of a missing object returned no error")
+	}
+}
+
+func TestLocalBlobStoreNamesStayInBucket(t *testing.T) {
+	store, cleanup := newTestLocalBlobStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	err := store.Put(ctx, "files", "../../escaped.txt", []byte("data"), BlobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(store.Root, "files", "escaped.txt")); err != nil {
+		t.Errorf("object was not kept inside the bucket: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(store.Root), "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("object was written outside the store")
+	}
+
+	invalid := []struct {
+		bucket string
+		name   string
+	}{
+		{"", "list.csv"},
+		{"files", ""},
+		{"files", "/"},
+		{"..", "list.csv"},
+		{"files/other", "list.csv"},
+	}
+	for _, test := range invalid {
+		err := store.Put(ctx, test.bucket, test.name, []byte("data"), BlobOptions{})
+		if err == nil {
+			t.Errorf("Put(%q, %q) returned no error", test.bucket, test.name)
+		}
+	}
+}
+
+func TestLocalBlobStorePublicURL(t *testing.T) {
+	store, cleanup := newTestLocalBlobStore(t)
+	defer cleanup()
+
+	got := store.PublicURL("images", "12/logo.png")
+	want := "http://localhost/blobs/images/12/logo.png"
+	if got != want {
+		t.Errorf("PublicURL = %q, want %q", got, want)
+	}
+}
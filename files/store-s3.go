@@ -0,0 +1,113 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Amazon S3 or anything that speaks its API (Minio, DigitalOcean Spaces...).
+// Configured with TABULAE_S3_ENDPOINT, TABULAE_S3_REGION,
+// TABULAE_S3_ACCESS_KEY and TABULAE_S3_SECRET_KEY. Without keys the default
+// AWS credential chain is used.
+type s3BlobStore struct {
+	client *s3.S3
+
+	Endpoint string
+	Region   string
+}
+
+func newS3BlobStore() (*s3BlobStore, error) {
+	store := &s3BlobStore{
+		Endpoint: strings.TrimSuffix(getEnv("TABULAE_S3_ENDPOINT", ""), "/"),
+		Region:   getEnv("TABULAE_S3_REGION", "us-east-1"),
+	}
+
+	config := aws.NewConfig().WithRegion(store.Region)
+	if endpoint := store.Endpoint; endpoint != "" {
+		// Most S3-compatible servers don't support bucket subdomains
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	accessKey := getEnv("TABULAE_S3_ACCESS_KEY", "")
+	secretKey := getEnv("TABULAE_S3_SECRET_KEY", "")
+	if accessKey != "" && secretKey != "" {
+		config = config.WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, ""))
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	store.client = s3.New(sess)
+	return store, nil
+}
+
+func (s *s3BlobStore) Put(ctx context.Context, bucket string, name string, data []byte, options BlobOptions) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(data),
+		ACL:    aws.String(s3.ObjectCannedACLPrivate),
+	}
+
+	if options.Public {
+		input.ACL = aws.String(s3.ObjectCannedACLPublicRead)
+	}
+	if options.ContentType != "" {
+		input.ContentType = aws.String(options.ContentType)
+	}
+	if options.CacheControl != "" {
+		input.CacheControl = aws.String(options.CacheControl)
+	}
+	if options.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(options.ContentDisposition)
+	}
+	if len(options.Metadata) > 0 {
+		input.Metadata = aws.StringMap(options.Metadata)
+	}
+
+	_, err := s.client.PutObjectWithContext(ctx, input)
+	return err
+}
+
+func (s *s3BlobStore) Get(ctx context.Context, bucket string, name string) ([]byte, string, error) {
+	output, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer output.Body.Close()
+
+	data, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, aws.StringValue(output.ContentType), nil
+}
+
+func (s *s3BlobStore) PublicURL(bucket string, name string) string {
+	if s.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", s.Endpoint, bucket, name)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, s.Region, name)
+}
+
+func (s *s3BlobStore) Delete(ctx context.Context, bucket string, name string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(name),
+	})
+	return err
+}
@@ -0,0 +1,94 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Options for a single object written to a BlobStore
+type BlobOptions struct {
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	Metadata           map[string]string
+
+	// Whether anyone should be able to read the object from its URL
+	Public bool
+}
+
+// BlobStore is where uploaded files, email images and attachments are kept.
+// Which implementation is used is picked by the TABULAE_BLOB_STORE
+// environment variable: "gcs" (default), "s3" or "local".
+type BlobStore interface {
+	Put(ctx context.Context, bucket string, name string, data []byte, options BlobOptions) error
+	Get(ctx context.Context, bucket string, name string) ([]byte, string, error)
+	Delete(ctx context.Context, bucket string, name string) error
+
+	// Where a public object can be read from
+	PublicURL(bucket string, name string) string
+}
+
+var (
+	blobStore     BlobStore
+	blobStoreErr  error
+	blobStoreOnce sync.Once
+)
+
+/*
+* Private methods
+ */
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func newBlobStore() (BlobStore, error) {
+	switch getEnv("TABULAE_BLOB_STORE", "gcs") {
+	case "gcs":
+		return newGCSBlobStore(), nil
+	case "s3":
+		return newS3BlobStore()
+	case "local":
+		return newLocalBlobStore()
+	}
+	return nil, errors.New("Unknown blob store, expected gcs, s3 or local")
+}
+
+// Buckets files are kept in. They can be overridden for staging and local
+// development.
+func getStorageBucket() string {
+	return getEnv("TABULAE_FILES_BUCKET", "newsai-1166.appspot.com")
+}
+
+func getImageStorageBucket() string {
+	return getEnv("TABULAE_IMAGES_BUCKET", "tabulae-email-images")
+}
+
+func getAttachmentStorageBucket() string {
+	return getEnv("TABULAE_ATTACHMENTS_BUCKET", "tabulae-email-attachment")
+}
+
+/*
+* Public methods
+ */
+
+// Gets the configured blob store. It is set up once per instance.
+func GetBlobStore() (BlobStore, error) {
+	blobStoreOnce.Do(func() {
+		blobStore, blobStoreErr = newBlobStore()
+	})
+	return blobStore, blobStoreErr
+}
+
+// Replaces the configured blob store, for tools and tests that need to
+// point uploads somewhere else
+func SetBlobStore(store BlobStore) {
+	blobStoreOnce.Do(func() {})
+	blobStore = store
+	blobStoreErr = nil
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// The beat taxonomy, and the beats contacts and publications cover
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.Beat{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			"ALTER TABLE contacts ADD COLUMN IF NOT EXISTS beats jsonb",
+			"ALTER TABLE publications ADD COLUMN IF NOT EXISTS beats jsonb",
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db,
+			"ALTER TABLE contacts DROP COLUMN IF EXISTS beats",
+			"ALTER TABLE publications DROP COLUMN IF EXISTS beats",
+		)
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.Beat{})
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Whether the email addresses of contacts are known to work, and the jobs
+// that check the addresses of a list
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.EmailVerificationJob{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			`ALTER TABLE contacts
+				ADD COLUMN IF NOT EXISTS email_verification text,
+				ADD COLUMN IF NOT EXISTS email_verification_reason text,
+				ADD COLUMN IF NOT EXISTS email_verified timestamptz`,
+			"CREATE INDEX IF NOT EXISTS email_verification_jobs_list_id_idx ON email_verification_jobs (list_id)",
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db,
+			`ALTER TABLE contacts
+				DROP COLUMN IF EXISTS email_verification,
+				DROP COLUMN IF EXISTS email_verification_reason,
+				DROP COLUMN IF EXISTS email_verified`,
+		)
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.EmailVerificationJob{})
+	})
+}
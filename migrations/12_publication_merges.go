@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Publications found to be duplicates of each other, and the publication a
+// duplicate was merged into
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.PublicationDuplicate{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			"ALTER TABLE publications ADD COLUMN IF NOT EXISTS merged_into bigint DEFAULT 0",
+			"CREATE INDEX IF NOT EXISTS publication_duplicates_publication_id_idx ON publication_duplicates (publication_id)",
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db, "ALTER TABLE publications DROP COLUMN IF EXISTS merged_into")
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.PublicationDuplicate{})
+	})
+}
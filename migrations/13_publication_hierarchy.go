@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+)
+
+// Sections and editions of publications
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		return execAll(db,
+			`ALTER TABLE publications
+				ADD COLUMN IF NOT EXISTS publication_type text,
+				ADD COLUMN IF NOT EXISTS parent_publication bigint DEFAULT 0`,
+			"CREATE INDEX IF NOT EXISTS publications_parent_publication_idx ON publications (parent_publication)",
+		)
+	}, func(db pgMigrations.DB) error {
+		return execAll(db,
+			`ALTER TABLE publications
+				DROP COLUMN IF EXISTS publication_type,
+				DROP COLUMN IF EXISTS parent_publication`,
+		)
+	})
+}
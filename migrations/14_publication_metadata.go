@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+)
+
+// What kind of outlet a publication is and who it reaches
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		return execAll(db,
+			`ALTER TABLE publications
+				ADD COLUMN IF NOT EXISTS media_types jsonb,
+				ADD COLUMN IF NOT EXISTS language text,
+				ADD COLUMN IF NOT EXISTS country text,
+				ADD COLUMN IF NOT EXISTS region text,
+				ADD COLUMN IF NOT EXISTS frequency text,
+				ADD COLUMN IF NOT EXISTS audience_size bigint`,
+		)
+	}, func(db pgMigrations.DB) error {
+		return execAll(db,
+			`ALTER TABLE publications
+				DROP COLUMN IF EXISTS media_types,
+				DROP COLUMN IF EXISTS language,
+				DROP COLUMN IF EXISTS country,
+				DROP COLUMN IF EXISTS region,
+				DROP COLUMN IF EXISTS frequency,
+				DROP COLUMN IF EXISTS audience_size`,
+		)
+	})
+}
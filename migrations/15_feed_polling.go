@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// What the feed pollers keep about each feed, and the headlines they find
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.Headline{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			`ALTER TABLE feeds
+				ADD COLUMN IF NOT EXISTS source text,
+				ADD COLUMN IF NOT EXISTS title text,
+				ADD COLUMN IF NOT EXISTS e_tag text,
+				ADD COLUMN IF NOT EXISTS last_modified text,
+				ADD COLUMN IF NOT EXISTS last_fetched timestamptz,
+				ADD COLUMN IF NOT EXISTS next_fetch timestamptz,
+				ADD COLUMN IF NOT EXISTS failures bigint,
+				ADD COLUMN IF NOT EXISTS unchanged bigint,
+				ADD COLUMN IF NOT EXISTS last_error text`,
+			"CREATE INDEX IF NOT EXISTS feeds_next_fetch_idx ON feeds (next_fetch) WHERE running = true",
+			"CREATE INDEX IF NOT EXISTS headlines_feed_id_guid_idx ON headlines (feed_id, guid)",
+			"CREATE INDEX IF NOT EXISTS headlines_contact_id_idx ON headlines (contact_id)",
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db,
+			"DROP INDEX IF EXISTS feeds_next_fetch_idx",
+			`ALTER TABLE feeds
+				DROP COLUMN IF EXISTS source,
+				DROP COLUMN IF EXISTS title,
+				DROP COLUMN IF EXISTS e_tag,
+				DROP COLUMN IF EXISTS last_modified,
+				DROP COLUMN IF EXISTS last_fetched,
+				DROP COLUMN IF EXISTS next_fetch,
+				DROP COLUMN IF EXISTS failures,
+				DROP COLUMN IF EXISTS unchanged,
+				DROP COLUMN IF EXISTS last_error`,
+		)
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.Headline{})
+	})
+}
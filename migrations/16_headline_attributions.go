@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Contacts credited with the headlines of publication feeds by their byline
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.HeadlineAttribution{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db, "CREATE INDEX IF NOT EXISTS headline_attributions_contact_id_idx ON headline_attributions (contact_id)")
+	}, func(db pgMigrations.DB) error {
+		return dropTables(db, &models.HeadlineAttribution{})
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"net/http"
+
+	pgMigrations "github.com/go-pg/migrations"
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+)
+
+// Groups emails sent before campaigns existed into campaigns, so they show
+// up in the campaign views
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		var userIds pg.Ints
+		_, err := db.Query(&userIds, "SELECT DISTINCT created_by FROM emails WHERE campaign_id = 0 AND is_sent = true")
+		if err != nil {
+			return err
+		}
+
+		return forEachUser(userIds, func(r *http.Request) error {
+			_, err := controllers.BackfillEmailCampaigns(r)
+			return err
+		})
+	}, func(db pgMigrations.DB) error {
+		// The campaigns are kept, they are what the emails are shown by now
+		return nil
+	})
+}
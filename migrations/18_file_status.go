@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Files kept whether they had been imported in a flag before import jobs
+// gave them a status. Imported files would otherwise look like they could be
+// imported again.
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		_, err := db.Exec("UPDATE files SET status = ? WHERE status IS NULL AND imported = true", models.FileStatusImported)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec("UPDATE files SET status = ? WHERE status IS NULL", models.FileStatusUploaded)
+		return err
+	}, func(db pgMigrations.DB) error {
+		_, err := db.Exec("UPDATE files SET imported = true WHERE status IN (?, ?, ?)", models.FileStatusQueued, models.FileStatusImporting, models.FileStatusImported)
+		return err
+	})
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+)
+
+// Contacts kept their jobs only in Employers and PastEmployers before
+// employments. They are opened here rather than when the jobs are read.
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		return controllers.BackfillEmployments()
+	}, func(db pgMigrations.DB) error {
+		// Employments are what jobs are changed through by now
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Saved searches, and the smart lists that take their contacts from one
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.SavedSearch{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			`ALTER TABLE media_lists
+				ADD COLUMN IF NOT EXISTS smart_list boolean DEFAULT false,
+				ADD COLUMN IF NOT EXISTS saved_search_id bigint,
+				ADD COLUMN IF NOT EXISTS refresh_interval bigint,
+				ADD COLUMN IF NOT EXISTS last_refreshed timestamptz`,
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db,
+			`ALTER TABLE media_lists
+				DROP COLUMN IF EXISTS smart_list,
+				DROP COLUMN IF EXISTS saved_search_id,
+				DROP COLUMN IF EXISTS refresh_interval,
+				DROP COLUMN IF EXISTS last_refreshed`,
+		)
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.SavedSearch{})
+	})
+}
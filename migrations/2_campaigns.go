@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Campaigns, and the campaign every email is sent as part of
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.Campaign{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			"ALTER TABLE emails ADD COLUMN IF NOT EXISTS campaign_id bigint DEFAULT 0",
+			"CREATE INDEX IF NOT EXISTS emails_campaign_id_idx ON emails (campaign_id)",
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db,
+			"DROP INDEX IF EXISTS emails_campaign_id_idx",
+			"ALTER TABLE emails DROP COLUMN IF EXISTS campaign_id",
+		)
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.Campaign{})
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Opens, clicks and bounces of sent emails, which campaign analytics are
+// counted from
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.EmailEvent{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			"CREATE INDEX IF NOT EXISTS email_events_campaign_id_idx ON email_events (campaign_id)",
+			"CREATE INDEX IF NOT EXISTS email_events_contact_id_idx ON email_events (contact_id)",
+		)
+	}, func(db pgMigrations.DB) error {
+		return dropTables(db, &models.EmailEvent{})
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+)
+
+// How much contacts engage with the emails sent to them
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		return execAll(db,
+			`ALTER TABLE contacts
+				ADD COLUMN IF NOT EXISTS engagement_score double precision,
+				ADD COLUMN IF NOT EXISTS engagement_updated timestamptz,
+				ADD COLUMN IF NOT EXISTS last_contacted timestamptz,
+				ADD COLUMN IF NOT EXISTS last_engaged timestamptz`,
+		)
+	}, func(db pgMigrations.DB) error {
+		return execAll(db,
+			`ALTER TABLE contacts
+				DROP COLUMN IF EXISTS engagement_score,
+				DROP COLUMN IF EXISTS engagement_updated,
+				DROP COLUMN IF EXISTS last_contacted,
+				DROP COLUMN IF EXISTS last_engaged`,
+		)
+	})
+}
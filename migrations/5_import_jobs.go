@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Background import jobs, and the import status of files. The flag files
+// had before is kept until 18_file_status has moved it over.
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.ImportJob{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db,
+			"ALTER TABLE files ADD COLUMN IF NOT EXISTS status text",
+			"CREATE INDEX IF NOT EXISTS import_jobs_status_idx ON import_jobs (status)",
+		)
+	}, func(db pgMigrations.DB) error {
+		err := execAll(db, "ALTER TABLE files DROP COLUMN IF EXISTS status")
+		if err != nil {
+			return err
+		}
+
+		return dropTables(db, &models.ImportJob{})
+	})
+}
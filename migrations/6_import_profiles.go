@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Column mappings a team keeps for the files it imports often
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		return createTables(db, &models.ImportProfile{})
+	}, func(db pgMigrations.DB) error {
+		return dropTables(db, &models.ImportProfile{})
+	})
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+)
+
+// The contact a duplicate was merged into
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		return execAll(db, "ALTER TABLE contacts ADD COLUMN IF NOT EXISTS merged_into bigint DEFAULT 0")
+	}, func(db pgMigrations.DB) error {
+		return execAll(db, "ALTER TABLE contacts DROP COLUMN IF EXISTS merged_into")
+	})
+}
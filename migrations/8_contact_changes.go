@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Field by field history of contacts
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.ContactChange{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db, "CREATE INDEX IF NOT EXISTS contact_changes_contact_id_idx ON contact_changes (contact_id)")
+	}, func(db pgMigrations.DB) error {
+		return dropTables(db, &models.ContactChange{})
+	})
+}
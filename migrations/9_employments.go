@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	pgMigrations "github.com/go-pg/migrations"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Dated jobs of contacts at publications. Filled from the employers contacts
+// already have by 19_employment_backfill.
+func init() {
+	pgMigrations.Register(func(db pgMigrations.DB) error {
+		err := createTables(db, &models.Employment{})
+		if err != nil {
+			return err
+		}
+
+		return execAll(db, "CREATE INDEX IF NOT EXISTS employments_contact_id_idx ON employments (contact_id)")
+	}, func(db pgMigrations.DB) error {
+		return dropTables(db, &models.Employment{})
+	})
+}
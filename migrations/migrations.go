@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"log"
+	"net/http"
+
+	pgMigrations "github.com/go-pg/migrations"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	gcontext "github.com/gorilla/context"
+
+	"github.com/news-ai/api-v1/db"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+)
+
+/*
+* Private methods
+ */
+
+// Runs fn for each of the users as if they had made the request
+func forEachUser(userIds pg.Ints, fn func(r *http.Request) error) error {
+	for i := 0; i < len(userIds); i++ {
+		r, err := controllers.UserRequest("/migrations", userIds[i])
+		if err != nil {
+			return err
+		}
+
+		err = fn(r)
+		gcontext.Clear(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Makes a table for each of the models, with the columns go-pg maps the
+// model's fields to
+func createTables(db pgMigrations.DB, tables ...interface{}) error {
+	for i := 0; i < len(tables); i++ {
+		err := db.Model(tables[i]).CreateTable(&orm.CreateTableOptions{IfNotExists: true})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropTables(db pgMigrations.DB, tables ...interface{}) error {
+	for i := len(tables) - 1; i >= 0; i-- {
+		err := db.Model(tables[i]).DropTable(&orm.DropTableOptions{IfExists: true})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Runs each of the statements in order
+func execAll(db pgMigrations.DB, statements ...string) error {
+	for i := 0; i < len(statements); i++ {
+		_, err := db.Exec(statements[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+* Public methods
+ */
+
+// Brings the database up to the latest migration. Migrations are the
+// numbered files of this package, each runs once. They start from the schema
+// tabulae had before them: the tables and columns added since come first,
+// then the backfills that fill them. Called by the server when it starts,
+// before any routes are served.
+func Run() error {
+	_, _, err := pgMigrations.Run(db.DB, "init")
+	if err != nil {
+		return err
+	}
+
+	oldVersion, newVersion, err := pgMigrations.Run(db.DB, "up")
+	if err != nil {
+		return err
+	}
+
+	if newVersion != oldVersion {
+		log.Printf("Migrated from version %d to %d", oldVersion, newVersion)
+	}
+	return nil
+}
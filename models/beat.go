@@ -0,0 +1,261 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+// A beat or topic journalists and publications cover. Beats are nested, so
+// "Tech > AI > Policy" is the Policy beat under AI under Tech.
+type Beat struct {
+	apiModels.Base
+
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+
+	ParentBeat int64 `json:"parent" apiModel:"Beat"`
+
+	// Names of the beat and the ones above it, e.g. "Tech > AI > Policy"
+	Path string `json:"path" sql:"-"`
+}
+
+const beatPathSeparator = " > "
+
+/*
+* Private methods
+ */
+
+// Lowercase letters and digits joined by dashes: "AI & Policy" is "ai-policy"
+func beatSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	return strings.Join(words, "-")
+}
+
+// Beats are matched on their slug or their name
+func (b *Beat) matches(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.EqualFold(b.Name, value) || b.Slug == beatSlug(value)
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (b *Beat) Create(r *http.Request, currentUser apiModels.UserPostgres) (*Beat, error) {
+	b.CreatedBy = currentUser.Id
+	b.Created = time.Now()
+	_, err := db.DB.Model(b).Returning("*").Insert()
+	return b, err
+}
+
+/*
+* Update methods
+ */
+
+func (b *Beat) Save() (*Beat, error) {
+	b.Updated = time.Now()
+	_, err := db.DB.Model(b).Update()
+	return b, err
+}
+
+func (b *Beat) Validate() (*Beat, error) {
+	b.Name = strings.TrimSpace(b.Name)
+	if b.Name == "" {
+		return b, errors.New("Missing fields")
+	}
+
+	if b.Slug == "" {
+		b.Slug = b.Name
+	}
+	b.Slug = beatSlug(b.Slug)
+	if b.Slug == "" {
+		return b, errors.New("Beat needs a name with letters or digits")
+	}
+	return b, nil
+}
+
+/*
+* Taxonomy methods
+ */
+
+// The whole taxonomy, with paths filled in and ordered by them so beats
+// come right after their parent
+type BeatTaxonomy struct {
+	Beats []Beat
+
+	byId map[int64]int
+}
+
+func GetBeatTaxonomy() (BeatTaxonomy, error) {
+	beats := []Beat{}
+	err := db.DB.Model(&beats).Select()
+	if err != nil {
+		return BeatTaxonomy{}, err
+	}
+
+	taxonomy := BeatTaxonomy{Beats: beats, byId: map[int64]int{}}
+	for i := 0; i < len(beats); i++ {
+		taxonomy.byId[beats[i].Id] = i
+	}
+
+	for i := 0; i < len(beats); i++ {
+		names := []string{}
+		for _, ancestor := range taxonomy.Ancestors(beats[i].Id) {
+			names = append([]string{ancestor.Name}, names...)
+		}
+		beats[i].Path = strings.Join(append(names, beats[i].Name), beatPathSeparator)
+		beats[i].Type = "beats"
+	}
+
+	sort.SliceStable(beats, func(i, j int) bool {
+		return strings.ToLower(beats[i].Path) < strings.ToLower(beats[j].Path)
+	})
+	for i := 0; i < len(beats); i++ {
+		taxonomy.byId[beats[i].Id] = i
+	}
+
+	return taxonomy, nil
+}
+
+func (bt *BeatTaxonomy) Get(id int64) (Beat, bool) {
+	i, ok := bt.byId[id]
+	if !ok {
+		return Beat{}, false
+	}
+	return bt.Beats[i], true
+}
+
+// Beats above a beat, nearest first. Stops if the parents loop.
+func (bt *BeatTaxonomy) Ancestors(id int64) []Beat {
+	ancestors := []Beat{}
+	seen := map[int64]bool{id: true}
+
+	beat, ok := bt.Get(id)
+	for ok && beat.ParentBeat != 0 && !seen[beat.ParentBeat] {
+		seen[beat.ParentBeat] = true
+		beat, ok = bt.Get(beat.ParentBeat)
+		if ok {
+			ancestors = append(ancestors, beat)
+		}
+	}
+	return ancestors
+}
+
+// The beat along with every beat under it. Filtering on "Tech" includes
+// contacts on "Tech > AI".
+func (bt *BeatTaxonomy) WithDescendants(id int64) []int64 {
+	if _, ok := bt.Get(id); !ok {
+		return []int64{}
+	}
+
+	ids := []int64{id}
+	seen := map[int64]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, beat := range bt.Beats {
+			if beat.ParentBeat == ids[i] && !seen[beat.Id] {
+				seen[beat.Id] = true
+				ids = append(ids, beat.Id)
+			}
+		}
+	}
+	return ids
+}
+
+// Finds a beat by id, slug, name or path. Paths can be separated by ">" or
+// "/" and don't have to start at the top, so "ai/policy" finds
+// "Tech > AI > Policy" as long as only one beat matches.
+func (bt *BeatTaxonomy) Find(value string) (Beat, error) {
+	value = strings.TrimSpace(value)
+	if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if beat, ok := bt.Get(id); ok {
+			return beat, nil
+		}
+		return Beat{}, errors.New("No beat by this id")
+	}
+
+	segments := strings.FieldsFunc(value, func(c rune) bool { return c == '>' || c == '/' })
+	if len(segments) == 0 {
+		return Beat{}, errors.New("No beat by this name")
+	}
+
+	found := []Beat{}
+	for _, beat := range bt.Beats {
+		if !beat.matches(segments[len(segments)-1]) {
+			continue
+		}
+
+		ancestors := bt.Ancestors(beat.Id)
+		matched := len(ancestors) >= len(segments)-1
+		for i := 0; matched && i < len(segments)-1; i++ {
+			matched = ancestors[i].matches(segments[len(segments)-2-i])
+		}
+		if matched {
+			found = append(found, beat)
+		}
+	}
+
+	if len(found) == 0 {
+		return Beat{}, errors.New("No beat by this name")
+	}
+	if len(found) > 1 {
+		return Beat{}, errors.New("More than one beat is called " + value + ", use its path")
+	}
+	return found[0], nil
+}
+
+// Ids of the beats a value like "tech/ai" stands for
+func (bt *BeatTaxonomy) FindWithDescendants(value string) ([]int64, error) {
+	beat, err := bt.Find(value)
+	if err != nil {
+		return []int64{}, err
+	}
+	return bt.WithDescendants(beat.Id), nil
+}
+
+// Splits a query like "health care/insurance london" into the ids of the
+// beat it starts with, and the beats under it, and the text after it. Beat
+// names can have spaces in them, so the longest run of words that names a
+// beat is taken.
+func (bt *BeatTaxonomy) FindInQuery(query string) ([]int64, string, error) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return []int64{}, "", errors.New("No beat by this name")
+	}
+
+	var err error
+	for i := len(words); i > 0; i-- {
+		var beat Beat
+		beat, err = bt.Find(strings.Join(words[:i], " "))
+		if err == nil {
+			return bt.WithDescendants(beat.Id), strings.Join(words[i:], " "), nil
+		}
+	}
+	return []int64{}, "", err
+}
+
+// Whether a contact or publication with these beats covers any of beatIds
+func HasBeat(beats []int64, beatIds []int64) bool {
+	for i := 0; i < len(beats); i++ {
+		for x := 0; x < len(beatIds); x++ {
+			if beats[i] == beatIds[x] {
+				return true
+			}
+		}
+	}
+	return false
+}
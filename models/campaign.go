@@ -0,0 +1,79 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	CampaignStatusDraft     = "draft"
+	CampaignStatusScheduled = "scheduled"
+	CampaignStatusSent      = "sent"
+	CampaignStatusCancelled = "cancelled"
+)
+
+type Campaign struct {
+	apiModels.Base
+
+	Name string `json:"name"`
+
+	// Subject before it is templated for each contact
+	Subject string `json:"subject"`
+
+	TemplateId int64 `json:"templateid" apiModel:"Template"`
+	ListId     int64 `json:"listid" apiModel:"MediaList"`
+	TeamId     int64 `json:"teamid"`
+
+	SendAt time.Time `json:"sendat"`
+	Status string    `json:"status"`
+
+	Archived bool `json:"archived"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (cp *Campaign) Create(r *http.Request, currentUser apiModels.UserPostgres) (*Campaign, error) {
+	cp.CreatedBy = currentUser.Id
+	cp.Created = time.Now()
+
+	if cp.Status == "" {
+		cp.Status = CampaignStatusDraft
+	}
+
+	_, err := db.DB.Model(cp).Returning("*").Insert()
+	return cp, err
+}
+
+/*
+* Update methods
+ */
+
+func (cp *Campaign) Save() (*Campaign, error) {
+	cp.Updated = time.Now()
+	_, err := db.DB.Model(cp).Update()
+	return cp, err
+}
+
+// Marks the campaign as sent, or scheduled if it goes out in the future
+func (cp *Campaign) MarkSent(sendAt time.Time) (*Campaign, error) {
+	if cp.Status == CampaignStatusCancelled {
+		return cp, nil
+	}
+
+	cp.SendAt = sendAt
+	cp.Status = CampaignStatusSent
+	if !sendAt.IsZero() && sendAt.After(time.Now()) {
+		cp.Status = CampaignStatusScheduled
+	}
+
+	return cp.Save()
+}
@@ -0,0 +1,274 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	ContactChangeSourceManual     = "manual"
+	ContactChangeSourceSync       = "sync"
+	ContactChangeSourceEnrichment = "enrichment"
+	ContactChangeSourceEmail      = "email"
+	ContactChangeSourceMaster     = "master"
+	ContactChangeSourceMerge      = "merge"
+	ContactChangeSourceRevert     = "revert"
+)
+
+// A change to one field of a contact. Lists and custom fields are kept as
+// JSON so any change can be put back as it was.
+type ContactChange struct {
+	apiModels.Base
+
+	ContactId int64 `json:"contactid" apiModel:"Contact"`
+
+	Field    string `json:"field"`
+	OldValue string `json:"oldvalue"`
+	NewValue string `json:"newvalue"`
+
+	// Who made the change, 0 when nobody was logged in, and how it was made
+	ActorId int64  `json:"actorid" apiModel:"User"`
+	Source  string `json:"source"`
+
+	// The change this one reverted
+	RevertOf int64 `json:"revertof" apiModel:"ContactChange"`
+}
+
+// Fields that are tracked, in the order their changes are recorded
+var ContactChangeFields = []string{
+	"firstname", "lastname", "email", "notes", "linkedin", "twitter", "instagram",
+	"website", "blog", "location", "phonenumber", "imageurl", "employers",
+	"pastemployers", "tags", "beats", "customfields", "parent", "list",
+	"ismastercontact", "emailbounced", "emailverification", "twitterinvalid",
+	"instagraminvalid", "twitterprivate", "instagramprivate", "isoutdated",
+	"isdeleted",
+}
+
+/*
+* Private methods
+ */
+
+// Lists are encoded the same whether they are nil or empty
+func encodeChangeValue(value interface{}) string {
+	if reflect.ValueOf(value).Len() == 0 {
+		return "[]"
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (cc *ContactChange) Create() (*ContactChange, error) {
+	cc.Created = time.Now()
+	_, err := db.DB.Model(cc).Returning("*").Insert()
+	return cc, err
+}
+
+/*
+* Action methods
+ */
+
+// The value of a tracked field the way it is stored in a ContactChange
+func (ct *Contact) ChangeValue(field string) (string, error) {
+	switch field {
+	case "firstname":
+		return ct.FirstName, nil
+	case "lastname":
+		return ct.LastName, nil
+	case "email":
+		return ct.Email, nil
+	case "notes":
+		return ct.Notes, nil
+	case "linkedin":
+		return ct.LinkedIn, nil
+	case "twitter":
+		return ct.Twitter, nil
+	case "instagram":
+		return ct.Instagram, nil
+	case "website":
+		return ct.Website, nil
+	case "blog":
+		return ct.Blog, nil
+	case "location":
+		return ct.Location, nil
+	case "phonenumber":
+		return ct.PhoneNumber, nil
+	case "imageurl":
+		return ct.ImageURL, nil
+	case "employers":
+		return encodeChangeValue(ct.Employers), nil
+	case "pastemployers":
+		return encodeChangeValue(ct.PastEmployers), nil
+	case "tags":
+		return encodeChangeValue(ct.Tags), nil
+	case "beats":
+		return encodeChangeValue(ct.Beats), nil
+	case "customfields":
+		return encodeChangeValue(ct.CustomFields), nil
+	case "parent":
+		return strconv.FormatInt(ct.ParentContact, 10), nil
+	case "list":
+		return strconv.FormatInt(ct.ListId, 10), nil
+	case "ismastercontact":
+		return strconv.FormatBool(ct.IsMasterContact), nil
+	case "emailbounced":
+		return strconv.FormatBool(ct.EmailBounced), nil
+	case "emailverification":
+		return ct.EmailVerification, nil
+	case "twitterinvalid":
+		return strconv.FormatBool(ct.TwitterInvalid), nil
+	case "instagraminvalid":
+		return strconv.FormatBool(ct.InstagramInvalid), nil
+	case "twitterprivate":
+		return strconv.FormatBool(ct.TwitterPrivate), nil
+	case "instagramprivate":
+		return strconv.FormatBool(ct.InstagramPrivate), nil
+	case "isoutdated":
+		return strconv.FormatBool(ct.IsOutdated), nil
+	case "isdeleted":
+		return strconv.FormatBool(ct.IsDeleted), nil
+	}
+	return "", errors.New("Field is not tracked: " + field)
+}
+
+// Sets an editable field from a value stored in a ContactChange. Flags and
+// links to other contacts are left to the code that maintains them.
+func (ct *Contact) SetChangeValue(field string, value string) error {
+	switch field {
+	case "firstname":
+		ct.FirstName = value
+	case "lastname":
+		ct.LastName = value
+	case "email":
+		ct.Email = value
+	case "notes":
+		ct.Notes = value
+	case "linkedin":
+		ct.LinkedIn = value
+	case "twitter":
+		ct.Twitter = value
+	case "instagram":
+		ct.Instagram = value
+	case "website":
+		ct.Website = value
+	case "blog":
+		ct.Blog = value
+	case "location":
+		ct.Location = value
+	case "phonenumber":
+		ct.PhoneNumber = value
+	case "imageurl":
+		ct.ImageURL = value
+	case "employers":
+		return json.Unmarshal([]byte(value), &ct.Employers)
+	case "pastemployers":
+		return json.Unmarshal([]byte(value), &ct.PastEmployers)
+	case "tags":
+		return json.Unmarshal([]byte(value), &ct.Tags)
+	case "beats":
+		return json.Unmarshal([]byte(value), &ct.Beats)
+	case "customfields":
+		return json.Unmarshal([]byte(value), &ct.CustomFields)
+	default:
+		return errors.New("Field can not be reverted: " + field)
+	}
+	return nil
+}
+
+// The changes between two versions of a contact
+func DiffContacts(previous Contact, current Contact, actorId int64, source string) []ContactChange {
+	changes := []ContactChange{}
+	for _, field := range ContactChangeFields {
+		oldValue, _ := previous.ChangeValue(field)
+		newValue, _ := current.ChangeValue(field)
+		if oldValue == newValue {
+			continue
+		}
+
+		change := ContactChange{
+			ContactId: current.Id,
+			Field:     field,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			ActorId:   actorId,
+			Source:    source,
+		}
+		change.CreatedBy = actorId
+		change.Created = current.Updated
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Saves the contact along with a change for each field that is different
+// from what is stored
+func (ct *Contact) SaveWithHistory(r *http.Request, actorId int64, source string) ([]ContactChange, error) {
+	return ct.saveWithHistory(actorId, source, 0)
+}
+
+// Saves the contact after putting back a change. The changes it makes point
+// to the change they revert.
+func (ct *Contact) RevertWithHistory(r *http.Request, actorId int64, revertOf int64) ([]ContactChange, error) {
+	return ct.saveWithHistory(actorId, ContactChangeSourceRevert, revertOf)
+}
+
+// Same as SaveWithHistory, as part of a transaction that is already open
+func (ct *Contact) SaveWithHistoryTx(tx *pg.Tx, actorId int64, source string) ([]ContactChange, error) {
+	return ct.saveWithHistoryTx(tx, actorId, source, 0)
+}
+
+func (ct *Contact) saveWithHistory(actorId int64, source string, revertOf int64) ([]ContactChange, error) {
+	changes := []ContactChange{}
+	err := db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		var err error
+		changes, err = ct.saveWithHistoryTx(tx, actorId, source, revertOf)
+		return err
+	})
+	return changes, err
+}
+
+func (ct *Contact) saveWithHistoryTx(tx *pg.Tx, actorId int64, source string, revertOf int64) ([]ContactChange, error) {
+	ct.Updated = time.Now()
+	ct.Normalize()
+	ct.FormatName()
+
+	previous := Contact{}
+	err := tx.Model(&previous).Where("id = ?", ct.Id).For("UPDATE").Select()
+	if err != nil {
+		return []ContactChange{}, err
+	}
+
+	_, err = tx.Model(ct).Update()
+	if err != nil {
+		return []ContactChange{}, err
+	}
+
+	changes := DiffContacts(previous, *ct, actorId, source)
+	for i := 0; i < len(changes); i++ {
+		changes[i].RevertOf = revertOf
+	}
+	if len(changes) > 0 {
+		_, err = tx.Model(&changes).Returning("*").Insert()
+	}
+	return changes, err
+}
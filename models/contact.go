@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-pg/pg"
+
 	"github.com/news-ai/web/utilities"
 
 	"github.com/news-ai/api-v1/db"
@@ -62,23 +64,54 @@ type Contact struct {
 	IsOutdated   bool `json:"isoutdated"`
 	EmailBounced bool `json:"emailbounced"`
 
-	// Parent contact
-	IsMasterContact bool  `json:"ismastercontact"`
-	ParentContact   int64 `json:"parent" apiModel:"Contact"`
+	// Last check of the email address, with a status from the verify
+	// package. Blank when the address has not been checked.
+	EmailVerification       string    `json:"emailverification"`
+	EmailVerificationReason string    `json:"emailverificationreason"`
+	EmailVerified           time.Time `json:"emailverified"`
+
+	// Parent contact. Children show the master contact's fields, named in
+	// MasterFields, read only.
+	IsMasterContact bool     `json:"ismastercontact"`
+	ParentContact   int64    `json:"parent" apiModel:"Contact"`
+	MasterFields    []string `json:"masterfields" sql:"-"`
+
+	// Title of the current employment
+	CurrentTitle string `json:"currenttitle" sql:"-"`
 
 	IsDeleted bool `json:"isdeleted"`
 	ReadOnly  bool `json:"readonly" datastore:"-"`
 
+	// Contact this one was merged into as a duplicate
+	MergedInto int64 `json:"mergedinto" apiModel:"Contact"`
+
 	ImageURL string `json:"imageurl"`
 
 	Tags []string `json:"tags"`
 
+	// Beats the contact covers
+	Beats []int64 `json:"beats" apiModel:"Beat"`
+
 	TeamId   int64 `json:"teamid"`
 	ClientId int64 `json:"clientid"`
 
 	LinkedInUpdated time.Time `json:"linkedinupdated"`
+
+	// Engagement with the emails sent to this contact. The score decays over
+	// time, EngagementUpdated is when it was last brought up to date.
+	EngagementScore   float64   `json:"engagementscore"`
+	EngagementUpdated time.Time `json:"engagementupdated"`
+	LastContacted     time.Time `json:"lastcontacted"`
+	LastEngaged       time.Time `json:"lastengaged"`
 }
 
+// Fields a master contact holds for all of its children. The rest, like notes
+// and custom fields, belong to each list's contact.
+var MasterContactFields = []string{"firstname", "lastname", "email", "linkedin", "twitter", "instagram", "website", "blog", "location", "phonenumber", "imageurl", "employers", "pastemployers"}
+
+// Columns of MasterContactFields
+var masterContactColumns = []string{"first_name", "last_name", "email", "linked_in", "twitter", "instagram", "website", "blog", "location", "phone_number", "image_url", "employers", "past_employers"}
+
 /*
 * Public methods
  */
@@ -110,6 +143,70 @@ func (ct *Contact) Save(r *http.Request) (*Contact, error) {
 	return ct, err
 }
 
+// Saves the result of checking an email address on the contacts given,
+// along with the history of each. Only the verification is written, so
+// edits made to the contacts meanwhile are kept.
+func SaveEmailVerification(actorId int64, contactIds []int64, status string, reason string) error {
+	if len(contactIds) == 0 {
+		return nil
+	}
+
+	return db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		contacts := []Contact{}
+		err := tx.Model(&contacts).Column("id", "email_verification").Where("id IN (?)", pg.In(contactIds)).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+
+		verified := time.Now()
+		_, err = tx.Model(&Contact{}).Set("email_verification = ?", status).Set("email_verification_reason = ?", reason).Set("email_verified = ?", verified).Set("updated = ?", verified).Where("id IN (?)", pg.In(contactIds)).Update()
+		if err != nil {
+			return err
+		}
+
+		changes := []ContactChange{}
+		for i := 0; i < len(contacts); i++ {
+			if contacts[i].EmailVerification == status {
+				continue
+			}
+
+			change := ContactChange{
+				ContactId: contacts[i].Id,
+				Field:     "emailverification",
+				OldValue:  contacts[i].EmailVerification,
+				NewValue:  status,
+				ActorId:   actorId,
+				Source:    ContactChangeSourceEnrichment,
+			}
+			change.CreatedBy = actorId
+			change.Created = verified
+			changes = append(changes, change)
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+
+		_, err = tx.Model(&changes).Insert()
+		return err
+	})
+}
+
+/*
+* Get methods
+ */
+
+// Contacts of the user out of the ones given, leaving out deleted ones
+func GetContactsOfUser(userId int64, ids []int64) ([]Contact, error) {
+	contacts := []Contact{}
+	if len(ids) == 0 {
+		return contacts, nil
+	}
+
+	err := db.DB.Model(&contacts).Where("id IN (?)", pg.In(ids)).Where("created_by = ?", userId).Where("is_deleted = ?", false).Select()
+	return contacts, err
+}
+
 /*
 * Normalization methods
  */
@@ -156,6 +253,66 @@ func (ct *Contact) Normalize() (*Contact, error) {
 	return ct, nil
 }
 
+/*
+* Master contact methods
+ */
+
+func (ct *Contact) CopyMasterFields(master Contact) {
+	ct.FirstName = master.FirstName
+	ct.LastName = master.LastName
+	ct.Email = master.Email
+	ct.LinkedIn = master.LinkedIn
+	ct.Twitter = master.Twitter
+	ct.Instagram = master.Instagram
+	ct.Website = master.Website
+	ct.Blog = master.Blog
+	ct.Location = master.Location
+	ct.PhoneNumber = master.PhoneNumber
+	ct.ImageURL = master.ImageURL
+	ct.Employers = master.Employers
+	ct.PastEmployers = master.PastEmployers
+}
+
+// Copies the master's fields onto all of its children, recording what
+// changed on each of them
+func (ct *Contact) PropagateToChildren(actorId int64) error {
+	if !ct.IsMasterContact || ct.Id == 0 {
+		return nil
+	}
+
+	return db.DB.RunInTransaction(func(tx *pg.Tx) error {
+		children := []Contact{}
+		err := tx.Model(&children).Where("parent_contact = ?", ct.Id).Where("is_deleted = ?", false).For("UPDATE").Select()
+		if err != nil || len(children) == 0 {
+			return err
+		}
+
+		now := time.Now()
+		changes := []ContactChange{}
+		for i := 0; i < len(children); i++ {
+			previous := children[i]
+			children[i].CopyMasterFields(*ct)
+			children[i].Updated = now
+			changes = append(changes, DiffContacts(previous, children[i], actorId, ContactChangeSourceMaster)...)
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+
+		child := Contact{}
+		child.CopyMasterFields(*ct)
+		child.Updated = now
+		_, err = tx.Model(&child).Column(append(masterContactColumns, "updated")...).Where("parent_contact = ?", ct.Id).Where("is_deleted = ?", false).Update()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model(&changes).Insert()
+		return err
+	})
+}
+
 /*
 * Action methods
  */
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	EmailEventSent        = "sent"
+	EmailEventOpen        = "open"
+	EmailEventClick       = "click"
+	EmailEventBounce      = "bounce"
+	EmailEventDelivered   = "delivered"
+	EmailEventSpam        = "spam"
+	EmailEventDropped     = "dropped"
+	EmailEventUnsubscribe = "unsubscribe"
+)
+
+// A single tracking event for an email. The counters on Email only keep
+// totals, events keep when each one happened.
+type EmailEvent struct {
+	apiModels.Base
+
+	EmailId    int64 `json:"emailid" apiModel:"Email"`
+	CampaignId int64 `json:"campaignid" apiModel:"Campaign"`
+	ContactId  int64 `json:"contactid" apiModel:"Contact"`
+	ListId     int64 `json:"listid" apiModel:"MediaList"`
+
+	Event string `json:"event"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (ee *EmailEvent) Create() (*EmailEvent, error) {
+	ee.Created = time.Now()
+	_, err := db.DB.Model(ee).Returning("*").Insert()
+	return ee, err
+}
+
+func NewEmailEvent(email Email, event string) EmailEvent {
+	emailEvent := EmailEvent{}
+	emailEvent.CreatedBy = email.CreatedBy
+	emailEvent.EmailId = email.Id
+	emailEvent.CampaignId = email.CampaignId
+	emailEvent.ContactId = email.ContactId
+	emailEvent.ListId = email.ListId
+	emailEvent.Event = event
+	return emailEvent
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+// Checks the email addresses of the contacts of a list in the background.
+// Results are kept on the contacts, the job only tracks how far it got.
+type EmailVerificationJob struct {
+	apiModels.Base
+
+	ListId int64 `json:"listid" apiModel:"MediaList"`
+
+	// Check addresses that have been checked recently as well
+	Force bool `json:"force"`
+
+	// Same statuses as an import job
+	Status string `json:"status"`
+
+	// Addresses to check and the ones checked so far
+	Total   int `json:"total"`
+	Checked int `json:"checked"`
+
+	Error string `json:"error"`
+
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (evj *EmailVerificationJob) Create(r *http.Request, currentUser apiModels.UserPostgres) (*EmailVerificationJob, error) {
+	evj.CreatedBy = currentUser.Id
+	evj.Created = time.Now()
+
+	if evj.Status == "" {
+		evj.Status = ImportJobStatusQueued
+	}
+
+	_, err := db.DB.Model(evj).Returning("*").Insert()
+	return evj, err
+}
+
+/*
+* Update methods
+ */
+
+func (evj *EmailVerificationJob) Save() (*EmailVerificationJob, error) {
+	evj.Updated = time.Now()
+	_, err := db.DB.Model(evj).Update()
+	return evj, err
+}
+
+// Whether the job has stopped, either way
+func (evj *EmailVerificationJob) IsFinished() bool {
+	return evj.Status == ImportJobStatusCompleted || evj.Status == ImportJobStatusFailed
+}
@@ -71,6 +71,7 @@ type Email struct {
 	TemplateId int64 `json:"templateid" apiModel:"Template"`
 	ContactId  int64 `json:"contactId" apiModel:"Contact"`
 	ClientId   int64 `json:"clientid"`
+	CampaignId int64 `json:"campaignid" apiModel:"Campaign"`
 
 	FromEmail string `json:"fromemail"`
 
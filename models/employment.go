@@ -0,0 +1,104 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	EmploymentSourceManual     = "manual"
+	EmploymentSourceImport     = "import"
+	EmploymentSourceEnrichment = "enrichment"
+
+	// Made from Employers and PastEmployers of contacts from before
+	// employments were kept
+	EmploymentSourceLegacy = "legacy"
+)
+
+// A job a contact had at a publication. Contact.Employers and PastEmployers
+// follow the current and past employments.
+type Employment struct {
+	apiModels.Base
+
+	ContactId     int64 `json:"contactid" apiModel:"Contact"`
+	PublicationId int64 `json:"publicationid" apiModel:"Publication"`
+
+	Title string `json:"title"`
+	Beat  string `json:"beat"`
+
+	// Either can be unknown. An employment is closed when the job ends.
+	StartDate time.Time `json:"startdate"`
+	EndDate   time.Time `json:"enddate"`
+	Current   bool      `json:"current"`
+
+	Source string `json:"source"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (em *Employment) Create(r *http.Request, currentUser apiModels.UserPostgres) (*Employment, error) {
+	em.CreatedBy = currentUser.Id
+	em.Created = time.Now()
+	em.Updated = em.Created
+	if em.Source == "" {
+		em.Source = EmploymentSourceManual
+	}
+	_, err := db.DB.Model(em).Returning("*").Insert()
+	return em, err
+}
+
+/*
+* Update methods
+ */
+
+func (em *Employment) Save() (*Employment, error) {
+	em.Updated = time.Now()
+	_, err := db.DB.Model(em).Update()
+	return em, err
+}
+
+// Ends the job, by default as of now
+func (em *Employment) Close(endDate time.Time) {
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+	em.EndDate = endDate
+	em.Current = false
+}
+
+// Whether the job was held at the given time. Jobs that ended without an
+// end date are taken to have ended before it.
+func (em *Employment) HeldAt(at time.Time) bool {
+	if at.IsZero() {
+		return em.Current
+	}
+	if !em.StartDate.IsZero() && at.Before(em.StartDate) {
+		return false
+	}
+	return em.Current || (!em.EndDate.IsZero() && !at.After(em.EndDate))
+}
+
+/*
+* Get methods
+ */
+
+func GetEmploymentsAtPublications(publicationIds []int64) ([]Employment, error) {
+	employments := []Employment{}
+	if len(publicationIds) == 0 {
+		return employments, nil
+	}
+
+	err := db.DB.Model(&employments).Where("publication_id IN (?)", pg.In(publicationIds)).Select()
+	return employments, err
+}
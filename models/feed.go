@@ -8,6 +8,13 @@ import (
 	apiModels "github.com/news-ai/api-v1/models"
 )
 
+const (
+	FeedSourceManual = "manual"
+
+	// Found on the site of a contact or publication
+	FeedSourceDiscovered = "discovered"
+)
+
 type Feed struct {
 	apiModels.Base
 
@@ -19,6 +26,25 @@ type Feed struct {
 
 	ValidFeed bool `json:"validfeed"`
 	Running   bool `json:"running"`
+
+	Source string `json:"source"`
+
+	// Title the feed gives itself
+	Title string `json:"title"`
+
+	// Validators from the last fetch, sent back so unchanged feeds don't
+	// have to be downloaded again
+	ETag         string `json:"-"`
+	LastModified string `json:"-"`
+
+	LastFetched time.Time `json:"lastfetched"`
+	NextFetch   time.Time `json:"nextfetch"`
+
+	// Fetches in a row that failed, or that found nothing new. Both push
+	// the next fetch further out.
+	Failures  int    `json:"failures"`
+	Unchanged int    `json:"-"`
+	LastError string `json:"lasterror"`
 }
 
 /*
@@ -36,6 +62,9 @@ func (f *Feed) Create(r *http.Request, currentUser apiModels.UserPostgres) (*Fee
 	// Initially the feed is both running and valid
 	f.Running = true
 	f.ValidFeed = true
+	if f.Source == "" {
+		f.Source = FeedSourceManual
+	}
 	_, err := db.DB.Model(f).Returning("*").Insert()
 	return f, err
 }
@@ -50,8 +79,42 @@ func (f *Feed) Save() (*Feed, error) {
 	return f, err
 }
 
+// Saves what came of fetching the feed
+func (f *Feed) SaveFetch() (*Feed, error) {
+	_, err := db.DB.Model(f).Column("valid_feed", "running", "title", "e_tag", "last_modified", "last_fetched", "next_fetch", "failures", "unchanged", "last_error").Update()
+	return f, err
+}
+
 // Function to save a new user into App Engine
 func (f *Feed) Delete() (*Feed, error) {
 	err := db.DB.Delete(f)
 	return f, err
 }
+
+/*
+* Poll methods
+ */
+
+// Takes the running feed that has waited longest to be fetched, if one is
+// due. Its next fetch is pushed out by lease so no one else takes it while
+// it is being fetched.
+func ClaimDueFeed(lease time.Duration) (Feed, bool, error) {
+	now := time.Now()
+
+	feed := Feed{}
+	res, err := db.DB.Model(&feed).
+		Set("next_fetch = ?", now.Add(lease)).
+		Where("id = (SELECT id FROM feeds WHERE running = ? AND (next_fetch IS NULL OR next_fetch <= ?) ORDER BY next_fetch ASC NULLS FIRST, id LIMIT 1 FOR UPDATE SKIP LOCKED)", true, now).
+		Returning("*").
+		Update()
+	if err != nil {
+		return Feed{}, false, err
+	}
+
+	if res.RowsAffected() == 0 {
+		return Feed{}, false, nil
+	}
+
+	feed.Type = "feeds"
+	return feed, true, nil
+}
@@ -8,6 +8,15 @@ import (
 	apiModels "github.com/news-ai/api-v1/models"
 )
 
+const (
+	FileStatusUploaded  = "uploaded"
+	FileStatusQueued    = "queued"
+	FileStatusImporting = "importing"
+	FileStatusImported  = "imported"
+	FileStatusFailed    = "failed"
+	FileStatusCancelled = "cancelled"
+)
+
 type File struct {
 	apiModels.Base
 
@@ -21,14 +30,16 @@ type File struct {
 	HeaderNames []string `json:"headernames" datastore:",noindex"`
 	Order       []string `json:"order" datastore:",noindex"`
 
-	Imported   bool `json:"imported"`
-	FileExists bool `json:"fileexists"`
+	// Where the file is in being imported into its list
+	Status     string `json:"status"`
+	FileExists bool   `json:"fileexists"`
 }
 
 type FileOrder struct {
-	HeaderNames []string `json:"headernames"`
-	Order       []string `json:"order"`
-	Sheet       string   `json:"string"`
+	HeaderNames []string          `json:"headernames"`
+	Order       []string          `json:"order"`
+	Transforms  []ImportTransform `json:"transforms"`
+	Sheet       string            `json:"string"`
 }
 
 /*
@@ -42,6 +53,11 @@ type FileOrder struct {
 func (f *File) Create(r *http.Request, currentUser apiModels.UserPostgres) (*File, error) {
 	f.CreatedBy = currentUser.Id
 	f.Created = time.Now()
+
+	if f.Status == "" {
+		f.Status = FileStatusUploaded
+	}
+
 	_, err := db.DB.Model(f).Returning("*").Insert()
 	return f, err
 }
@@ -50,6 +66,15 @@ func (f *File) Create(r *http.Request, currentUser apiModels.UserPostgres) (*Fil
 * Update methods
  */
 
+// Whether the contacts in the file are in its list, or are being put there
+func (f *File) IsImported() bool {
+	switch f.Status {
+	case FileStatusQueued, FileStatusImporting, FileStatusImported:
+		return true
+	}
+	return false
+}
+
 // Function to save a new file into App Engine
 func (f *File) Save() (*File, error) {
 	// Update the Updated time
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+// An entry of a feed, kept for the contact and publication of the feed
+type Headline struct {
+	apiModels.Base
+
+	FeedId        int64 `json:"feedid" apiModel:"Feed"`
+	ContactId     int64 `json:"contactid" apiModel:"Contact"`
+	PublicationId int64 `json:"publicationid" apiModel:"Publication"`
+
+	// Id of the entry in the feed
+	GUID string `json:"guid"`
+
+	Title   string `json:"title"`
+	Url     string `json:"url"`
+	Summary string `json:"summary"`
+	Author  string `json:"author"`
+
+	Published time.Time `json:"published"`
+}
+
+// A headline of a publication feed put down to a contact whose name is in
+// its byline
+type HeadlineAttribution struct {
+	apiModels.Base
+
+	HeadlineId    int64 `json:"headlineid" apiModel:"Headline"`
+	ContactId     int64 `json:"contactid" apiModel:"Contact"`
+	PublicationId int64 `json:"publicationid" apiModel:"Publication"`
+
+	// Name or address in the byline that matched the contact
+	Byline string `json:"byline"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+// Adds the headlines of a feed that aren't kept yet, going by their GUID.
+// Returns the ones that were added.
+func SaveHeadlines(feed Feed, headlines []Headline) ([]Headline, error) {
+	if len(headlines) == 0 {
+		return []Headline{}, nil
+	}
+
+	guids := []string{}
+	for i := 0; i < len(headlines); i++ {
+		guids = append(guids, headlines[i].GUID)
+	}
+
+	existing := []Headline{}
+	err := db.DB.Model(&existing).Column("guid").Where("feed_id = ?", feed.Id).Where("guid IN (?)", pg.In(guids)).Select()
+	if err != nil {
+		return []Headline{}, err
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < len(existing); i++ {
+		seen[existing[i].GUID] = true
+	}
+
+	now := time.Now()
+	newHeadlines := []Headline{}
+	for i := 0; i < len(headlines); i++ {
+		if seen[headlines[i].GUID] {
+			continue
+		}
+		seen[headlines[i].GUID] = true
+
+		headline := headlines[i]
+		headline.FeedId = feed.Id
+		headline.ContactId = feed.ContactId
+		headline.PublicationId = feed.PublicationId
+		headline.CreatedBy = feed.CreatedBy
+		headline.Created = now
+		headline.Updated = now
+		newHeadlines = append(newHeadlines, headline)
+	}
+
+	if len(newHeadlines) == 0 {
+		return newHeadlines, nil
+	}
+
+	_, err = db.DB.Model(&newHeadlines).Returning("*").Insert()
+	return newHeadlines, err
+}
+
+// Adds the attributions that aren't kept yet, going by their headline and
+// contact
+func SaveHeadlineAttributions(attributions []HeadlineAttribution) ([]HeadlineAttribution, error) {
+	if len(attributions) == 0 {
+		return []HeadlineAttribution{}, nil
+	}
+
+	headlineIds := []int64{}
+	for i := 0; i < len(attributions); i++ {
+		headlineIds = append(headlineIds, attributions[i].HeadlineId)
+	}
+
+	existing := []HeadlineAttribution{}
+	err := db.DB.Model(&existing).Column("headline_id", "contact_id").Where("headline_id IN (?)", pg.In(headlineIds)).Select()
+	if err != nil {
+		return []HeadlineAttribution{}, err
+	}
+
+	seen := map[[2]int64]bool{}
+	for i := 0; i < len(existing); i++ {
+		seen[[2]int64{existing[i].HeadlineId, existing[i].ContactId}] = true
+	}
+
+	now := time.Now()
+	newAttributions := []HeadlineAttribution{}
+	for i := 0; i < len(attributions); i++ {
+		key := [2]int64{attributions[i].HeadlineId, attributions[i].ContactId}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		attribution := attributions[i]
+		attribution.Created = now
+		attribution.Updated = now
+		newAttributions = append(newAttributions, attribution)
+	}
+
+	if len(newAttributions) == 0 {
+		return newAttributions, nil
+	}
+
+	_, err = db.DB.Model(&newAttributions).Returning("*").Insert()
+	return newAttributions, err
+}
+
+/*
+* Get methods
+ */
+
+// Headlines from the contact's own feeds or with the contact in the
+// byline, newest first
+func GetHeadlinesForContact(contactId int64, offset int, limit int) ([]Headline, int, error) {
+	headlines := []Headline{}
+	total, err := db.DB.Model(&headlines).Where("contact_id = ? OR id IN (SELECT headline_id FROM headline_attributions WHERE contact_id = ?)", contactId, contactId).Order("published DESC", "id DESC").Offset(offset).Limit(limit).SelectAndCount()
+	if err != nil {
+		return []Headline{}, 0, err
+	}
+
+	for i := 0; i < len(headlines); i++ {
+		headlines[i].Type = "headlines"
+	}
+	return headlines, total, nil
+}
+
+// A headline of a contact, from its own feeds or its byline
+type contactHeadline struct {
+	ContactId  int64
+	HeadlineId int64
+}
+
+// The newest headline of each of the contacts that has one, found in two
+// queries however many contacts there are
+func GetLatestHeadlines(contactIds []int64) (map[int64]Headline, error) {
+	latest := map[int64]Headline{}
+	if len(contactIds) == 0 {
+		return latest, nil
+	}
+
+	var contactHeadlines []contactHeadline
+	_, err := db.DB.Query(&contactHeadlines, `SELECT DISTINCT ON (matches.contact_id) matches.contact_id, matches.headline_id
+		FROM (SELECT contact_id, id AS headline_id FROM headlines WHERE contact_id IN (?0)
+			UNION SELECT contact_id, headline_id FROM headline_attributions WHERE contact_id IN (?0)) AS matches
+		JOIN headlines ON headlines.id = matches.headline_id
+		ORDER BY matches.contact_id, headlines.published DESC, headlines.id DESC`, pg.In(contactIds))
+	if err != nil {
+		return map[int64]Headline{}, err
+	}
+	if len(contactHeadlines) == 0 {
+		return latest, nil
+	}
+
+	headlineIds := []int64{}
+	for i := 0; i < len(contactHeadlines); i++ {
+		headlineIds = append(headlineIds, contactHeadlines[i].HeadlineId)
+	}
+
+	headlines := []Headline{}
+	err = db.DB.Model(&headlines).Where("id IN (?)", pg.In(headlineIds)).Select()
+	if err != nil {
+		return map[int64]Headline{}, err
+	}
+
+	headlineIdToHeadline := map[int64]Headline{}
+	for i := 0; i < len(headlines); i++ {
+		headlines[i].Type = "headlines"
+		headlineIdToHeadline[headlines[i].Id] = headlines[i]
+	}
+
+	for i := 0; i < len(contactHeadlines); i++ {
+		if headline, ok := headlineIdToHeadline[contactHeadlines[i].HeadlineId]; ok {
+			latest[contactHeadlines[i].ContactId] = headline
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	ImportJobStatusQueued    = "queued"
+	ImportJobStatusRunning   = "running"
+	ImportJobStatusCompleted = "completed"
+	ImportJobStatusFailed    = "failed"
+	ImportJobStatusCancelled = "cancelled"
+)
+
+// A problem with a single row of an import job
+type ImportJobError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+	Skipped bool   `json:"skipped"`
+}
+
+// Imports a File into its media list in the background. The job is saved
+// after every chunk of rows so it can carry on from Checkpoint after a crash.
+type ImportJob struct {
+	apiModels.Base
+
+	FileId int64 `json:"fileid" apiModel:"File"`
+	ListId int64 `json:"listid" apiModel:"MediaList"`
+
+	HeaderNames []string          `json:"headernames"`
+	Order       []string          `json:"order"`
+	Transforms  []ImportTransform `json:"transforms"`
+
+	Status string `json:"status"`
+
+	// Progress. RowsDone includes the rows that failed.
+	TotalRows  int `json:"totalrows"`
+	RowsDone   int `json:"rowsdone"`
+	RowsFailed int `json:"rowsfailed"`
+
+	// Last row that has been imported
+	Checkpoint int `json:"checkpoint"`
+
+	Errors []ImportJobError `json:"errors"`
+	Error  string           `json:"error"`
+
+	// Set by the worker running the job. Running jobs that haven't had a
+	// heartbeat in a while are picked up again by another worker, only the
+	// worker that holds a job may write its progress.
+	WorkerId  string    `json:"workerid"`
+	Heartbeat time.Time `json:"heartbeat"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (ij *ImportJob) Create(r *http.Request, currentUser apiModels.UserPostgres) (*ImportJob, error) {
+	ij.CreatedBy = currentUser.Id
+	ij.Created = time.Now()
+
+	if ij.Status == "" {
+		ij.Status = ImportJobStatusQueued
+	}
+
+	_, err := db.DB.Model(ij).Returning("*").Insert()
+	return ij, err
+}
+
+/*
+* Update methods
+ */
+
+func (ij *ImportJob) Save() (*ImportJob, error) {
+	ij.Updated = time.Now()
+	_, err := db.DB.Model(ij).Update()
+	return ij, err
+}
+
+// Whether the job is finished and won't be picked up by a worker again
+func (ij *ImportJob) IsFinished() bool {
+	switch ij.Status {
+	case ImportJobStatusCompleted, ImportJobStatusFailed, ImportJobStatusCancelled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	// Splits a full name column into firstname and lastname
+	ImportTransformSplitName = "splitname"
+
+	// Strips a leading "@" from handles
+	ImportTransformStripAt = "stripat"
+
+	// Adds the values of the column to the tags of the contact. Several
+	// columns can be mapped into tags.
+	ImportTransformTags = "tags"
+)
+
+// Transform applied to a column while importing it. Column is the name of
+// the header so profiles still apply when columns are moved around.
+type ImportTransform struct {
+	Column    string `json:"column"`
+	Transform string `json:"transform"`
+}
+
+// A header mapping a team has saved for a file format they import often.
+// Profiles are matched to uploads by the fingerprint of their headers.
+type ImportProfile struct {
+	apiModels.Base
+
+	Name   string `json:"name"`
+	TeamId int64  `json:"teamid"`
+
+	HeaderNames []string          `json:"headernames"`
+	Order       []string          `json:"order"`
+	Transforms  []ImportTransform `json:"transforms"`
+
+	Fingerprint string `json:"fingerprint"`
+}
+
+var nonAlphanumericHeader = regexp.MustCompile(`[^a-z0-9]+`)
+
+/*
+* Public methods
+ */
+
+// Header names are compared without case, spaces or punctuation
+func NormalizeHeaderName(name string) string {
+	return nonAlphanumericHeader.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// Identifies a file format by its headers, whatever order they are in
+func HeaderFingerprint(headerNames []string) string {
+	normalized := []string{}
+	for i := 0; i < len(headerNames); i++ {
+		if name := NormalizeHeaderName(headerNames[i]); name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+	sort.Strings(normalized)
+
+	hash := sha1.Sum([]byte(strings.Join(normalized, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+/*
+* Create methods
+ */
+
+func (ip *ImportProfile) Create(r *http.Request, currentUser apiModels.UserPostgres) (*ImportProfile, error) {
+	ip.CreatedBy = currentUser.Id
+	ip.Created = time.Now()
+	ip.Fingerprint = HeaderFingerprint(ip.HeaderNames)
+	_, err := db.DB.Model(ip).Returning("*").Insert()
+	return ip, err
+}
+
+/*
+* Update methods
+ */
+
+func (ip *ImportProfile) Save() (*ImportProfile, error) {
+	ip.Updated = time.Now()
+	ip.Fingerprint = HeaderFingerprint(ip.HeaderNames)
+	_, err := db.DB.Model(ip).Update()
+	return ip, err
+}
+
+/*
+* Delete methods
+ */
+
+func (ip *ImportProfile) Delete() (*ImportProfile, error) {
+	err := db.DB.Delete(ip)
+	return ip, err
+}
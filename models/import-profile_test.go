@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNormalizeHeaderName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"First Name", "firstname"},
+		{"E-Mail Address", "emailaddress"},
+		{"  twitter_handle ", "twitterhandle"},
+		{"Phone #2", "phone2"},
+		{"Zürich Office", "zrichoffice"},
+		{"---", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeHeaderName(test.name); got != test.want {
+			t.Errorf("NormalizeHeaderName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHeaderFingerprint(t *testing.T) {
+	fingerprint := HeaderFingerprint([]string{"First Name", "Email", "Outlet"})
+
+	same := [][]string{
+		{"Outlet", "First Name", "Email"},
+		{"first_name", "EMAIL", "outlet"},
+		{"First Name", "", "Email", "---", "Outlet"},
+	}
+	for _, headerNames := range same {
+		if got := HeaderFingerprint(headerNames); got != fingerprint {
+			t.Errorf("HeaderFingerprint(%q) = %q, want %q", headerNames, got, fingerprint)
+		}
+	}
+
+	different := [][]string{
+		{"First Name", "Email"},
+		{"First Name", "Email", "Outlet", "Notes"},
+		{"First Name", "Email Address", "Outlet"},
+		{},
+	}
+	for _, headerNames := range different {
+		if got := HeaderFingerprint(headerNames); got == fingerprint {
+			t.Errorf("HeaderFingerprint(%q) matches the fingerprint of other headers", headerNames)
+		}
+	}
+
+	// Headers aren't joined into one, "a b" and "ab" are told apart
+	if HeaderFingerprint([]string{"a", "b"}) == HeaderFingerprint([]string{"ab"}) {
+		t.Error("HeaderFingerprint does not tell separate headers from joined ones")
+	}
+}
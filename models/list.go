@@ -47,6 +47,12 @@ type MediaList struct {
 	Subscribed bool `json:"subscribed"`
 
 	IsDeleted bool `json:"isdeleted"`
+
+	// Smart lists have their contacts computed from a saved search
+	SmartList       bool      `json:"smartlist"`
+	SavedSearchId   int64     `json:"savedsearchid" apiModel:"SavedSearch"`
+	RefreshInterval int       `json:"refreshinterval"` // In hours. 0 means only on demand.
+	LastRefreshed   time.Time `json:"lastrefreshed"`
 }
 
 /*
@@ -77,7 +83,7 @@ var fieldsMapValueToDescription = map[string]string{
 * Create methods
  */
 
-func (ml *MediaList) Create(r *http.Request, currentUser apiModels.User) (*MediaList, error) {
+func (ml *MediaList) Create(r *http.Request, currentUser apiModels.UserPostgres) (*MediaList, error) {
 	ml.CreatedBy = currentUser.Id
 	ml.Created = time.Now()
 	_, err := db.DB.Model(ml).Returning("*").Insert()
@@ -228,6 +234,29 @@ func (ml *MediaList) Save() (*MediaList, error) {
 	return ml, err
 }
 
+// Takes the smart list that has gone longest past its refresh interval, so
+// servers running side by side don't refresh the same list. Returns false
+// when none is due.
+func ClaimDueSmartMediaList() (MediaList, bool, error) {
+	now := time.Now()
+
+	mediaList := MediaList{}
+	res, err := db.DB.Model(&mediaList).
+		Set("last_refreshed = ?", now).
+		Where("id = (SELECT id FROM media_lists WHERE smart_list = ? AND refresh_interval > 0 AND archived = ? AND is_deleted = ? AND (last_refreshed IS NULL OR last_refreshed <= ?::timestamptz - refresh_interval * interval '1 hour') ORDER BY last_refreshed ASC NULLS FIRST, id LIMIT 1 FOR UPDATE SKIP LOCKED)", true, false, false, now).
+		Returning("*").
+		Update()
+	if err != nil {
+		return MediaList{}, false, err
+	}
+
+	if res.RowsAffected() == 0 {
+		return MediaList{}, false, nil
+	}
+
+	return mediaList, true, nil
+}
+
 func (ml *MediaList) Format(modelType string) {
 	ml.Type = modelType
 
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+const (
+	PublicationDuplicateStatusPending   = "pending"
+	PublicationDuplicateStatusMerged    = "merged"
+	PublicationDuplicateStatusDismissed = "dismissed"
+)
+
+// Two publications that look like the same one, waiting for an admin to
+// merge or dismiss them. PublicationId is the one that would be kept.
+type PublicationDuplicate struct {
+	apiModels.Base
+
+	PublicationId int64 `json:"publicationid" apiModel:"Publication"`
+	DuplicateId   int64 `json:"duplicateid" apiModel:"Publication"`
+
+	Reasons    []string `json:"reasons"`
+	Confidence float64  `json:"confidence"`
+
+	Status     string    `json:"status"`
+	ReviewedBy int64     `json:"reviewedby" apiModel:"User"`
+	Reviewed   time.Time `json:"reviewed"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Update methods
+ */
+
+func (pd *PublicationDuplicate) Save() (*PublicationDuplicate, error) {
+	pd.Updated = time.Now()
+	_, err := db.DB.Model(pd).Update()
+	return pd, err
+}
+
+// Marks the pair as merged or dismissed by an admin
+func (pd *PublicationDuplicate) Review(status string, reviewerId int64) (*PublicationDuplicate, error) {
+	pd.Status = status
+	pd.ReviewedBy = reviewerId
+	pd.Reviewed = time.Now()
+	return pd.Save()
+}
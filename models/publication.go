@@ -4,7 +4,11 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/go-pg/pg"
 
 	"github.com/news-ai/api-v1/db"
 	apiModels "github.com/news-ai/api-v1/models"
@@ -12,6 +16,54 @@ import (
 	"github.com/news-ai/web/utilities"
 )
 
+const (
+	PublicationTypeOutlet = "outlet"
+
+	// Part of an outlet, like "NYT Tech" or "BBC Radio 4"
+	PublicationTypeSection = "section"
+
+	// Regional or national edition of an outlet, like "The Guardian US"
+	PublicationTypeEdition = "edition"
+)
+
+// How the publication reaches its audience. A publication can have more
+// than one.
+const (
+	PublicationMediaTypePrint     = "print"
+	PublicationMediaTypeOnline    = "online"
+	PublicationMediaTypeBroadcast = "broadcast"
+	PublicationMediaTypePodcast   = "podcast"
+)
+
+// How often the publication comes out. Continuous is for sites that publish
+// throughout the day.
+const (
+	PublicationFrequencyContinuous = "continuous"
+	PublicationFrequencyDaily      = "daily"
+	PublicationFrequencyWeekly     = "weekly"
+	PublicationFrequencyBiweekly   = "biweekly"
+	PublicationFrequencyMonthly    = "monthly"
+	PublicationFrequencyQuarterly  = "quarterly"
+	PublicationFrequencyAnnual     = "annual"
+)
+
+var publicationMediaTypes = map[string]bool{
+	PublicationMediaTypePrint:     true,
+	PublicationMediaTypeOnline:    true,
+	PublicationMediaTypeBroadcast: true,
+	PublicationMediaTypePodcast:   true,
+}
+
+var publicationFrequencies = map[string]bool{
+	PublicationFrequencyContinuous: true,
+	PublicationFrequencyDaily:      true,
+	PublicationFrequencyWeekly:     true,
+	PublicationFrequencyBiweekly:   true,
+	PublicationFrequencyMonthly:    true,
+	PublicationFrequencyQuarterly:  true,
+	PublicationFrequencyAnnual:     true,
+}
+
 type Publication struct {
 	apiModels.Base
 
@@ -27,6 +79,45 @@ type Publication struct {
 	Blog      string `json:"blog"`
 
 	Verified bool `json:"verified"`
+
+	// Beats the publication covers
+	Beats []int64 `json:"beats" apiModel:"Beat"`
+
+	// Publication this one was merged into as a duplicate
+	MergedInto int64 `json:"mergedinto" apiModel:"Publication"`
+
+	// Sections and editions belong to a parent brand
+	PublicationType   string `json:"publicationtype"`
+	ParentPublication int64  `json:"parent" apiModel:"Publication"`
+
+	// Metadata to qualify an outlet. Language is an ISO 639-1 code like
+	// "en" and Country an ISO 3166 code like "US". AudienceSize is the
+	// circulation, listeners or monthly visitors, whichever fits.
+	MediaTypes   []string `json:"mediatypes"`
+	Language     string   `json:"language"`
+	Country      string   `json:"country"`
+	Region       string   `json:"region"`
+	Frequency    string   `json:"frequency"`
+	AudienceSize int64    `json:"audiencesize"`
+
+	// Filled in when the publication is returned as a tree
+	Children []Publication `json:"children,omitempty" sql:"-"`
+}
+
+/*
+* Private methods
+ */
+
+func isLetterCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c > unicode.MaxASCII || !unicode.IsLetter(c) {
+			return false
+		}
+	}
+	return true
 }
 
 /*
@@ -72,6 +163,66 @@ func (p *Publication) Validate() (*Publication, error) {
 		}
 		p.Url = normalizedUrl
 	}
+
+	switch p.PublicationType {
+	case "":
+		p.PublicationType = PublicationTypeOutlet
+	case PublicationTypeOutlet, PublicationTypeSection, PublicationTypeEdition:
+	default:
+		return p, errors.New("Publication type has to be outlet, section or edition")
+	}
+
+	if p.PublicationType != PublicationTypeOutlet && p.ParentPublication == 0 {
+		return p, errors.New("Sections and editions need a parent publication")
+	}
+	if p.ParentPublication != 0 && p.ParentPublication == p.Id {
+		return p, errors.New("Publication can not be its own parent")
+	}
+	return p.ValidateMetadata()
+}
+
+// Lowercases media types, language and frequency, uppercases the country and
+// makes sure each is one we know
+func (p *Publication) ValidateMetadata() (*Publication, error) {
+	mediaTypes := []string{}
+	for _, mediaType := range p.MediaTypes {
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if !publicationMediaTypes[mediaType] {
+			return p, errors.New("Media type has to be print, online, broadcast or podcast")
+		}
+
+		exists := false
+		for i := 0; i < len(mediaTypes); i++ {
+			exists = exists || mediaTypes[i] == mediaType
+		}
+		if !exists {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if p.MediaTypes != nil {
+		p.MediaTypes = mediaTypes
+	}
+
+	p.Language = strings.ToLower(strings.TrimSpace(p.Language))
+	if p.Language != "" && !isLetterCode(p.Language) {
+		return p, errors.New("Language has to be a two letter code like en")
+	}
+
+	p.Country = strings.ToUpper(strings.TrimSpace(p.Country))
+	if p.Country != "" && !isLetterCode(p.Country) {
+		return p, errors.New("Country has to be a two letter code like US")
+	}
+
+	p.Region = strings.TrimSpace(p.Region)
+
+	p.Frequency = strings.ToLower(strings.TrimSpace(p.Frequency))
+	if p.Frequency != "" && !publicationFrequencies[p.Frequency] {
+		return p, errors.New("Frequency has to be continuous, daily, weekly, biweekly, monthly, quarterly or annual")
+	}
+
+	if p.AudienceSize < 0 {
+		return p, errors.New("Audience size can not be negative")
+	}
 	return p, nil
 }
 
@@ -84,3 +235,32 @@ func (p *Publication) FillStruct(m map[string]interface{}) error {
 	}
 	return nil
 }
+
+/*
+* Hierarchy methods
+ */
+
+// The publication along with its sections and editions, and theirs. Filtering
+// on "The New York Times" includes journalists at "NYT Tech".
+func PublicationWithDescendants(id int64) ([]int64, error) {
+	ids := []int64{id}
+	seen := map[int64]bool{id: true}
+	parentIds := []int64{id}
+	for len(parentIds) > 0 {
+		children := []Publication{}
+		err := db.DB.Model(&children).Column("id").Where("parent_publication IN (?)", pg.In(parentIds)).Select()
+		if err != nil {
+			return []int64{}, err
+		}
+
+		parentIds = []int64{}
+		for i := 0; i < len(children); i++ {
+			if !seen[children[i].Id] {
+				seen[children[i].Id] = true
+				ids = append(ids, children[i].Id)
+				parentIds = append(parentIds, children[i].Id)
+			}
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/news-ai/api-v1/db"
+	apiModels "github.com/news-ai/api-v1/models"
+)
+
+type SavedSearch struct {
+	apiModels.Base
+
+	Name string `json:"name"`
+
+	// Same syntax as the contacts "q" parameter (e.g. "tag:tech")
+	Query string `json:"query"`
+
+	// Set when the saved search is shared with the rest of the team
+	TeamId int64 `json:"teamid"`
+
+	Archived bool `json:"archived"`
+}
+
+/*
+* Public methods
+ */
+
+/*
+* Create methods
+ */
+
+func (ss *SavedSearch) Create(r *http.Request, currentUser apiModels.UserPostgres) (*SavedSearch, error) {
+	ss.CreatedBy = currentUser.Id
+	ss.Created = time.Now()
+	_, err := db.DB.Model(ss).Returning("*").Insert()
+	return ss, err
+}
+
+/*
+* Update methods
+ */
+
+func (ss *SavedSearch) Save() (*SavedSearch, error) {
+	ss.Updated = time.Now()
+	_, err := db.DB.Model(ss).Update()
+	return ss, err
+}
+
+func (ss *SavedSearch) Delete() (*SavedSearch, error) {
+	err := db.DB.Delete(ss)
+	return ss, err
+}
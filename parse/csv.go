@@ -0,0 +1,264 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Reads a CSV or TSV file one row at a time. The encoding, byte order mark
+// and delimiter are detected when the reader is created.
+type CSVReader struct {
+	Encoding  string
+	Delimiter rune
+	Header    []string
+
+	reader *csv.Reader
+	row    int
+}
+
+// Delimiters we look for. Earlier ones win ties.
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+var (
+	// How much of the decoded file is looked at to pick a delimiter
+	csvSampleSize  = 64 * 1024
+	csvSampleLines = 20
+
+	// Values returned for each column when reading headers
+	csvHeaderSampleRows = 15
+)
+
+/*
+* Private methods
+ */
+
+// Looks for a byte order mark, then for the NUL bytes UTF-16 leaves on ASCII
+// text, then falls back to Windows-1252 if the file isn't valid UTF-8.
+func decodeTextFile(file []byte) (io.Reader, string) {
+	switch {
+	case bytes.HasPrefix(file, []byte{0xEF, 0xBB, 0xBF}):
+		return bytes.NewReader(file[3:]), "utf-8"
+	case bytes.HasPrefix(file, []byte{0xFF, 0xFE}):
+		return transform.NewReader(bytes.NewReader(file), unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()), "utf-16le"
+	case bytes.HasPrefix(file, []byte{0xFE, 0xFF}):
+		return transform.NewReader(bytes.NewReader(file), unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()), "utf-16be"
+	}
+
+	sample := file
+	if len(sample) > 1024 {
+		sample = sample[:1024]
+	}
+
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i < len(sample); i++ {
+		if sample[i] == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+
+	half := len(sample) / 2
+	if half > 0 && oddZeros > half/3 && oddZeros > evenZeros*4 {
+		return transform.NewReader(bytes.NewReader(file), unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()), "utf-16le"
+	}
+	if half > 0 && evenZeros > half/3 && evenZeros > oddZeros*4 {
+		return transform.NewReader(bytes.NewReader(file), unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()), "utf-16be"
+	}
+
+	if utf8.Valid(file) {
+		return bytes.NewReader(file), "utf-8"
+	}
+
+	return transform.NewReader(bytes.NewReader(file), charmap.Windows1252.NewDecoder()), "windows-1252"
+}
+
+// Counts delimiters outside of quotes on each of the first lines of the file
+// and picks the one that shows up the same number of times on the most lines.
+func detectCSVDelimiter(sample string) rune {
+	counts := make([][]int, len(csvDelimiters))
+
+	inQuotes := false
+	lineCounts := make([]int, len(csvDelimiters))
+	lines := 0
+	for _, c := range sample {
+		if lines >= csvSampleLines {
+			break
+		}
+
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == '\n' && !inQuotes:
+			for i := 0; i < len(csvDelimiters); i++ {
+				counts[i] = append(counts[i], lineCounts[i])
+				lineCounts[i] = 0
+			}
+			lines++
+		case !inQuotes:
+			for i := 0; i < len(csvDelimiters); i++ {
+				if c == csvDelimiters[i] {
+					lineCounts[i]++
+				}
+			}
+		}
+	}
+
+	// The sample might end in the middle of a line, only count it if it is
+	// the whole file
+	if lines == 0 {
+		for i := 0; i < len(csvDelimiters); i++ {
+			counts[i] = append(counts[i], lineCounts[i])
+		}
+	}
+
+	bestDelimiter := csvDelimiters[0]
+	bestConsistent, bestHeaderCount := 0, 0
+	for i := 0; i < len(csvDelimiters); i++ {
+		if len(counts[i]) == 0 || counts[i][0] == 0 {
+			continue
+		}
+
+		consistent := 0
+		for x := 0; x < len(counts[i]); x++ {
+			if counts[i][x] == counts[i][0] {
+				consistent++
+			}
+		}
+
+		if consistent > bestConsistent || (consistent == bestConsistent && counts[i][0] > bestHeaderCount) {
+			bestDelimiter = csvDelimiters[i]
+			bestConsistent = consistent
+			bestHeaderCount = counts[i][0]
+		}
+	}
+
+	return bestDelimiter
+}
+
+/*
+* Public methods
+ */
+
+// Whether a file should go through the CSV importer rather than the Excel one
+func IsDelimitedFile(fileName string, contentType string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".tsv", ".tab", ".txt":
+		return true
+	case ".xls", ".xlsx":
+		return false
+	}
+
+	switch strings.ToLower(strings.Split(contentType, ";")[0]) {
+	case "text/csv", "application/csv", "text/comma-separated-values", "text/tab-separated-values", "text/plain":
+		return true
+	}
+	return false
+}
+
+func NewCSVReader(file []byte) (*CSVReader, error) {
+	decoded, encoding := decodeTextFile(file)
+
+	buffered := bufio.NewReaderSize(decoded, csvSampleSize)
+	sample, err := buffered.Peek(csvSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	csvReader := &CSVReader{
+		Encoding:  encoding,
+		Delimiter: detectCSVDelimiter(string(sample)),
+	}
+
+	csvReader.reader = csv.NewReader(buffered)
+	csvReader.reader.Comma = csvReader.Delimiter
+	csvReader.reader.LazyQuotes = true
+	csvReader.reader.FieldsPerRecord = -1
+
+	// The header is the first row that has anything in it
+	for {
+		header, err := csvReader.reader.Read()
+		if err == io.EOF {
+			return nil, errors.New("File is empty")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		csvReader.row++
+		if !isEmptyRow(header) {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+			for i := 0; i < len(header); i++ {
+				header[i] = strings.TrimSpace(header[i])
+			}
+			csvReader.Header = header
+			break
+		}
+	}
+
+	return csvReader, nil
+}
+
+// Returns the next row that isn't empty along with its row number. Rows that
+// can't be parsed return an error, and reading can carry on after them.
+func (cr *CSVReader) Read() ([]string, int, error) {
+	for {
+		row, err := cr.reader.Read()
+		if err == io.EOF {
+			return nil, cr.row, err
+		}
+
+		cr.row++
+		if err != nil {
+			return nil, cr.row, err
+		}
+
+		if !isEmptyRow(row) {
+			return row, cr.row, nil
+		}
+	}
+}
+
+func FileToCSVHeader(r *http.Request, file []byte) ([]Column, error) {
+	csvReader, err := NewCSVReader(file)
+	if err != nil {
+		log.Printf("%v", err)
+		return []Column{}, err
+	}
+
+	columns := make([]Column, len(csvReader.Header))
+	for i := 0; i < len(csvReader.Header); i++ {
+		columns[i].Name = csvReader.Header[i]
+		columns[i].Rows = []string{}
+	}
+
+	for x := 0; x < csvHeaderSampleRows; x++ {
+		row, _, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+
+		for i := 0; i < len(columns) && i < len(row); i++ {
+			columns[i].Rows = append(columns[i].Rows, strings.TrimSpace(row[i]))
+		}
+	}
+
+	return columns, nil
+}
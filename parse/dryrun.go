@@ -0,0 +1,322 @@
+package parse
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/goexcel"
+	"github.com/news-ai/web/utilities"
+)
+
+// What importing a file would do, worked out without writing anything. Rows
+// are numbered the same way as RowError.
+type ImportReport struct {
+	// Rows that have something in them, and the contacts they would create
+	Rows     int `json:"rows"`
+	Contacts int `json:"contacts"`
+
+	InvalidEmails       []RowError           `json:"invalidemails"`
+	EmptyNames          []RowError           `json:"emptynames"`
+	DuplicateRows       []DuplicateRow       `json:"duplicaterows"`
+	UnknownPublications []UnknownPublication `json:"unknownpublications"`
+	ExistingContacts    []ExistingContact    `json:"existingcontacts"`
+
+	// Rows that could not be read, or anything else wrong with a row
+	Errors []RowError `json:"errors"`
+}
+
+// A row with the same email, or the same name when there is no email, as an
+// earlier row of the file
+type DuplicateRow struct {
+	Row         int    `json:"row"`
+	DuplicateOf int    `json:"duplicateof"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+}
+
+// A publication that doesn't exist yet and would be created by the import
+type UnknownPublication struct {
+	Name string `json:"name"`
+	Rows []int  `json:"rows"`
+}
+
+// A row whose email already belongs to contacts of the user. These would be
+// merged into the existing contacts rather than being new people.
+type ExistingContact struct {
+	Row        int     `json:"row"`
+	Email      string  `json:"email"`
+	ContactIds []int64 `json:"contactids"`
+	ListIds    []int64 `json:"listids"`
+}
+
+// Emails looked up at once when checking for existing contacts
+var dryRunEmailBatchSize = 500
+
+// Header the Excel dry run reads publication names back from
+const dryRunPublicationPrefix = "dryrun_"
+
+type importReportBuilder struct {
+	report ImportReport
+
+	seenRows    map[string]int
+	emailToRows map[string][]int
+	emails      []string
+}
+
+func newImportReportBuilder() *importReportBuilder {
+	return &importReportBuilder{
+		report: ImportReport{
+			InvalidEmails:       []RowError{},
+			EmptyNames:          []RowError{},
+			DuplicateRows:       []DuplicateRow{},
+			UnknownPublications: []UnknownPublication{},
+			ExistingContacts:    []ExistingContact{},
+			Errors:              []RowError{},
+		},
+		seenRows:    map[string]int{},
+		emailToRows: map[string][]int{},
+	}
+}
+
+/*
+* Private methods
+ */
+
+func (b *importReportBuilder) addError(rowError RowError) {
+	b.report.Errors = append(b.report.Errors, rowError)
+}
+
+// Checks a contact the same way it would be stored
+func (b *importReportBuilder) addContact(contact models.Contact, rowNumber int, rowErrors []RowError) {
+	b.report.Rows++
+	b.report.Contacts++
+
+	contact.Normalize()
+	contact.FormatName()
+
+	validEmail := true
+	for i := 0; i < len(rowErrors); i++ {
+		if rowErrors[i].Column == "email" {
+			validEmail = false
+			b.report.InvalidEmails = append(b.report.InvalidEmails, rowErrors[i])
+		} else {
+			b.addError(rowErrors[i])
+		}
+	}
+
+	name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+	if name == "" {
+		b.report.EmptyNames = append(b.report.EmptyNames, RowError{Row: rowNumber, Column: "firstname", Message: "Row has no name"})
+	}
+
+	key := ""
+	if contact.Email != "" {
+		key = "email:" + contact.Email
+	} else if name != "" {
+		key = "name:" + strings.ToLower(name)
+	}
+
+	if key != "" {
+		if firstRow, ok := b.seenRows[key]; ok {
+			b.report.DuplicateRows = append(b.report.DuplicateRows, DuplicateRow{
+				Row:         rowNumber,
+				DuplicateOf: firstRow,
+				Email:       contact.Email,
+				Name:        name,
+			})
+		} else {
+			b.seenRows[key] = rowNumber
+		}
+	}
+
+	if contact.Email != "" && validEmail {
+		if _, ok := b.emailToRows[contact.Email]; !ok {
+			b.emails = append(b.emails, contact.Email)
+		}
+		b.emailToRows[contact.Email] = append(b.emailToRows[contact.Email], rowNumber)
+	}
+}
+
+// Looks up contacts the user already has with the emails in the file
+func (b *importReportBuilder) addExistingContacts(r *http.Request) error {
+	for start := 0; start < len(b.emails); start += dryRunEmailBatchSize {
+		end := start + dryRunEmailBatchSize
+		if end > len(b.emails) {
+			end = len(b.emails)
+		}
+
+		contacts, err := controllers.FilterContactsByEmailsForUser(r, b.emails[start:end])
+		if err != nil {
+			log.Printf("%v", err)
+			return err
+		}
+
+		existing := map[string]*ExistingContact{}
+		for i := 0; i < len(contacts); i++ {
+			if _, ok := existing[contacts[i].Email]; !ok {
+				existing[contacts[i].Email] = &ExistingContact{
+					Email:      contacts[i].Email,
+					ContactIds: []int64{},
+					ListIds:    []int64{},
+				}
+			}
+			existing[contacts[i].Email].ContactIds = append(existing[contacts[i].Email].ContactIds, contacts[i].Id)
+			if contacts[i].ListId != 0 {
+				existing[contacts[i].Email].ListIds = append(existing[contacts[i].Email].ListIds, contacts[i].ListId)
+			}
+		}
+
+		for email, existingContact := range existing {
+			rows := b.emailToRows[email]
+			for i := 0; i < len(rows); i++ {
+				existingContact.Row = rows[i]
+				b.report.ExistingContacts = append(b.report.ExistingContacts, *existingContact)
+			}
+		}
+	}
+
+	sort.Slice(b.report.ExistingContacts, func(i, j int) bool {
+		return b.report.ExistingContacts[i].Row < b.report.ExistingContacts[j].Row
+	})
+	return nil
+}
+
+func (b *importReportBuilder) finish(r *http.Request, mapper *rowMapper) (ImportReport, error) {
+	if len(mapper.unknownPublications) > 0 {
+		b.report.UnknownPublications = mapper.unknownPublications
+	}
+
+	err := b.addExistingContacts(r)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	return b.report, nil
+}
+
+/*
+* Public methods
+ */
+
+// Reports on a CSV or TSV import with the given header mapping
+func CSVDryRun(r *http.Request, file []byte, headerNames []string, headers []string, transforms []models.ImportTransform) (ImportReport, error) {
+	if len(headers) != len(headerNames) {
+		return ImportReport{}, errors.New("Length of headers does not match length of header names")
+	}
+
+	csvReader, err := NewCSVReader(file)
+	if err != nil {
+		log.Printf("%v", err)
+		return ImportReport{}, err
+	}
+
+	mapper := newRowMapper(r, headers)
+	mapper.setTransforms(headerNames, transforms)
+	mapper.dryRun = true
+	builder := newImportReportBuilder()
+
+	for {
+		row, rowNumber, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			builder.addError(RowError{Row: rowNumber, Message: err.Error(), Skipped: true})
+			continue
+		}
+
+		if len(row) > len(headers) && !isEmptyRow(row[len(headers):]) {
+			builder.addError(RowError{Row: rowNumber, Message: "Row has more columns than the header, extra values were ignored"})
+		}
+
+		contact, contactErrors := mapper.rowToContact(row, rowNumber)
+		builder.addContact(contact, rowNumber, contactErrors)
+	}
+
+	return builder.finish(r, mapper)
+}
+
+// Reports on a vCard import. Cards are numbered from 1.
+func VCardDryRun(r *http.Request, file []byte) (ImportReport, error) {
+	cards, err := readVCards(file)
+	if err != nil {
+		log.Printf("%v", err)
+		return ImportReport{}, err
+	}
+
+	mapper := newRowMapper(r, []string{})
+	mapper.dryRun = true
+	builder := newImportReportBuilder()
+
+	for i := 0; i < len(cards); i++ {
+		contact, contactErrors := mapper.vCardToContact(cards[i], i+1)
+		if contact.FirstName == "" && contact.LastName == "" && contact.Email == "" {
+			builder.report.Rows++
+			builder.addError(RowError{Row: i + 1, Message: "vCard has no name or email", Skipped: true})
+			continue
+		}
+		builder.addContact(contact, i+1, contactErrors)
+	}
+
+	return builder.finish(r, mapper)
+}
+
+// Reports on an Excel import. goexcel doesn't say which row a contact came
+// from, so rows are numbered by the order of the contacts after the header.
+func ExcelDryRun(r *http.Request, file []byte, headerNames []string, headers []string, transforms []models.ImportTransform, contentType string) (ImportReport, error) {
+	if len(headers) != len(headerNames) {
+		return ImportReport{}, errors.New("Length of headers does not match length of header names")
+	}
+
+	mapper := newRowMapper(r, headers)
+	mapper.setTransforms(headerNames, transforms)
+	mapper.dryRun = true
+	builder := newImportReportBuilder()
+
+	// Publications are read as custom fields so goexcel doesn't create them,
+	// and are looked up here instead
+	dryRunHeaders := excelTransformHeaders(headers, mapper.transforms)
+	for i := 0; i < len(dryRunHeaders); i++ {
+		if dryRunHeaders[i] == "employers" || dryRunHeaders[i] == "pastemployers" {
+			dryRunHeaders[i] = dryRunPublicationPrefix + dryRunHeaders[i]
+		}
+	}
+
+	contacts, _, err := goexcel.HeadersToListModel(r, file, dryRunHeaders, contentType)
+	if err != nil {
+		log.Printf("%v", err)
+		return ImportReport{}, err
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		rowNumber := i + 2
+		rowErrors := mapper.applyExcelTransforms(&contacts[i], rowNumber)
+
+		if contacts[i].Email != "" && !utilities.ValidateEmailFormat(contacts[i].Email) {
+			rowErrors = append(rowErrors, RowError{Row: rowNumber, Column: "email", Message: "Invalid email address " + contacts[i].Email})
+		}
+
+		for _, customField := range contacts[i].CustomFields {
+			if !strings.HasPrefix(customField.Name, dryRunPublicationPrefix) {
+				continue
+			}
+
+			column := strings.TrimPrefix(customField.Name, dryRunPublicationPrefix)
+			_, missing := mapper.publicationIds(customField.Value)
+			for x := 0; x < len(missing); x++ {
+				rowErrors = append(rowErrors, mapper.publicationNotFound(column, missing[x], rowNumber)...)
+			}
+		}
+
+		builder.addContact(contacts[i], rowNumber, rowErrors)
+	}
+
+	return builder.finish(r, mapper)
+}
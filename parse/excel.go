@@ -5,9 +5,9 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/news-ai/tabulae/controllers"
-	"github.com/news-ai/tabulae/models"
-	// "github.com/news-ai/tabulae/sync"
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/models"
+	// "github.com/news-ai/tabulae-v1/sync"
 
 	"github.com/news-ai/goexcel"
 	"github.com/news-ai/web/utilities"
@@ -21,6 +21,16 @@ func FileToExcelHeader(r *http.Request, file []byte, contentType string) ([]goex
 	return goexcel.FileToExcelHeader(r, file, contentType)
 }
 
+// Turns the headers of an Excel file into the columns the CSV importer uses
+func ExcelHeaderColumns(excelColumns []goexcel.Column) []Column {
+	columns := make([]Column, len(excelColumns))
+	for i := 0; i < len(excelColumns); i++ {
+		columns[i].Name = excelColumns[i].Name
+		columns[i].Rows = excelColumns[i].Rows
+	}
+	return columns
+}
+
 func ExcelHeadersToListModel(r *http.Request, file []byte, fileName string, headerNames []string, headers []string, mediaListid int64, contentType string) (models.MediaList, error) {
 	// Batch get all the contacts
 	contacts, customFields, err := goexcel.HeadersToListModel(r, file, headers, contentType)
@@ -37,8 +47,8 @@ func ExcelHeadersToListModel(r *http.Request, file []byte, fileName string, head
 	}
 
 	if len(headers) != len(headerNames) {
-		log.Infof(c, "%v", headers)
-		log.Infof(c, "%v", headerNames)
+		log.Printf("%v", headers)
+		log.Printf("%v", headerNames)
 
 		headerError := errors.New("Length of headers does not match length of header names")
 		return models.MediaList{}, headerError
@@ -48,18 +58,7 @@ func ExcelHeadersToListModel(r *http.Request, file []byte, fileName string, head
 	mediaListId := utilities.IntIdToString(mediaListid)
 	mediaList, _, err := controllers.GetMediaList(r, mediaListId)
 	mediaList.Contacts = contactIds
-	for i := 0; i < len(headers); i++ {
-		if _, ok := customFields[headers[i]]; ok {
-			if headers[i] != "ignore_column" {
-				customField := models.CustomFieldsMap{}
-				customField.Name = headerNames[i]
-				customField.Value = headers[i]
-				customField.CustomField = true
-				customField.Hidden = false
-				mediaList.FieldsMap = append(mediaList.FieldsMap, customField)
-			}
-		}
-	}
+	addCustomFieldsToList(&mediaList, headerNames, headers, customFields)
 
 	// Save the media list
 	mediaList.Save()
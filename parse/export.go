@@ -0,0 +1,345 @@
+package parse
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+// Writes the contacts of a list a batch at a time, so a list of any size can
+// be streamed straight to the response
+type ListWriter interface {
+	WriteContacts(contacts []models.Contact, publications []models.Publication) error
+
+	// Finishes the file. Nothing is complete until Close is called.
+	Close() error
+}
+
+type csvListWriter struct {
+	writer  *csv.Writer
+	columns []models.CustomFieldsMap
+}
+
+type jsonListWriter struct {
+	w       io.Writer
+	columns []models.CustomFieldsMap
+	written int
+}
+
+type xlsxListWriter struct {
+	zipWriter *zip.Writer
+	sheet     io.Writer
+	columns   []models.CustomFieldsMap
+	row       int
+}
+
+// A column of a JSON export
+type exportColumn struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	CustomField bool   `json:"customfield"`
+	Hidden      bool   `json:"hidden"`
+}
+
+var ListExportFormats = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Contacts" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+/*
+* Private methods
+ */
+
+// The value of a column for a contact. Read only columns are filled in as
+// custom fields by ContactsToDefaultFields.
+func exportValue(contact models.Contact, column models.CustomFieldsMap, publicationIdToName map[int64]string) string {
+	if !column.CustomField && !column.ReadOnly {
+		switch column.Value {
+		case "firstname":
+			return contact.FirstName
+		case "lastname":
+			return contact.LastName
+		case "email":
+			return contact.Email
+		case "employers":
+			return publicationNames(contact.Employers, publicationIdToName)
+		case "pastemployers":
+			return publicationNames(contact.PastEmployers, publicationIdToName)
+		case "notes":
+			return contact.Notes
+		case "linkedin":
+			return contact.LinkedIn
+		case "twitter":
+			return contact.Twitter
+		case "instagram":
+			return contact.Instagram
+		case "website":
+			return contact.Website
+		case "blog":
+			return contact.Blog
+		case "location":
+			return contact.Location
+		case "phonenumber":
+			return contact.PhoneNumber
+		case "tags":
+			return strings.Join(contact.Tags, ", ")
+		}
+	}
+
+	if column.Value == "lastcontacted" && !contact.LastContacted.IsZero() {
+		return contact.LastContacted.Format(time.RFC3339)
+	}
+
+	for i := 0; i < len(contact.CustomFields); i++ {
+		if contact.CustomFields[i].Name == column.Value {
+			return contact.CustomFields[i].Value
+		}
+	}
+	return ""
+}
+
+func publicationNames(ids []int64, publicationIdToName map[int64]string) string {
+	names := []string{}
+	for i := 0; i < len(ids); i++ {
+		if publicationIdToName[ids[i]] != "" {
+			names = append(names, publicationIdToName[ids[i]])
+		}
+	}
+	return strings.Join(names, "; ")
+}
+
+func exportRows(contacts []models.Contact, publications []models.Publication, columns []models.CustomFieldsMap) [][]string {
+	publicationIdToName := map[int64]string{}
+	for i := 0; i < len(publications); i++ {
+		publicationIdToName[publications[i].Id] = publications[i].Name
+	}
+
+	rows := make([][]string, len(contacts))
+	for i := 0; i < len(contacts); i++ {
+		rows[i] = make([]string, len(columns))
+		for x := 0; x < len(columns); x++ {
+			rows[i][x] = exportValue(contacts[i], columns[x], publicationIdToName)
+		}
+	}
+	return rows
+}
+
+func (cw *csvListWriter) WriteContacts(contacts []models.Contact, publications []models.Publication) error {
+	err := cw.writer.WriteAll(exportRows(contacts, publications, cw.columns))
+	if err != nil {
+		return err
+	}
+	return cw.writer.Error()
+}
+
+func (cw *csvListWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// Contacts are written as objects with their keys in column order, which a
+// map would lose
+func (jw *jsonListWriter) WriteContacts(contacts []models.Contact, publications []models.Publication) error {
+	rows := exportRows(contacts, publications, jw.columns)
+	for i := 0; i < len(rows); i++ {
+		var line strings.Builder
+		if jw.written > 0 {
+			line.WriteString(",")
+		}
+		line.WriteString("{")
+		for x := 0; x < len(rows[i]); x++ {
+			if x > 0 {
+				line.WriteString(",")
+			}
+			key, _ := json.Marshal(jw.columns[x].Value)
+			value, _ := json.Marshal(rows[i][x])
+			line.Write(key)
+			line.WriteString(":")
+			line.Write(value)
+		}
+		line.WriteString("}")
+
+		_, err := io.WriteString(jw.w, line.String())
+		if err != nil {
+			return err
+		}
+		jw.written++
+	}
+	return nil
+}
+
+func (jw *jsonListWriter) Close() error {
+	_, err := io.WriteString(jw.w, "]}")
+	return err
+}
+
+// Cells are written as inline strings so no shared string table has to be
+// kept in memory
+func (xw *xlsxListWriter) writeRow(values []string) error {
+	xw.row++
+	var line strings.Builder
+	line.WriteString(`<row r="` + strconv.Itoa(xw.row) + `">`)
+	for i := 0; i < len(values); i++ {
+		line.WriteString(`<c r="` + xlsxColumnName(i) + strconv.Itoa(xw.row) + `" t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(&line, []byte(xlsxCleanText(values[i])))
+		line.WriteString(`</t></is></c>`)
+	}
+	line.WriteString(`</row>`)
+
+	_, err := io.WriteString(xw.sheet, line.String())
+	return err
+}
+
+func (xw *xlsxListWriter) WriteContacts(contacts []models.Contact, publications []models.Publication) error {
+	rows := exportRows(contacts, publications, xw.columns)
+	for i := 0; i < len(rows); i++ {
+		err := xw.writeRow(rows[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (xw *xlsxListWriter) Close() error {
+	_, err := io.WriteString(xw.sheet, `</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+	return xw.zipWriter.Close()
+}
+
+// 0 is A, 25 is Z, 26 is AA
+func xlsxColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// Control characters other than tabs and newlines aren't allowed in XML
+func xlsxCleanText(value string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			return -1
+		}
+		return c
+	}, value)
+}
+
+/*
+* Public methods
+ */
+
+// The columns of a list in the order of its FieldsMap. Hidden columns are
+// left out unless includeHidden is set, and internal ones always are.
+func ListExportColumns(mediaList models.MediaList, includeHidden bool) []models.CustomFieldsMap {
+	columns := []models.CustomFieldsMap{}
+	for i := 0; i < len(mediaList.FieldsMap); i++ {
+		if mediaList.FieldsMap[i].Internal {
+			continue
+		}
+		if mediaList.FieldsMap[i].Hidden && !includeHidden {
+			continue
+		}
+		columns = append(columns, mediaList.FieldsMap[i])
+	}
+	return columns
+}
+
+// Starts an export in format, one of ListExportFormats, by writing the header
+func NewListWriter(format string, w io.Writer, columns []models.CustomFieldsMap) (ListWriter, error) {
+	names := make([]string, len(columns))
+	for i := 0; i < len(columns); i++ {
+		names[i] = columns[i].Name
+		if names[i] == "" {
+			names[i] = columns[i].Value
+		}
+	}
+
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		err := writer.Write(names)
+		if err != nil {
+			return nil, err
+		}
+		return &csvListWriter{writer: writer, columns: columns}, nil
+	case "json":
+		exportColumns := make([]exportColumn, len(columns))
+		for i := 0; i < len(columns); i++ {
+			exportColumns[i] = exportColumn{
+				Name:        names[i],
+				Value:       columns[i].Value,
+				CustomField: columns[i].CustomField,
+				Hidden:      columns[i].Hidden,
+			}
+		}
+
+		header, err := json.Marshal(exportColumns)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(w, `{"columns":`+string(header)+`,"contacts":[`)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonListWriter{w: w, columns: columns}, nil
+	case "xlsx":
+		zipWriter := zip.NewWriter(w)
+		for _, part := range xlsxParts {
+			partWriter, err := zipWriter.Create(part.name)
+			if err != nil {
+				return nil, err
+			}
+			_, err = io.WriteString(partWriter, part.content)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The sheet has to be the last part as it is written as contacts come
+		sheet, err := zipWriter.Create("xl/worksheets/sheet1.xml")
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+		if err != nil {
+			return nil, err
+		}
+
+		xlsxWriter := &xlsxListWriter{zipWriter: zipWriter, sheet: sheet, columns: columns}
+		err = xlsxWriter.writeRow(names)
+		if err != nil {
+			return nil, err
+		}
+		return xlsxWriter, nil
+	}
+
+	return nil, errors.New("Export format has to be one of csv, json or xlsx")
+}
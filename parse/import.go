@@ -0,0 +1,274 @@
+package parse
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/goexcel"
+	"github.com/news-ai/web/utilities"
+)
+
+// Reads the contacts of an uploaded file one at a time, whatever its format.
+// Import jobs use it to work through a file in chunks.
+type ImportReader interface {
+	// Rows in the file, not counting the header
+	Total() int
+
+	// Returns the next contact along with its row number and any problems
+	// with the row. Rows that can't be read return an error and reading can
+	// carry on after them. Returns io.EOF at the end of the file.
+	Next() (models.Contact, int, []RowError, error)
+
+	// Rows up to and including row are passed over without being mapped
+	Skip(row int)
+
+	// Adds the custom fields seen so far to the list
+	AddCustomFields(mediaList *models.MediaList)
+}
+
+type csvImportReader struct {
+	csvReader   *CSVReader
+	mapper      *rowMapper
+	headerNames []string
+	total       int
+	skip        int
+}
+
+type vCardImportReader struct {
+	cards  []vCard
+	mapper *rowMapper
+	next   int
+}
+
+type excelImportReader struct {
+	contacts     []models.Contact
+	customFields map[string]bool
+	mapper       *rowMapper
+	headerNames  []string
+	next         int
+}
+
+// Columns with a transform are read from goexcel as custom fields under this
+// prefix, and transformed afterwards
+const transformColumnPrefix = "transform_"
+
+/*
+* Private methods
+ */
+
+// goexcel maps the columns itself, so columns with a transform are renamed
+// for it to leave their values alone. goexcel doesn't know about beats, so
+// they are mapped the same way.
+func excelTransformHeaders(headers []string, transforms []string) []string {
+	excelHeaders := make([]string, len(headers))
+	for i := 0; i < len(headers); i++ {
+		excelHeaders[i] = headers[i]
+		if headers[i] == "beats" || (i < len(transforms) && transforms[i] != "" && headers[i] != ignoreColumn) {
+			excelHeaders[i] = transformColumnPrefix + strconv.Itoa(i)
+		}
+	}
+	return excelHeaders
+}
+
+// Transforms the columns excelTransformHeaders set aside and maps them onto
+// their fields
+func (m *rowMapper) applyExcelTransforms(contact *models.Contact, rowNumber int) []RowError {
+	rowErrors := []RowError{}
+
+	transformed := []models.CustomContactField{}
+	customFields := []models.CustomContactField{}
+	for _, customField := range contact.CustomFields {
+		if strings.HasPrefix(customField.Name, transformColumnPrefix) {
+			transformed = append(transformed, customField)
+		} else {
+			customFields = append(customFields, customField)
+		}
+	}
+	contact.CustomFields = customFields
+
+	for _, customField := range transformed {
+		i, err := strconv.Atoi(strings.TrimPrefix(customField.Name, transformColumnPrefix))
+		if err != nil || i >= len(m.headers) || i >= len(m.transforms) {
+			continue
+		}
+
+		value, ok := applyTransform(contact, m.transforms[i], strings.TrimSpace(customField.Value))
+		if ok && value != "" && m.headers[i] != "" {
+			rowErrors = append(rowErrors, m.setField(contact, m.headers[i], value, rowNumber)...)
+		}
+	}
+
+	return rowErrors
+}
+
+func (cr *csvImportReader) Total() int {
+	return cr.total
+}
+
+func (cr *csvImportReader) Next() (models.Contact, int, []RowError, error) {
+	for {
+		row, rowNumber, err := cr.csvReader.Read()
+		if err == io.EOF {
+			return models.Contact{}, rowNumber, []RowError{}, err
+		}
+		if rowNumber <= cr.skip {
+			continue
+		}
+		if err != nil {
+			return models.Contact{}, rowNumber, []RowError{}, err
+		}
+
+		rowErrors := []RowError{}
+		if len(row) > len(cr.mapper.headers) && !isEmptyRow(row[len(cr.mapper.headers):]) {
+			rowErrors = append(rowErrors, RowError{Row: rowNumber, Message: "Row has more columns than the header, extra values were ignored"})
+		}
+
+		contact, contactErrors := cr.mapper.rowToContact(row, rowNumber)
+		return contact, rowNumber, append(rowErrors, contactErrors...), nil
+	}
+}
+
+func (cr *csvImportReader) Skip(row int) {
+	cr.skip = row
+}
+
+func (cr *csvImportReader) AddCustomFields(mediaList *models.MediaList) {
+	addCustomFieldsToList(mediaList, cr.headerNames, cr.mapper.headers, cr.mapper.customFields)
+}
+
+func (vr *vCardImportReader) Total() int {
+	return len(vr.cards)
+}
+
+func (vr *vCardImportReader) Next() (models.Contact, int, []RowError, error) {
+	if vr.next >= len(vr.cards) {
+		return models.Contact{}, len(vr.cards), []RowError{}, io.EOF
+	}
+
+	vr.next++
+	contact, rowErrors := vr.mapper.vCardToContact(vr.cards[vr.next-1], vr.next)
+	if contact.FirstName == "" && contact.LastName == "" && contact.Email == "" {
+		return models.Contact{}, vr.next, rowErrors, errors.New("vCard has no name or email")
+	}
+	return contact, vr.next, rowErrors, nil
+}
+
+func (vr *vCardImportReader) Skip(row int) {
+	if row > vr.next {
+		vr.next = row
+	}
+}
+
+func (vr *vCardImportReader) AddCustomFields(mediaList *models.MediaList) {
+	addCustomFieldsToList(mediaList, []string{"Title"}, []string{"title"}, vr.mapper.customFields)
+}
+
+func (er *excelImportReader) Total() int {
+	return len(er.contacts)
+}
+
+// goexcel doesn't say which row a contact came from, so rows are numbered by
+// the order of the contacts after the header
+func (er *excelImportReader) Next() (models.Contact, int, []RowError, error) {
+	if er.next >= len(er.contacts) {
+		return models.Contact{}, len(er.contacts) + 1, []RowError{}, io.EOF
+	}
+
+	contact := er.contacts[er.next]
+	er.next++
+	rowNumber := er.next + 1
+
+	rowErrors := er.mapper.applyExcelTransforms(&contact, rowNumber)
+	if contact.Email != "" && !utilities.ValidateEmailFormat(contact.Email) {
+		rowErrors = append(rowErrors, RowError{Row: rowNumber, Column: "email", Message: "Invalid email address " + contact.Email})
+	}
+	return contact, rowNumber, rowErrors, nil
+}
+
+func (er *excelImportReader) Skip(row int) {
+	if row-1 > er.next {
+		er.next = row - 1
+	}
+}
+
+func (er *excelImportReader) AddCustomFields(mediaList *models.MediaList) {
+	addCustomFieldsToList(mediaList, er.headerNames, er.mapper.headers, er.customFields)
+	addCustomFieldsToList(mediaList, er.headerNames, er.mapper.headers, er.mapper.customFields)
+}
+
+/*
+* Public methods
+ */
+
+// Picks the reader for the file. transforms are applied to the columns they
+// name.
+func NewImportReader(r *http.Request, file []byte, fileName string, contentType string, headerNames []string, headers []string, transforms []models.ImportTransform) (ImportReader, error) {
+	if IsVCardFile(fileName, contentType) {
+		cards, err := readVCards(file)
+		if err != nil {
+			log.Printf("%v", err)
+			return nil, err
+		}
+		return &vCardImportReader{cards: cards, mapper: newRowMapper(r, []string{})}, nil
+	}
+
+	if len(headers) != len(headerNames) {
+		return nil, errors.New("Length of headers does not match length of header names")
+	}
+
+	if IsDelimitedFile(fileName, contentType) {
+		// Count the rows first so progress can be reported against them
+		counter, err := NewCSVReader(file)
+		if err != nil {
+			log.Printf("%v", err)
+			return nil, err
+		}
+
+		total := 0
+		for {
+			_, _, err := counter.Read()
+			if err == io.EOF {
+				break
+			}
+			total++
+		}
+
+		csvReader, err := NewCSVReader(file)
+		if err != nil {
+			log.Printf("%v", err)
+			return nil, err
+		}
+
+		mapper := newRowMapper(r, headers)
+		mapper.setTransforms(headerNames, transforms)
+
+		return &csvImportReader{
+			csvReader:   csvReader,
+			mapper:      mapper,
+			headerNames: headerNames,
+			total:       total,
+		}, nil
+	}
+
+	mapper := newRowMapper(r, headers)
+	mapper.setTransforms(headerNames, transforms)
+
+	contacts, customFields, err := goexcel.HeadersToListModel(r, file, excelTransformHeaders(headers, mapper.transforms), contentType)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil, err
+	}
+
+	return &excelImportReader{
+		contacts:     contacts,
+		customFields: customFields,
+		mapper:       mapper,
+		headerNames:  headerNames,
+	}, nil
+}
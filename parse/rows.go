@@ -0,0 +1,340 @@
+package parse
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// A problem with a single row of an imported file. Rows are numbered the way
+// a spreadsheet shows them, with the header as row 1.
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+
+	// Whether the row was left out of the import
+	Skipped bool `json:"skipped"`
+}
+
+// Column of an imported file along with a few of its values
+type Column struct {
+	Name string   `json:"name"`
+	Rows []string `json:"rows"`
+}
+
+const ignoreColumn = "ignore_column"
+
+// Turns rows into contacts. Publications are looked up once per import.
+type rowMapper struct {
+	r       *http.Request
+	headers []string
+
+	publicationNameToId map[string]int64
+	customFields        map[string]bool
+
+	// Beats are only matched, never created. Loaded the first time a beat
+	// column has a value.
+	beatTaxonomy *models.BeatTaxonomy
+
+	// Transform for each column, if it has one
+	transforms []string
+
+	// A dry run only looks publications up, the ones that would be created
+	// are collected instead
+	dryRun              bool
+	unknownPublications []UnknownPublication
+}
+
+func newRowMapper(r *http.Request, headers []string) *rowMapper {
+	return &rowMapper{
+		r:                   r,
+		headers:             headers,
+		publicationNameToId: map[string]int64{},
+		customFields:        map[string]bool{},
+	}
+}
+
+/*
+* Private methods
+ */
+
+// Lines the transforms up with the columns they are for
+func (m *rowMapper) setTransforms(headerNames []string, transforms []models.ImportTransform) {
+	m.transforms = make([]string, len(headerNames))
+	for i := 0; i < len(headerNames); i++ {
+		for _, transform := range transforms {
+			if models.NormalizeHeaderName(transform.Column) == models.NormalizeHeaderName(headerNames[i]) {
+				m.transforms[i] = transform.Transform
+			}
+		}
+	}
+}
+
+// "Agarwal, Abhi" or "Abhi Agarwal"
+func splitFullName(value string) (string, string) {
+	if strings.Contains(value, ",") {
+		nameSplit := strings.SplitN(value, ",", 2)
+		return strings.TrimSpace(nameSplit[1]), strings.TrimSpace(nameSplit[0])
+	}
+
+	nameSplit := strings.Fields(value)
+	if len(nameSplit) == 1 {
+		return nameSplit[0], ""
+	}
+	return nameSplit[0], strings.Join(nameSplit[1:], " ")
+}
+
+func splitTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.FieldsFunc(value, func(c rune) bool { return c == ',' || c == ';' }) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Applies a transform to the value of a column. Returns the value to map
+// onto the column's field, or false if the transform already put it on the
+// contact.
+func applyTransform(contact *models.Contact, transform string, value string) (string, bool) {
+	switch transform {
+	case models.ImportTransformSplitName:
+		contact.FirstName, contact.LastName = splitFullName(value)
+		return "", false
+	case models.ImportTransformTags:
+		contact.Tags = append(contact.Tags, splitTags(value)...)
+		return "", false
+	case models.ImportTransformStripAt:
+		return strings.TrimPrefix(strings.TrimSpace(value), "@"), true
+	}
+	return value, true
+}
+
+// Cells can hold several publications separated by semicolons
+func splitPublicationNames(value string) []string {
+	names := []string{}
+	for _, name := range strings.Split(value, ";") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Looks up or creates a publication by name, remembering the result
+func (m *rowMapper) publicationId(name string) (int64, bool) {
+	key := strings.ToLower(name)
+	if publicationId, ok := m.publicationNameToId[key]; ok {
+		return publicationId, publicationId != 0
+	}
+
+	var publication models.Publication
+	var err error
+	if m.dryRun {
+		publication, _, err = controllers.FilterPublicationByNameAndUrl(name, "")
+	} else {
+		publication, err = controllers.UploadFindOrCreatePublication(m.r, name, "")
+	}
+	if err != nil || publication.Id == 0 {
+		if err != nil {
+			log.Printf("%v", err)
+		}
+		m.publicationNameToId[key] = 0
+		return 0, false
+	}
+
+	m.publicationNameToId[key] = publication.Id
+	return publication.Id, true
+}
+
+// Publications that aren't found are an error for an import. A dry run
+// reports them separately since they would be created.
+func (m *rowMapper) publicationNotFound(column string, name string, rowNumber int) []RowError {
+	if !m.dryRun {
+		return []RowError{{Row: rowNumber, Column: column, Message: "Could not create publication " + name}}
+	}
+
+	for i := 0; i < len(m.unknownPublications); i++ {
+		if strings.EqualFold(m.unknownPublications[i].Name, name) {
+			m.unknownPublications[i].Rows = append(m.unknownPublications[i].Rows, rowNumber)
+			return []RowError{}
+		}
+	}
+
+	m.unknownPublications = append(m.unknownPublications, UnknownPublication{
+		Name: name,
+		Rows: []int{rowNumber},
+	})
+	return []RowError{}
+}
+
+// Cells can hold several beats, each a name, slug or path like "Tech > AI"
+func (m *rowMapper) beatIds(value string, rowNumber int) ([]int64, []RowError) {
+	if m.beatTaxonomy == nil {
+		taxonomy, err := models.GetBeatTaxonomy()
+		if err != nil {
+			log.Printf("%v", err)
+			return []int64{}, []RowError{{Row: rowNumber, Column: "beats", Message: "Could not look up beats"}}
+		}
+		m.beatTaxonomy = &taxonomy
+	}
+
+	beatIds := []int64{}
+	rowErrors := []RowError{}
+	for _, name := range strings.FieldsFunc(value, func(c rune) bool { return c == ',' || c == ';' }) {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		beat, err := m.beatTaxonomy.Find(name)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: rowNumber, Column: "beats", Message: err.Error() + ": " + strings.TrimSpace(name)})
+			continue
+		}
+		beatIds = append(beatIds, beat.Id)
+	}
+
+	return beatIds, rowErrors
+}
+
+func (m *rowMapper) publicationIds(value string) ([]int64, []string) {
+	publicationIds := []int64{}
+	missing := []string{}
+
+	for _, name := range splitPublicationNames(value) {
+		publicationId, ok := m.publicationId(name)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		publicationIds = append(publicationIds, publicationId)
+	}
+
+	return publicationIds, missing
+}
+
+// Puts the value of a column onto the field it is mapped to
+func (m *rowMapper) setField(contact *models.Contact, header string, value string, rowNumber int) []RowError {
+	rowErrors := []RowError{}
+
+	switch header {
+	case "firstname":
+		contact.FirstName = value
+	case "lastname":
+		contact.LastName = value
+	case "email":
+		contact.Email = value
+		if !utilities.ValidateEmailFormat(value) {
+			rowErrors = append(rowErrors, RowError{Row: rowNumber, Column: header, Message: "Invalid email address " + value})
+		}
+	case "notes":
+		contact.Notes = value
+	case "employers", "pastemployers":
+		publicationIds, missing := m.publicationIds(value)
+		if header == "employers" {
+			contact.Employers = append(contact.Employers, publicationIds...)
+		} else {
+			contact.PastEmployers = append(contact.PastEmployers, publicationIds...)
+		}
+		for x := 0; x < len(missing); x++ {
+			rowErrors = append(rowErrors, m.publicationNotFound(header, missing[x], rowNumber)...)
+		}
+	case "linkedin":
+		contact.LinkedIn = value
+	case "twitter":
+		contact.Twitter = value
+	case "instagram":
+		contact.Instagram = value
+	case "website":
+		contact.Website = value
+	case "blog":
+		contact.Blog = value
+	case "location":
+		contact.Location = value
+	case "phonenumber":
+		contact.PhoneNumber = value
+	case "beats":
+		beatIds, beatErrors := m.beatIds(value, rowNumber)
+		contact.Beats = append(contact.Beats, beatIds...)
+		rowErrors = append(rowErrors, beatErrors...)
+	default:
+		m.customFields[header] = true
+		contact.CustomFields = append(contact.CustomFields, models.CustomContactField{
+			Name:  header,
+			Value: value,
+		})
+	}
+
+	return rowErrors
+}
+
+// Maps a row onto a contact. rowNumber is only used for errors.
+func (m *rowMapper) rowToContact(row []string, rowNumber int) (models.Contact, []RowError) {
+	contact := models.Contact{}
+	rowErrors := []RowError{}
+
+	for i := 0; i < len(m.headers) && i < len(row); i++ {
+		header := m.headers[i]
+		value := strings.TrimSpace(row[i])
+		if header == ignoreColumn || value == "" {
+			continue
+		}
+
+		if i < len(m.transforms) && m.transforms[i] != "" {
+			var ok bool
+			value, ok = applyTransform(&contact, m.transforms[i], value)
+			if !ok || value == "" {
+				continue
+			}
+		}
+
+		if header == "" {
+			continue
+		}
+
+		rowErrors = append(rowErrors, m.setField(&contact, header, value, rowNumber)...)
+	}
+
+	return contact, rowErrors
+}
+
+func isEmptyRow(row []string) bool {
+	for i := 0; i < len(row); i++ {
+		if strings.TrimSpace(row[i]) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds the custom columns of an import to the fields of the list. Fields the
+// list already has are left alone, so this can be called once per chunk.
+func addCustomFieldsToList(mediaList *models.MediaList, headerNames []string, headers []string, customFields map[string]bool) {
+	existingFields := map[string]bool{}
+	for i := 0; i < len(mediaList.FieldsMap); i++ {
+		existingFields[mediaList.FieldsMap[i].Value] = true
+	}
+
+	for i := 0; i < len(headers); i++ {
+		if _, ok := customFields[headers[i]]; ok {
+			if headers[i] != ignoreColumn && !existingFields[headers[i]] {
+				existingFields[headers[i]] = true
+				customField := models.CustomFieldsMap{}
+				customField.Name = headerNames[i]
+				customField.Value = headers[i]
+				customField.CustomField = true
+				customField.Hidden = false
+				mediaList.FieldsMap = append(mediaList.FieldsMap, customField)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+func TestApplyTransform(t *testing.T) {
+	tests := []struct {
+		transform string
+		value     string
+		tags      []string
+
+		wantValue     string
+		wantMapped    bool
+		wantFirstName string
+		wantLastName  string
+		wantTags      []string
+	}{
+		{models.ImportTransformSplitName, "Jane Doe", nil, "", false, "Jane", "Doe", nil},
+		{models.ImportTransformSplitName, "Jane van der Berg", nil, "", false, "Jane", "van der Berg", nil},
+		{models.ImportTransformSplitName, "Doe, Jane", nil, "", false, "Jane", "Doe", nil},
+		{models.ImportTransformSplitName, "Cher", nil, "", false, "Cher", "", nil},
+		{models.ImportTransformStripAt, "@janedoe", nil, "janedoe", true, "", "", nil},
+		{models.ImportTransformStripAt, " @janedoe ", nil, "janedoe", true, "", "", nil},
+		{models.ImportTransformStripAt, "janedoe", nil, "janedoe", true, "", "", nil},
+		{models.ImportTransformTags, "tech; media, startups", nil, "", false, "", "", []string{"tech", "media", "startups"}},
+		{models.ImportTransformTags, "politics", []string{"tech"}, "", false, "", "", []string{"tech", "politics"}},
+		{models.ImportTransformTags, " ; ,", nil, "", false, "", "", []string{}},
+		{"", "Jane Doe", nil, "Jane Doe", true, "", "", nil},
+		{"unknown", "Jane Doe", nil, "Jane Doe", true, "", "", nil},
+	}
+
+	for _, test := range tests {
+		contact := models.Contact{Tags: test.tags}
+		value, mapped := applyTransform(&contact, test.transform, test.value)
+		if value != test.wantValue || mapped != test.wantMapped {
+			t.Errorf("applyTransform(%q, %q) = %q, %v, want %q, %v", test.transform, test.value, value, mapped, test.wantValue, test.wantMapped)
+		}
+		if contact.FirstName != test.wantFirstName || contact.LastName != test.wantLastName {
+			t.Errorf("applyTransform(%q, %q) set name %q %q, want %q %q", test.transform, test.value, contact.FirstName, contact.LastName, test.wantFirstName, test.wantLastName)
+		}
+		if len(contact.Tags) != len(test.wantTags) || (len(test.wantTags) > 0 && !reflect.DeepEqual(contact.Tags, test.wantTags)) {
+			t.Errorf("applyTransform(%q, %q) set tags %q, want %q", test.transform, test.value, contact.Tags, test.wantTags)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package parse
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// The field a column of an uploaded file most likely maps onto
+type HeaderSuggestion struct {
+	Column string `json:"column"`
+	Field  string `json:"field"`
+
+	// Whether Field is a custom field rather than a contact field
+	Custom bool `json:"custom"`
+
+	// Transform from the import profile the suggestion came from
+	Transform string `json:"transform"`
+	ProfileId int64  `json:"profileid"`
+
+	// Between 0 and 1, and what the suggestion is based on: "profile",
+	// "history", "name", "values" or "none"
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// Header names, once normalized, for each contact field
+var headerSynonyms = map[string][]string{
+	"firstname":     {"firstname", "first", "givenname", "fname", "forename", "name", "fullname", "contactname", "contact", "journalist", "reporter"},
+	"lastname":      {"lastname", "last", "surname", "familyname", "lname"},
+	"email":         {"email", "emailaddress", "mail", "workemail", "emails"},
+	"employers":     {"employers", "employer", "outlet", "outlets", "publication", "publications", "mediaoutlet", "company", "organization", "organisation", "newspaper", "magazine", "network", "station", "currentemployer"},
+	"pastemployers": {"pastemployers", "pastemployer", "previousemployers", "previousemployer", "pastoutlets", "formeremployers", "formeroutlets"},
+	"notes":         {"notes", "note", "comments", "comment", "description", "bio"},
+	"linkedin":      {"linkedin", "linkedinurl", "linkedinprofile"},
+	"twitter":       {"twitter", "twitterhandle", "twitterusername", "twitterurl", "handle"},
+	"instagram":     {"instagram", "instagramhandle", "ig", "instagramusername", "instagramurl"},
+	"website":       {"website", "url", "site", "web", "homepage", "websiteurl"},
+	"blog":          {"blog", "blogurl"},
+	"location":      {"location", "city", "address", "region", "country", "state", "market", "dma"},
+	"phonenumber":   {"phonenumber", "phone", "telephone", "tel", "mobile", "cell", "workphone", "officephone"},
+	"beats":         {"beats", "beat", "topics", "topic", "coverage", "coverageareas", "subjects", "focus"},
+}
+
+var (
+	twitterHandle = regexp.MustCompile(`^@[A-Za-z0-9_]{1,15}$`)
+	phoneNumber   = regexp.MustCompile(`^\+?[0-9 ().\-/x]{7,}$`)
+
+	// How much of the sample has to look like a field to suggest it
+	valueMatchThreshold = 0.6
+)
+
+/*
+* Private methods
+ */
+
+// Matches a header name onto a field, exactly or by the name containing one
+// of the synonyms
+func suggestFromName(name string) (string, float64) {
+	normalized := models.NormalizeHeaderName(name)
+	if normalized == "" {
+		return "", 0
+	}
+
+	for field, synonyms := range headerSynonyms {
+		for i := 0; i < len(synonyms); i++ {
+			if normalized == synonyms[i] {
+				return field, 0.9
+			}
+		}
+	}
+
+	// Longest synonym wins so "pastemployer" beats "employer"
+	bestField, bestLength := "", 0
+	for field, synonyms := range headerSynonyms {
+		for i := 0; i < len(synonyms); i++ {
+			if len(synonyms[i]) <= 3 || !strings.Contains(normalized, synonyms[i]) {
+				continue
+			}
+			if len(synonyms[i]) > bestLength || (len(synonyms[i]) == bestLength && field < bestField) {
+				bestField, bestLength = field, len(synonyms[i])
+			}
+		}
+	}
+	if bestField != "" {
+		return bestField, 0.7
+	}
+
+	return "", 0
+}
+
+func countDigits(value string) int {
+	digits := 0
+	for _, c := range value {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits
+}
+
+func valueField(value string) string {
+	lower := strings.ToLower(value)
+	switch {
+	case utilities.ValidateEmailFormat(value):
+		return "email"
+	case twitterHandle.MatchString(value), strings.Contains(lower, "twitter.com/"):
+		return "twitter"
+	case strings.Contains(lower, "linkedin.com/"):
+		return "linkedin"
+	case strings.Contains(lower, "instagram.com/"):
+		return "instagram"
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "www."):
+		return "website"
+	case phoneNumber.MatchString(value) && countDigits(value) >= 7:
+		return "phonenumber"
+	}
+	return ""
+}
+
+// Looks at the sampled values of a column for ones that look like a field
+func suggestFromValues(rows []string) (string, float64) {
+	counts := map[string]int{}
+	total := 0
+	for i := 0; i < len(rows); i++ {
+		value := strings.TrimSpace(rows[i])
+		if value == "" {
+			continue
+		}
+		total++
+		if field := valueField(value); field != "" {
+			counts[field]++
+		}
+	}
+
+	if total == 0 {
+		return "", 0
+	}
+
+	bestField, bestCount := "", 0
+	for field, count := range counts {
+		if count > bestCount {
+			bestField, bestCount = field, count
+		}
+	}
+
+	share := float64(bestCount) / float64(total)
+	if share < valueMatchThreshold {
+		return "", 0
+	}
+	return bestField, 0.8 * share
+}
+
+// Counts what each header name has been mapped onto in earlier uploads
+func headerHistory(pastFiles []models.File) map[string]map[string]int {
+	history := map[string]map[string]int{}
+	for _, file := range pastFiles {
+		for i := 0; i < len(file.HeaderNames) && i < len(file.Order); i++ {
+			name := models.NormalizeHeaderName(file.HeaderNames[i])
+			if name == "" || file.Order[i] == "" {
+				continue
+			}
+			if _, ok := history[name]; !ok {
+				history[name] = map[string]int{}
+			}
+			history[name][file.Order[i]]++
+		}
+	}
+	return history
+}
+
+func suggestFromHistory(name string, history map[string]map[string]int) (string, float64) {
+	fields, ok := history[models.NormalizeHeaderName(name)]
+	if !ok {
+		return "", 0
+	}
+
+	bestField, bestCount, total := "", 0, 0
+	for field, count := range fields {
+		total += count
+		if count > bestCount || (count == bestCount && field < bestField) {
+			bestField, bestCount = field, count
+		}
+	}
+
+	// Mapped the same way every time is a safer bet than a mixed history
+	return bestField, 0.8 + 0.2*float64(bestCount)/float64(total)
+}
+
+func isContactField(field string) bool {
+	_, ok := headerSynonyms[field]
+	return ok
+}
+
+/*
+* Public methods
+ */
+
+// Suggests a field for each column from what the user mapped the same header
+// onto before, the header name itself and the values in the column. Each
+// contact field is only suggested once, for the column it fits best.
+func SuggestHeaderMappings(columns []Column, pastFiles []models.File) []HeaderSuggestion {
+	history := headerHistory(pastFiles)
+
+	type candidate struct {
+		column     int
+		field      string
+		confidence float64
+		source     string
+	}
+
+	candidates := []candidate{}
+	for i := 0; i < len(columns); i++ {
+		if field, confidence := suggestFromHistory(columns[i].Name, history); field != "" {
+			candidates = append(candidates, candidate{i, field, confidence, "history"})
+		}
+		if field, confidence := suggestFromName(columns[i].Name); field != "" {
+			candidates = append(candidates, candidate{i, field, confidence, "name"})
+		}
+		if field, confidence := suggestFromValues(columns[i].Rows); field != "" {
+			candidates = append(candidates, candidate{i, field, confidence, "values"})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].confidence > candidates[j].confidence
+	})
+
+	suggestions := make([]HeaderSuggestion, len(columns))
+	assigned := make([]bool, len(columns))
+	usedFields := map[string]bool{}
+	for _, c := range candidates {
+		if assigned[c.column] {
+			continue
+		}
+		// Custom fields and ignored columns can be used more than once
+		if isContactField(c.field) && usedFields[c.field] {
+			continue
+		}
+
+		assigned[c.column] = true
+		usedFields[c.field] = true
+		suggestions[c.column] = HeaderSuggestion{
+			Column:     columns[c.column].Name,
+			Field:      c.field,
+			Custom:     !isContactField(c.field) && c.field != ignoreColumn,
+			Confidence: c.confidence,
+			Source:     c.source,
+		}
+	}
+
+	// Anything left over is kept as a custom field named after the header
+	for i := 0; i < len(columns); i++ {
+		if assigned[i] {
+			continue
+		}
+
+		field := strings.ToLower(strings.TrimSpace(columns[i].Name))
+		if field == "" {
+			field = ignoreColumn
+		}
+		suggestions[i] = HeaderSuggestion{
+			Column: columns[i].Name,
+			Field:  field,
+			Custom: field != ignoreColumn,
+			Source: "none",
+		}
+	}
+
+	return suggestions
+}
+
+// Maps the columns the way a saved import profile does. Columns are matched
+// by name, so the profile still applies when they are in another order.
+func ProfileHeaderMappings(columns []Column, importProfile models.ImportProfile) []HeaderSuggestion {
+	fields := map[string]string{}
+	for i := 0; i < len(importProfile.HeaderNames) && i < len(importProfile.Order); i++ {
+		fields[models.NormalizeHeaderName(importProfile.HeaderNames[i])] = importProfile.Order[i]
+	}
+
+	transforms := map[string]string{}
+	for _, transform := range importProfile.Transforms {
+		transforms[models.NormalizeHeaderName(transform.Column)] = transform.Transform
+	}
+
+	suggestions := SuggestHeaderMappings(columns, []models.File{})
+	for i := 0; i < len(columns); i++ {
+		name := models.NormalizeHeaderName(columns[i].Name)
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		suggestions[i] = HeaderSuggestion{
+			Column:     columns[i].Name,
+			Field:      field,
+			Custom:     !isContactField(field) && field != ignoreColumn,
+			Transform:  transforms[name],
+			ProfileId:  importProfile.Id,
+			Confidence: 1,
+			Source:     "profile",
+		}
+	}
+
+	return suggestions
+}
@@ -0,0 +1,160 @@
+package parse
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/news-ai/tabulae-v1/models"
+)
+
+func TestSuggestFromName(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      string
+		confidence float64
+	}{
+		{"First Name", "firstname", 0.9},
+		{"E-mail", "email", 0.9},
+		{"Twitter Handle", "twitter", 0.9},
+		{"Past Employers", "pastemployers", 0.9},
+		{"Outlet", "employers", 0.9},
+		{"Coverage Areas", "beats", 0.9},
+		{"Current Outlet Name", "employers", 0.7},
+		{"Past Employer Name", "pastemployers", 0.7},
+		{"Primary Phone", "phonenumber", 0.7},
+		{"Zip", "", 0},
+		{"!!!", "", 0},
+		{"", "", 0},
+	}
+
+	for _, test := range tests {
+		field, confidence := suggestFromName(test.name)
+		if field != test.field || confidence != test.confidence {
+			t.Errorf("suggestFromName(%q) = %q, %v, want %q, %v", test.name, field, confidence, test.field, test.confidence)
+		}
+	}
+}
+
+func TestValueField(t *testing.T) {
+	tests := []struct {
+		value string
+		field string
+	}{
+		{"jane@example.com", "email"},
+		{"@janedoe", "twitter"},
+		{"https://twitter.com/janedoe", "twitter"},
+		{"linkedin.com/in/janedoe", "linkedin"},
+		{"https://www.instagram.com/janedoe", "instagram"},
+		{"https://example.com", "website"},
+		{"www.example.com", "website"},
+		{"+1 (555) 123-4567", "phonenumber"},
+		{"555.123.4567 x12", "phonenumber"},
+		{"2017", ""},
+		{"Jane Doe", ""},
+		{"@this_handle_is_too_long", ""},
+	}
+
+	for _, test := range tests {
+		if field := valueField(test.value); field != test.field {
+			t.Errorf("valueField(%q) = %q, want %q", test.value, field, test.field)
+		}
+	}
+}
+
+func TestSuggestFromValues(t *testing.T) {
+	tests := []struct {
+		rows       []string
+		field      string
+		confidence float64
+	}{
+		{[]string{"jane@example.com", "john@example.com", "", "  "}, "email", 0.8},
+		{[]string{"@jane", "@john", "@jim", "Jim"}, "twitter", 0.8 * 0.75},
+		{[]string{"jane@example.com", "Jane", "John"}, "", 0},
+		{[]string{"", ""}, "", 0},
+		{[]string{}, "", 0},
+	}
+
+	for _, test := range tests {
+		field, confidence := suggestFromValues(test.rows)
+		if field != test.field || math.Abs(confidence-test.confidence) > 1e-9 {
+			t.Errorf("suggestFromValues(%q) = %q, %v, want %q, %v", test.rows, field, confidence, test.field, test.confidence)
+		}
+	}
+}
+
+func TestSuggestHeaderMappings(t *testing.T) {
+	columns := []Column{
+		{Name: "Name", Rows: []string{"Jane Doe", "John Roe"}},
+		{Name: "Contact", Rows: []string{"Jane", "John"}},
+		{Name: "Email", Rows: []string{"jane@example.com", "john@example.com"}},
+		{Name: "Col 4", Rows: []string{"jane@example.org", "john@example.org"}},
+		{Name: "Col 5", Rows: []string{"@jane", "@john"}},
+		{Name: "Outlet Name", Rows: []string{"Reuters", "AP"}},
+		{Name: "", Rows: []string{}},
+	}
+	pastFiles := []models.File{
+		{HeaderNames: []string{"outlet name", "Notes"}, Order: []string{"employers", "notes"}},
+		{HeaderNames: []string{"Outlet-Name"}, Order: []string{"employers"}},
+	}
+
+	want := []struct {
+		field  string
+		custom bool
+		source string
+	}{
+		{"firstname", false, "name"},
+		// Each contact field is only used once, later columns are kept
+		{"contact", true, "none"},
+		{"email", false, "name"},
+		{"col 4", true, "none"},
+		{"twitter", false, "values"},
+		{"employers", false, "history"},
+		{ignoreColumn, false, "none"},
+	}
+
+	suggestions := SuggestHeaderMappings(columns, pastFiles)
+	if len(suggestions) != len(want) {
+		t.Fatalf("SuggestHeaderMappings returned %d suggestions, want %d", len(suggestions), len(want))
+	}
+
+	for i := 0; i < len(want); i++ {
+		got := suggestions[i]
+		if got.Column != columns[i].Name || got.Field != want[i].field || got.Custom != want[i].custom || got.Source != want[i].source {
+			t.Errorf("suggestion for %q = %+v, want field %q, custom %v, source %q", columns[i].Name, got, want[i].field, want[i].custom, want[i].source)
+		}
+	}
+
+	if suggestions[5].Confidence != 1 {
+		t.Errorf("history mapped the same way every time has confidence %v, want 1", suggestions[5].Confidence)
+	}
+}
+
+func TestProfileHeaderMappings(t *testing.T) {
+	importProfile := models.ImportProfile{
+		HeaderNames: []string{"Full Name", "E-Mail Address", "Handle"},
+		Order:       []string{"firstname", "email", "twitter"},
+		Transforms: []models.ImportTransform{
+			{Column: "Full Name", Transform: models.ImportTransformSplitName},
+			{Column: "Handle", Transform: models.ImportTransformStripAt},
+		},
+	}
+	importProfile.Id = 7
+
+	columns := []Column{
+		{Name: "email address", Rows: []string{"jane@example.com"}},
+		{Name: "FULL NAME", Rows: []string{"Jane Doe"}},
+		{Name: "Notes", Rows: []string{"Covers tech"}},
+	}
+
+	want := []HeaderSuggestion{
+		{Column: "email address", Field: "email", ProfileId: 7, Confidence: 1, Source: "profile"},
+		{Column: "FULL NAME", Field: "firstname", Transform: models.ImportTransformSplitName, ProfileId: 7, Confidence: 1, Source: "profile"},
+		{Column: "Notes", Field: "notes", Confidence: 0.9, Source: "name"},
+	}
+
+	suggestions := ProfileHeaderMappings(columns, importProfile)
+	if !reflect.DeepEqual(suggestions, want) {
+		t.Errorf("ProfileHeaderMappings = %+v, want %+v", suggestions, want)
+	}
+}
@@ -0,0 +1,396 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"path/filepath"
+	"strings"
+
+	"github.com/news-ai/tabulae-v1/models"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// A single line of a vCard, after unfolding
+type vCardProperty struct {
+	Name   string
+	Params map[string][]string
+	Value  string
+}
+
+// Properties of a single vCard, keyed by upper case name
+type vCard map[string][]vCardProperty
+
+/*
+* Private methods
+ */
+
+// Splits s on sep, skipping separators escaped with a backslash
+func splitVCardValue(s string, sep byte) []string {
+	parts := []string{}
+	current := []byte{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			current = append(current, s[i], s[i+1])
+			i++
+			continue
+		}
+		if s[i] == sep {
+			parts = append(parts, string(current))
+			current = []byte{}
+			continue
+		}
+		current = append(current, s[i])
+	}
+	return append(parts, string(current))
+}
+
+func unescapeVCardValue(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\:`, ":", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(s))
+}
+
+func escapeVCardValue(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "\r\n", `\n`, "\n", `\n`, ",", `\,`, ";", `\;`)
+	return replacer.Replace(s)
+}
+
+// Parses "item1.EMAIL;TYPE=INTERNET,pref:someone@example.com"
+func parseVCardProperty(line string) (vCardProperty, bool) {
+	// The first colon outside of a quoted parameter value ends the name
+	inQuotes := false
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == '"' {
+			inQuotes = !inQuotes
+		} else if line[i] == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return vCardProperty{}, false
+	}
+
+	property := vCardProperty{
+		Params: map[string][]string{},
+		Value:  line[colon+1:],
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	property.Name = strings.ToUpper(parts[0])
+	if dot := strings.LastIndex(property.Name, "."); dot != -1 {
+		property.Name = property.Name[dot+1:]
+	}
+
+	for _, param := range parts[1:] {
+		keyValue := strings.SplitN(param, "=", 2)
+		key := strings.ToUpper(strings.TrimSpace(keyValue[0]))
+		if len(keyValue) == 1 {
+			// vCard 2.1 allows bare types: "TEL;WORK;VOICE:..."
+			property.Params["TYPE"] = append(property.Params["TYPE"], strings.ToLower(key))
+			continue
+		}
+		for _, value := range strings.Split(strings.Trim(keyValue[1], `"`), ",") {
+			property.Params[key] = append(property.Params[key], strings.ToLower(value))
+		}
+	}
+
+	if encoding := property.Params["ENCODING"]; len(encoding) > 0 && encoding[0] == "quoted-printable" {
+		decoded, err := ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(property.Value)))
+		if err == nil {
+			property.Value = string(decoded)
+		}
+	}
+
+	return property, true
+}
+
+func (p vCardProperty) hasType(value string) bool {
+	for _, t := range p.Params["TYPE"] {
+		if t == value {
+			return true
+		}
+	}
+	return false
+}
+
+// The preferred value of a property, or the first one
+func (card vCard) preferred(name string) (vCardProperty, bool) {
+	properties := card[name]
+	if len(properties) == 0 {
+		return vCardProperty{}, false
+	}
+	for i := 0; i < len(properties); i++ {
+		if properties[i].hasType("pref") || len(properties[i].Params["PREF"]) > 0 {
+			return properties[i], true
+		}
+	}
+	return properties[0], true
+}
+
+// Reads every vCard in a file. Folded lines are joined back together and
+// quoted-printable soft line breaks from Outlook are followed.
+func readVCards(file []byte) ([]vCard, error) {
+	decoded, _ := decodeTextFile(file)
+	scanner := bufio.NewScanner(decoded)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if len(lines) > 0 && strings.HasSuffix(lines[len(lines)-1], "=") && strings.Contains(strings.ToUpper(lines[len(lines)-1]), "QUOTED-PRINTABLE") {
+			lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], "=") + line
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	cards := []vCard{}
+	var card vCard
+	for i := 0; i < len(lines); i++ {
+		property, ok := parseVCardProperty(strings.TrimPrefix(lines[i], "\ufeff"))
+		if !ok {
+			continue
+		}
+
+		switch {
+		case property.Name == "BEGIN" && strings.EqualFold(property.Value, "VCARD"):
+			card = vCard{}
+		case property.Name == "END" && strings.EqualFold(property.Value, "VCARD"):
+			if card != nil {
+				cards = append(cards, card)
+			}
+			card = nil
+		case card != nil:
+			card[property.Name] = append(card[property.Name], property)
+		}
+	}
+
+	if len(cards) == 0 {
+		return nil, errors.New("File does not contain any vCards")
+	}
+
+	return cards, nil
+}
+
+// Puts a URL from a vCard in the matching social field of a contact
+func addVCardURL(contact *models.Contact, url string) {
+	lowerURL := strings.ToLower(url)
+	switch {
+	case strings.Contains(lowerURL, "twitter.com/"):
+		if contact.Twitter == "" {
+			contact.Twitter = url
+		}
+	case strings.Contains(lowerURL, "linkedin.com/"):
+		if contact.LinkedIn == "" {
+			contact.LinkedIn = url
+		}
+	case strings.Contains(lowerURL, "instagram.com/"):
+		if contact.Instagram == "" {
+			contact.Instagram = url
+		}
+	default:
+		if contact.Website == "" {
+			contact.Website = url
+		} else if contact.Blog == "" {
+			contact.Blog = url
+		}
+	}
+}
+
+func (m *rowMapper) vCardToContact(card vCard, cardNumber int) (models.Contact, []RowError) {
+	contact := models.Contact{}
+	rowErrors := []RowError{}
+
+	if n, ok := card.preferred("N"); ok {
+		name := splitVCardValue(n.Value, ';')
+		contact.LastName = unescapeVCardValue(name[0])
+		if len(name) > 1 {
+			contact.FirstName = unescapeVCardValue(name[1])
+		}
+	}
+	if contact.FirstName == "" && contact.LastName == "" {
+		if fn, ok := card.preferred("FN"); ok {
+			// Split by FormatName when the contact is created
+			contact.FirstName = unescapeVCardValue(fn.Value)
+		}
+	}
+
+	if email, ok := card.preferred("EMAIL"); ok {
+		contact.Email = unescapeVCardValue(strings.TrimPrefix(email.Value, "mailto:"))
+		if !utilities.ValidateEmailFormat(contact.Email) {
+			rowErrors = append(rowErrors, RowError{Row: cardNumber, Column: "email", Message: "Invalid email address " + contact.Email})
+		}
+	}
+
+	if tel, ok := card.preferred("TEL"); ok {
+		contact.PhoneNumber = unescapeVCardValue(strings.TrimPrefix(tel.Value, "tel:"))
+	}
+
+	if org, ok := card.preferred("ORG"); ok {
+		// ORG is "Organization;Unit;Unit"
+		organization := unescapeVCardValue(splitVCardValue(org.Value, ';')[0])
+		if organization != "" {
+			publicationId, ok := m.publicationId(organization)
+			if ok {
+				contact.Employers = append(contact.Employers, publicationId)
+			} else {
+				rowErrors = append(rowErrors, m.publicationNotFound("employers", organization, cardNumber)...)
+			}
+		}
+	}
+
+	if title, ok := card.preferred("TITLE"); ok && unescapeVCardValue(title.Value) != "" {
+		m.customFields["title"] = true
+		contact.CustomFields = append(contact.CustomFields, models.CustomContactField{
+			Name:  "title",
+			Value: unescapeVCardValue(title.Value),
+		})
+	}
+
+	for _, url := range card["URL"] {
+		addVCardURL(&contact, unescapeVCardValue(url.Value))
+	}
+	for _, profile := range card["X-SOCIALPROFILE"] {
+		addVCardURL(&contact, unescapeVCardValue(profile.Value))
+	}
+
+	if adr, ok := card.preferred("ADR"); ok {
+		// ADR is "PO Box;Extended;Street;Locality;Region;Postal Code;Country"
+		address := splitVCardValue(adr.Value, ';')
+		location := []string{}
+		for _, i := range []int{3, 4, 6} {
+			if i < len(address) && unescapeVCardValue(address[i]) != "" {
+				location = append(location, unescapeVCardValue(address[i]))
+			}
+		}
+		contact.Location = strings.Join(location, ", ")
+	}
+
+	if note, ok := card.preferred("NOTE"); ok {
+		contact.Notes = unescapeVCardValue(note.Value)
+	}
+
+	if photo, ok := card.preferred("PHOTO"); ok && strings.HasPrefix(photo.Value, "http") {
+		contact.ImageURL = photo.Value
+	}
+
+	return contact, rowErrors
+}
+
+func writeVCardLine(w io.Writer, line string) error {
+	// Lines are folded at 75 octets, without splitting a character
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, err := io.WriteString(w, line[:cut]+"\r\n "); err != nil {
+			return err
+		}
+		line = line[cut:]
+	}
+	_, err := io.WriteString(w, line+"\r\n")
+	return err
+}
+
+/*
+* Public methods
+ */
+
+func IsVCardFile(fileName string, contentType string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".vcf", ".vcard":
+		return true
+	}
+
+	switch strings.ToLower(strings.Split(contentType, ";")[0]) {
+	case "text/vcard", "text/x-vcard", "text/directory":
+		return true
+	}
+	return false
+}
+
+// Writes contacts out as vCard 3.0, which both Outlook and Apple Contacts
+// read. publications is used to name the employers of each contact.
+func WriteVCards(w io.Writer, contacts []models.Contact, publications []models.Publication) error {
+	publicationIdToName := map[int64]string{}
+	for i := 0; i < len(publications); i++ {
+		publicationIdToName[publications[i].Id] = publications[i].Name
+	}
+
+	for i := 0; i < len(contacts); i++ {
+		var card bytes.Buffer
+		contact := contacts[i]
+
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:3.0",
+			"N:" + escapeVCardValue(contact.LastName) + ";" + escapeVCardValue(contact.FirstName) + ";;;",
+			"FN:" + escapeVCardValue(strings.TrimSpace(contact.FirstName+" "+contact.LastName)),
+		}
+
+		if contact.Email != "" {
+			lines = append(lines, "EMAIL;TYPE=INTERNET:"+escapeVCardValue(contact.Email))
+		}
+		if contact.PhoneNumber != "" {
+			lines = append(lines, "TEL;TYPE=WORK:"+escapeVCardValue(contact.PhoneNumber))
+		}
+		if len(contact.Employers) > 0 && publicationIdToName[contact.Employers[0]] != "" {
+			lines = append(lines, "ORG:"+escapeVCardValue(publicationIdToName[contact.Employers[0]]))
+		}
+		for x := 0; x < len(contact.CustomFields); x++ {
+			if contact.CustomFields[x].Name == "title" && contact.CustomFields[x].Value != "" {
+				lines = append(lines, "TITLE:"+escapeVCardValue(contact.CustomFields[x].Value))
+				break
+			}
+		}
+		if contact.Twitter != "" {
+			lines = append(lines, "URL;TYPE=twitter:https://twitter.com/"+contact.Twitter)
+		}
+		if contact.LinkedIn != "" {
+			lines = append(lines, "URL;TYPE=linkedin:"+contact.LinkedIn)
+		}
+		if contact.Instagram != "" {
+			lines = append(lines, "URL;TYPE=instagram:https://instagram.com/"+contact.Instagram)
+		}
+		if contact.Website != "" {
+			lines = append(lines, "URL;TYPE=work:"+contact.Website)
+		}
+		if contact.Blog != "" {
+			lines = append(lines, "URL;TYPE=blog:"+contact.Blog)
+		}
+		if contact.Location != "" {
+			lines = append(lines, "ADR;TYPE=WORK:;;;"+escapeVCardValue(contact.Location)+";;;")
+		}
+		if contact.Notes != "" {
+			lines = append(lines, "NOTE:"+escapeVCardValue(contact.Notes))
+		}
+		lines = append(lines, fmt.Sprintf("UID:tabulae-contact-%d", contact.Id), "END:VCARD")
+
+		for x := 0; x < len(lines); x++ {
+			if err := writeVCardLine(&card, lines[x]); err != nil {
+				return err
+			}
+		}
+
+		if _, err := card.WriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
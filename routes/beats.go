@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errBeatHandling = "Beat handling error"
+)
+
+func handleBeatActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		switch action {
+		case "contacts":
+			val, included, count, total, err := controllers.GetContactsForBeat(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "publications":
+			val, included, count, total, err := controllers.GetPublicationsForBeat(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleBeat(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		return api.BaseSingleResponseHandler(controllers.GetBeat(id))
+	case "PATCH":
+		return api.BaseSingleResponseHandler(controllers.UpdateBeat(r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleBeats(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		val, included, count, total, err := controllers.GetBeats(r)
+		return api.BaseResponseHandler(val, included, count, total, err, r)
+	case "POST":
+		return api.BaseSingleResponseHandler(controllers.CreateBeat(r))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when the user wants the beat taxonomy.
+func BeatsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	val, err := handleBeats(w, r)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errBeatHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when there is a key present after /beats/<id> route.
+func BeatHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleBeat(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errBeatHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when the user wants to perform an action on a beat
+func BeatActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleBeatActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errBeatHandling, err.Error())
+	}
+	return
+}
@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errCampaignHandling = "Campaign handling error"
+)
+
+func handleCampaignActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		switch action {
+		case "emails":
+			val, included, count, total, err := controllers.GetEmailsForCampaign(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "stats":
+			return api.BaseSingleResponseHandler(controllers.GetCampaignStats(r, id))
+		case "analytics":
+			return api.BaseSingleResponseHandler(controllers.GetCampaignAnalytics(r, id))
+		case "timetoopen":
+			val, included, count, total, err := controllers.GetCampaignTimeToOpen(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "publications":
+			val, included, count, total, err := controllers.GetCampaignPublications(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "contacts":
+			val, included, count, total, err := controllers.GetCampaignContacts(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "warnings":
+			val, included, count, total, err := controllers.GetCampaignEmailWarnings(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		}
+	case "POST":
+		switch action {
+		case "cancel":
+			return api.BaseSingleResponseHandler(controllers.CancelCampaign(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleCampaign(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		if id == "compare" {
+			val, included, count, total, err := controllers.CompareCampaigns(r)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		}
+		return api.BaseSingleResponseHandler(controllers.GetCampaign(r, id))
+	case "PATCH":
+		return api.BaseSingleResponseHandler(controllers.UpdateCampaign(r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleCampaigns(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		val, included, count, total, err := controllers.GetCampaigns(r)
+		return api.BaseResponseHandler(val, included, count, total, err, r)
+	case "POST":
+		return api.BaseSingleResponseHandler(controllers.CreateCampaign(r))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when the user wants all the campaigns.
+func CampaignsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	val, err := handleCampaigns(w, r)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errCampaignHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when there is a key present after /campaigns/<id> route.
+func CampaignHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleCampaign(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errCampaignHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when the user wants to perform an action on a campaign
+func CampaignActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleCampaignActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errCampaignHandling, err.Error())
+	}
+	return
+}
+
+// Handler for exporting campaign analytics as CSV. /campaigns/compare/export
+// exports the campaigns passed in the "ids" parameter side by side.
+func CampaignExportHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	// Build the whole report first so errors can still be returned as JSON
+	var buf bytes.Buffer
+	var err error
+	if id == "compare" {
+		err = controllers.ExportCampaignComparison(r, &buf)
+	} else {
+		err = controllers.ExportCampaignAnalytics(r, &buf, id)
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		nError.ReturnError(w, http.StatusInternalServerError, errCampaignHandling, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"campaign-"+id+".csv\"")
+	buf.WriteTo(w)
+	return
+}
@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errEmploymentHandling = "Employment handling error"
+)
+
+func handleEmploymentActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "POST":
+		switch action {
+		case "end":
+			return api.BaseSingleResponseHandler(controllers.EndEmployment(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleEmployment(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		return api.BaseSingleResponseHandler(controllers.GetEmployment(r, id))
+	case "PATCH":
+		return api.BaseSingleResponseHandler(controllers.UpdateEmployment(r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleEmployments(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "POST":
+		return api.BaseSingleResponseHandler(controllers.CreateEmployment(r))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when the user wants to add a job to a contact.
+func EmploymentsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	val, err := handleEmployments(w, r)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errEmploymentHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when there is a key present after /employments/<id> route.
+func EmploymentHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleEmployment(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errEmploymentHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when the user wants to perform an action on an employment
+func EmploymentActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleEmploymentActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errEmploymentHandling, err.Error())
+	}
+	return
+}
@@ -13,6 +13,23 @@ import (
 	nError "github.com/news-ai/web/errors"
 )
 
+func handleFeedActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		switch action {
+		case "headlines":
+			val, included, count, total, err := controllers.GetHeadlinesForFeed(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		}
+	case "POST":
+		switch action {
+		case "fetch":
+			return api.BaseSingleResponseHandler(controllers.FetchFeed(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
 func handleFeed(r *http.Request, id string) (interface{}, error) {
 	switch r.Method {
 	case "GET":
@@ -64,3 +81,20 @@ func FeedHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	}
 	return
 }
+
+// Handler for when the user wants to perform an action on the feeds
+func FeedActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleFeedActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, "Feed handling error", err.Error())
+	}
+	return
+}
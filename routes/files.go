@@ -8,29 +8,33 @@ import (
 	"github.com/pquerna/ffjson/ffjson"
 
 	"github.com/news-ai/tabulae-v1/controllers"
-	// "github.com/news-ai/tabulae-v1/files"
+	"github.com/news-ai/tabulae-v1/files"
 
 	"github.com/news-ai/web/api"
 	nError "github.com/news-ai/web/errors"
 )
 
-// func handleFileAction(r *http.Request, id string, action string) (interface{}, error) {
-// 	switch r.Method {
-// 	case "GET":
-// 		switch action {
-// 		case "headers":
-// 			return api.BaseSingleResponseHandler(files.HandleFileGetHeaders(r, id))
-// 		case "sheets":
-// 			return api.BaseSingleResponseHandler(files.HandleFileGetSheets(r, id))
-// 		}
-// 	case "POST":
-// 		switch action {
-// 		case "headers":
-// 			return api.BaseSingleResponseHandler(files.HandleFileUploadHeaders(r, id))
-// 		}
-// 	}
-// 	return nil, errors.New("method not implemented")
-// }
+func handleFileAction(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		switch action {
+		case "headers":
+			return api.BaseSingleResponseHandler(files.HandleFileGetHeaders(r, id))
+		case "sheets":
+			return api.BaseSingleResponseHandler(files.HandleFileGetSheets(r, id))
+		case "import":
+			return api.BaseSingleResponseHandler(controllers.GetImportJobForFile(r, id))
+		}
+	case "POST":
+		switch action {
+		case "headers":
+			return api.BaseSingleResponseHandler(files.HandleFileUploadHeaders(r, id))
+		case "dryrun":
+			return api.BaseSingleResponseHandler(files.HandleFileDryRun(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
 
 func handleFile(r *http.Request, id string) (interface{}, error) {
 	switch r.Method {
@@ -80,18 +84,18 @@ func FileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	return
 }
 
-// func FileActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-// 	w.Header().Set("Content-Type", "application/json")
-// 	id := ps.ByName("id")
-// 	action := ps.ByName("action")
-// 	val, err := handleFileAction(r, id, action)
-
-// 	if err == nil {
-// 		err = ffjson.NewEncoder(w).Encode(val)
-// 	}
-
-// 	if err != nil {
-// 		nError.ReturnError(w, http.StatusInternalServerError, "File handling error", err.Error())
-// 	}
-// 	return
-// }
+func FileActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+	val, err := handleFileAction(r, id, action)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, "File handling error", err.Error())
+	}
+	return
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errImportJobHandling = "Import job handling error"
+)
+
+func handleImportJobActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "POST":
+		switch action {
+		case "cancel":
+			return api.BaseSingleResponseHandler(controllers.CancelImportJob(r, id))
+		case "resume":
+			return api.BaseSingleResponseHandler(controllers.ResumeImportJob(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleImportJob(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		return api.BaseSingleResponseHandler(controllers.GetImportJob(r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when there is a key present after /importjobs/<id> route. The
+// job has the progress of the import.
+func ImportJobHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleImportJob(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errImportJobHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when the user wants to cancel or resume an import job
+func ImportJobActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleImportJobActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errImportJobHandling, err.Error())
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errImportProfileHandling = "Import profile handling error"
+)
+
+func handleImportProfile(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		return api.BaseSingleResponseHandler(controllers.GetImportProfile(r, id))
+	case "PATCH":
+		return api.BaseSingleResponseHandler(controllers.UpdateImportProfile(r, id))
+	case "DELETE":
+		return api.BaseSingleResponseHandler(controllers.DeleteImportProfile(r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleImportProfiles(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		val, included, count, total, err := controllers.GetImportProfiles(r)
+		return api.BaseResponseHandler(val, included, count, total, err, r)
+	case "POST":
+		return api.BaseSingleResponseHandler(controllers.CreateImportProfile(r))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when the user wants all the import profiles of their team.
+func ImportProfilesHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	val, err := handleImportProfiles(w, r)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errImportProfileHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when there is a key present after /importprofiles/<id> route.
+func ImportProfileHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleImportProfile(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errImportProfileHandling, err.Error())
+	}
+	return
+}
@@ -1,86 +1,102 @@
 package routes
 
-// import (
-// 	"errors"
-// 	"net/http"
-
-// 	"github.com/julienschmidt/httprouter"
-// 	"github.com/pquerna/ffjson/ffjson"
-
-// 	"github.com/news-ai/tabulae-v1/controllers"
-// 	"github.com/news-ai/tabulae-v1/files"
-
-// 	"github.com/news-ai/web/api"
-// 	nError "github.com/news-ai/web/errors"
-// )
-
-// var (
-// 	errMediaListHandling = "Media List handling error"
-// )
-
-// func handleMediaListActions(c context.Context, r *http.Request, id string, action string) (interface{}, error) {
-// 	switch r.Method {
-// 	case "GET":
-// 		switch action {
-// 		case "contacts":
-// 			val, included, count, total, err := controllers.GetContactsForList(c, r, id)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		case "headlines":
-// 			val, included, count, total, err := controllers.GetHeadlinesForList(c, r, id)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		case "tweets":
-// 			val, included, count, total, err := controllers.GetTweetsForList(c, r, id)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		case "feed":
-// 			val, included, count, total, err := controllers.GetFeedForList(c, r, id)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		case "emails":
-// 			val, included, count, total, err := controllers.GetEmailsForList(c, r, id)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		case "public":
-// 			return api.BaseSingleResponseHandler(controllers.UpdateMediaListToPublic(c, r, id))
-// 		case "resync":
-// 			return api.BaseSingleResponseHandler(controllers.ReSyncMediaList(c, r, id))
-// 		}
-// 	case "POST":
-// 		switch action {
-// 		case "upload":
-// 			return api.BaseSingleResponseHandler(files.HandleMediaListActionUpload(c, r, id))
-// 		case "twittertimeseries":
-// 			return api.BaseSingleResponseHandler(controllers.GetTwitterTimeseriesForList(c, r, id))
-// 		case "instagramtimeseries":
-// 			return api.BaseSingleResponseHandler(controllers.GetInstagramTimeseriesForList(c, r, id))
-// 		case "duplicate":
-// 			return api.BaseSingleResponseHandler(controllers.DuplicateList(c, r, id))
-// 		}
-// 	}
-// 	return nil, errors.New("method not implemented")
-// }
-
-// func handleMediaList(c context.Context, r *http.Request, id string) (interface{}, error) {
-// 	switch r.Method {
-// 	case "GET":
-// 		if id == "archived" {
-// 			val, included, count, total, err := controllers.GetMediaLists(c, r, true)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		} else if id == "clients" {
-// 			val, included, count, total, err := controllers.GetMediaListsClients(c, r)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		} else if id == "public" {
-// 			val, included, count, total, err := controllers.GetPublicMediaLists(c, r)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		} else if id == "team" {
-// 			val, included, count, total, err := controllers.GetTeamMediaLists(c, r)
-// 			return api.BaseResponseHandler(val, included, count, total, err, r)
-// 		}
-// 		return api.BaseSingleResponseHandler(controllers.GetMediaList(c, r, id))
-// 	case "PATCH":
-// 		return api.BaseSingleResponseHandler(controllers.UpdateMediaList(c, r, id))
-// 	case "DELETE":
-// 		return api.BaseSingleResponseHandler(controllers.DeleteMediaList(c, r, id))
-// 	}
-// 	return nil, errors.New("method not implemented")
-// }
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+	// "github.com/news-ai/tabulae-v1/files"
+	"github.com/news-ai/tabulae-v1/models"
+	"github.com/news-ai/tabulae-v1/parse"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errMediaListHandling = "Media List handling error"
+
+	// Contacts read at a time when exporting a list
+	listExportBatchSize = 500
+)
+
+func handleMediaListActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		switch action {
+		case "contacts":
+			val, included, count, total, err := controllers.GetContactsForList(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "verify":
+			return api.BaseSingleResponseHandler(controllers.GetEmailVerificationForList(r, id))
+			// case "headlines":
+			// 	val, included, count, total, err := controllers.GetHeadlinesForList(c, r, id)
+			// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+			// case "tweets":
+			// 	val, included, count, total, err := controllers.GetTweetsForList(c, r, id)
+			// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+			// case "feed":
+			// 	val, included, count, total, err := controllers.GetFeedForList(c, r, id)
+			// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+			// case "emails":
+			// 	val, included, count, total, err := controllers.GetEmailsForList(c, r, id)
+			// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+			// case "public":
+			// 	return api.BaseSingleResponseHandler(controllers.UpdateMediaListToPublic(c, r, id))
+			// case "resync":
+			// 	return api.BaseSingleResponseHandler(controllers.ReSyncMediaList(c, r, id))
+		}
+	case "POST":
+		switch action {
+		case "refresh":
+			return api.BaseSingleResponseHandler(controllers.RefreshSmartMediaList(r, id))
+		// case "upload":
+		// 	return api.BaseSingleResponseHandler(files.HandleMediaListActionUpload(c, r, id))
+		// case "twittertimeseries":
+		// 	return api.BaseSingleResponseHandler(controllers.GetTwitterTimeseriesForList(c, r, id))
+		// case "instagramtimeseries":
+		// 	return api.BaseSingleResponseHandler(controllers.GetInstagramTimeseriesForList(c, r, id))
+		// case "duplicate":
+		// 	return api.BaseSingleResponseHandler(controllers.DuplicateList(c, r, id))
+		case "engagement":
+			val, included, count, total, err := controllers.CalculateEngagementForList(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "verify":
+			return api.BaseSingleResponseHandler(controllers.VerifyEmailsForList(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleMediaList(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		// if id == "archived" {
+		// 	val, included, count, total, err := controllers.GetMediaLists(c, r, true)
+		// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+		// } else if id == "clients" {
+		// 	val, included, count, total, err := controllers.GetMediaListsClients(c, r)
+		// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+		// } else if id == "public" {
+		// 	val, included, count, total, err := controllers.GetPublicMediaLists(c, r)
+		// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+		// } else if id == "team" {
+		// 	val, included, count, total, err := controllers.GetTeamMediaLists(c, r)
+		// 	return api.BaseResponseHandler(val, included, count, total, err, r)
+		// }
+		return api.BaseSingleResponseHandler(controllers.GetMediaList(r, id))
+		// case "PATCH":
+		// 	return api.BaseSingleResponseHandler(controllers.UpdateMediaList(c, r, id))
+		// case "DELETE":
+		// 	return api.BaseSingleResponseHandler(controllers.DeleteMediaList(c, r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
 
 // func handleMediaLists(c context.Context, w http.ResponseWriter, r *http.Request) (interface{}, error) {
 // 	switch r.Method {
@@ -109,37 +125,115 @@ package routes
 // 	return
 // }
 
-// // Handler for when there is a key present after /users/<id> route.
-// func MediaListHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-// 	w.Header().Set("Content-Type", "application/json")
-// 	c := appengine.NewContext(r)
-// 	id := ps.ByName("id")
-// 	val, err := handleMediaList(c, r, id)
-
-// 	if err == nil {
-// 		err = ffjson.NewEncoder(w).Encode(val)
-// 	}
-
-// 	if err != nil {
-// 		nError.ReturnError(w, http.StatusInternalServerError, errMediaListHandling, err.Error())
-// 	}
-// 	return
-// }
-
-// // Handler for when the user wants to perform an action on the lists
-// func MediaListActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-// 	w.Header().Set("Content-Type", "application/json")
-// 	c := appengine.NewContext(r)
-// 	id := ps.ByName("id")
-// 	action := ps.ByName("action")
-
-// 	val, err := handleMediaListActions(c, r, id, action)
-// 	if err == nil {
-// 		err = ffjson.NewEncoder(w).Encode(val)
-// 	}
-
-// 	if err != nil {
-// 		nError.ReturnError(w, http.StatusInternalServerError, errMediaListHandling, err.Error())
-// 	}
-// 	return
-// }
+// Handler for when there is a key present after /lists/<id> route.
+func MediaListHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleMediaList(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errMediaListHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when the user wants to perform an action on the lists
+func MediaListActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleMediaListActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errMediaListHandling, err.Error())
+	}
+	return
+}
+
+// Handler for exporting the contacts of a list as vCards
+func MediaListVCardHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	var buf bytes.Buffer
+	_, contacts, publications, err := controllers.GetAllContactsForList(r, id)
+	if err == nil {
+		err = parse.WriteVCards(&buf, contacts, publications)
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		nError.ReturnError(w, http.StatusInternalServerError, errMediaListHandling, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/vcard; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"list-"+id+".vcf\"")
+	buf.WriteTo(w)
+	return
+}
+
+// Exports the list with its columns in FieldsMap order as csv, json or xlsx,
+// set with ?format=. Hidden columns are included with ?hidden=true. The file
+// is streamed, so errors after the first contacts are written can only be
+// logged.
+func MediaListExportHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	contentType, ok := parse.ListExportFormats[format]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		nError.ReturnError(w, http.StatusBadRequest, errMediaListHandling, "Export format has to be one of csv, json or xlsx")
+		return
+	}
+
+	mediaList, _, err := controllers.GetMediaList(r, id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		nError.ReturnError(w, http.StatusInternalServerError, errMediaListHandling, err.Error())
+		return
+	}
+
+	columns := parse.ListExportColumns(mediaList, r.URL.Query().Get("hidden") == "true")
+
+	// Read only values are only looked up for columns that aren't hidden
+	exportList := mediaList
+	exportList.FieldsMap = make([]models.CustomFieldsMap, len(columns))
+	for i := 0; i < len(columns); i++ {
+		exportList.FieldsMap[i] = columns[i]
+		exportList.FieldsMap[i].Hidden = false
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\"list-"+id+"."+format+"\"")
+
+	listWriter, err := parse.NewListWriter(format, w, columns)
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+
+	err = controllers.ForEachContactInList(r, exportList, listExportBatchSize, listWriter.WriteContacts)
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+
+	err = listWriter.Close()
+	if err != nil {
+		log.Printf("%v", err)
+	}
+	return
+}
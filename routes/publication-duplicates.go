@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errPublicationDuplicateHandling = "Publication duplicate handling error"
+)
+
+func handlePublicationDuplicateActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "POST":
+		switch action {
+		case "merge":
+			return api.BaseSingleResponseHandler(controllers.MergePublicationDuplicate(r, id))
+		case "dismiss":
+			return api.BaseSingleResponseHandler(controllers.DismissPublicationDuplicate(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handlePublicationDuplicates(r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		val, included, count, total, err := controllers.GetPublicationDuplicates(r)
+		return api.BaseResponseHandler(val, included, count, total, err, r)
+	case "POST":
+		val, included, count, total, err := controllers.ScanPublicationDuplicates(r)
+		return api.BaseResponseHandler(val, included, count, total, err, r)
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when an admin wants the queue of publications that look like
+// the same one, or to look for new ones
+func PublicationDuplicatesHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	val, err := handlePublicationDuplicates(r)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errPublicationDuplicateHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when an admin merges or dismisses a pair from the queue
+func PublicationDuplicateActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handlePublicationDuplicateActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errPublicationDuplicateHandling, err.Error())
+	}
+	return
+}
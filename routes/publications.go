@@ -24,6 +24,27 @@ func handlePublicationActions(r *http.Request, id string, action string) (interf
 			return api.BaseSingleResponseHandler(controllers.GetEnrichCompanyProfile(r, id))
 		case "verify":
 			return api.BaseSingleResponseHandler(controllers.VerifyPublication(r, id))
+		case "tree":
+			return api.BaseSingleResponseHandler(controllers.GetPublicationTree(id))
+		case "contacts":
+			val, included, count, total, err := controllers.GetContactsForPublication(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "feed-candidates":
+			val, included, count, total, err := controllers.GetFeedCandidatesForPublication(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		}
+	case "POST":
+		switch action {
+		case "merge":
+			return api.BaseSingleResponseHandler(controllers.MergePublications(r, id))
+		case "database-profile":
+			return api.BaseSingleResponseHandler(controllers.ApplyCompanyProfile(r, id))
+		case "discover-feeds":
+			val, included, count, total, err := controllers.DiscoverFeedsForPublication(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		case "attribute-headlines":
+			val, included, count, total, err := controllers.AttributeHeadlinesForPublication(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
 		}
 	}
 	return nil, errors.New("method not implemented")
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pquerna/ffjson/ffjson"
+
+	"github.com/news-ai/tabulae-v1/controllers"
+
+	"github.com/news-ai/web/api"
+	nError "github.com/news-ai/web/errors"
+)
+
+var (
+	errSavedSearchHandling = "Saved Search handling error"
+)
+
+func handleSavedSearchActions(r *http.Request, id string, action string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		switch action {
+		case "contacts":
+			val, included, count, total, err := controllers.GetContactsForSavedSearch(r, id)
+			return api.BaseResponseHandler(val, included, count, total, err, r)
+		}
+	case "POST":
+		switch action {
+		case "list":
+			return api.BaseSingleResponseHandler(controllers.CreateSmartMediaList(r, id))
+		}
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleSavedSearch(r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		return api.BaseSingleResponseHandler(controllers.GetSavedSearch(r, id))
+	case "PATCH":
+		return api.BaseSingleResponseHandler(controllers.UpdateSavedSearch(r, id))
+	case "DELETE":
+		return api.BaseSingleResponseHandler(controllers.DeleteSavedSearch(r, id))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+func handleSavedSearches(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		val, included, count, total, err := controllers.GetSavedSearches(r)
+		return api.BaseResponseHandler(val, included, count, total, err, r)
+	case "POST":
+		return api.BaseSingleResponseHandler(controllers.CreateSavedSearch(r))
+	}
+	return nil, errors.New("method not implemented")
+}
+
+// Handler for when the user wants all the saved searches.
+func SavedSearchesHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	val, err := handleSavedSearches(w, r)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errSavedSearchHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when there is a key present after /savedsearches/<id> route.
+func SavedSearchHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	val, err := handleSavedSearch(r, id)
+
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errSavedSearchHandling, err.Error())
+	}
+	return
+}
+
+// Handler for when the user wants to perform an action on a saved search
+func SavedSearchActionHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	id := ps.ByName("id")
+	action := ps.ByName("action")
+
+	val, err := handleSavedSearchActions(r, id, action)
+	if err == nil {
+		err = ffjson.NewEncoder(w).Encode(val)
+	}
+
+	if err != nil {
+		nError.ReturnError(w, http.StatusInternalServerError, errSavedSearchHandling, err.Error())
+	}
+	return
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/feeds"
+	"github.com/news-ai/tabulae-v1/files"
+	"github.com/news-ai/tabulae-v1/migrations"
+)
+
+// Gets the database and background work ready for the routes. The server
+// calls it once after connecting to the database and before serving.
+func Setup() error {
+	err := migrations.Run()
+	if err != nil {
+		return err
+	}
+
+	files.StartImportWorkers()
+	feeds.StartFeedPollers()
+	controllers.StartSmartListRefresher()
+	return nil
+}
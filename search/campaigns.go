@@ -4,22 +4,18 @@ import (
 	"log"
 	"math"
 	"net/http"
-
-	gcontext "github.com/gorilla/context"
+	"strconv"
 
 	"github.com/news-ai/api-v1/models"
-	apiSearch "github.com/news-ai/api-v1/search"
 
 	tabulaeModels "github.com/news-ai/tabulae-v1/models"
-
-	elastic "github.com/news-ai/elastic-appengine"
-)
-
-var (
-	elasticEmailCampaign *elastic.Elastic
 )
 
 type EmailCampaignResponse struct {
+	CampaignId int64  `json:"campaignid"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+
 	Date        string `json:"date"`
 	Subject     string `json:"subject"`
 	UserId      string `json:"userid"`
@@ -38,189 +34,117 @@ type EmailCampaignResponse struct {
 	Show        bool `json:"show"`
 }
 
-type EmailCampaignRequest struct {
-	Id string `json:"id"`
-
-	Date        string `json:"date"`
-	Subject     string `json:"subject"`
-	UserId      string `json:"userid"`
-	BaseSubject string `json:"baseSubject"`
-}
+func addEmailToCampaignResponse(emailCampaign *EmailCampaignResponse, email tabulaeModels.Email) {
+	if email.Archived {
+		return
+	}
 
-func (ec *EmailCampaignRequest) FillStruct(m map[string]interface{}) error {
-	for k, v := range m {
-		err := models.SetField(ec, k, v)
-		if err != nil {
-			return err
+	// Emails that never made it to the provider do not count towards the campaign
+	if email.Opened == 0 {
+		if email.Method == "sendgrid" && email.SendGridId == "" {
+			return
+		} else if email.Method == "gmail" && email.GmailId == "" {
+			return
 		}
 	}
 
-	return nil
-}
+	emailCampaign.Delivered += 1
+	emailCampaign.Opens += email.Opened
+	emailCampaign.Clicks += email.Clicked
 
-func searchEmailCampaigns(r *http.Request, elasticQuery interface{}, user models.User) (interface{}, int, int, error) {
-	hits, err := elasticEmailCampaign.QueryStruct(elasticQuery)
-	if err != nil {
-		log.Printf("%v", err)
-		return nil, 0, 0, err
+	if email.Opened > 0 {
+		emailCampaign.UniqueOpens += 1
 	}
 
-	// Get all email campaigns
-	emailCampaigns := []EmailCampaignRequest{}
-	for i := 0; i < len(hits.Hits); i++ {
-		rawEmailCampaign := hits.Hits[i].Source.Data
-		rawMap := rawEmailCampaign.(map[string]interface{})
-		emailCampaign := EmailCampaignRequest{}
-		err := emailCampaign.FillStruct(rawMap)
-		if err != nil {
-			log.Printf("%v", err)
-			continue
-		}
-
-		emailCampaign.Id = hits.Hits[i].ID
-		emailCampaigns = append(emailCampaigns, emailCampaign)
+	if email.Clicked > 0 {
+		emailCampaign.UniqueClicks += 1
 	}
 
-	// Get all emails for each of the campaigns
-	emailCampaignsResponse := []EmailCampaignResponse{}
-	for i := 0; i < len(emailCampaigns); i++ {
-		emailCampaign := EmailCampaignResponse{}
-		limit := 750
-		emails, _, total, err := SearchEmailsByDateAndSubject(r, user, emailCampaigns[i].Date, emailCampaigns[i].Subject, emailCampaigns[i].BaseSubject, 0, limit)
-
-		if err != nil {
-			log.Printf("%v", err)
-			continue
-		}
-
-		if emailCampaigns[i].BaseSubject != "" {
-			log.Printf("%v", len(emails))
-		}
-
-		emailCampaign.Date = emailCampaigns[i].Date
-		emailCampaign.UserId = emailCampaigns[i].UserId
-		emailCampaign.Subject = emailCampaigns[i].Subject
-		emailCampaign.BaseSubject = emailCampaigns[i].BaseSubject
-
-		for x := 0; x < len(emails); x++ {
-			emailSubject := emails[x].Subject
-			if emails[x].BaseSubject != "" {
-				emailSubject = emails[x].BaseSubject
-			}
+	if email.Bounced {
+		emailCampaign.Bounces += 1
+	}
+}
 
-			if emails[x].Opened == 0 {
-				if emails[x].Method == "sendgrid" && emails[x].SendGridId == "" {
-					continue
-				} else if emails[x].Method == "gmail" && emails[x].GmailId == "" {
-					continue
-				}
+func calculateCampaignPercentages(emailCampaign *EmailCampaignResponse) {
+	if emailCampaign.Delivered > 0 {
+		deliveredNumber := emailCampaign.Delivered - emailCampaign.Bounces
+		if deliveredNumber > 0 {
+			// For some reason if more people opened it then the number of
+			// emails that were delivered then we set a ceiling of 100%
+			if emailCampaign.UniqueOpens > deliveredNumber {
+				emailCampaign.UniqueOpens = deliveredNumber
 			}
 
-			if emailSubject == emailCampaign.Subject && !emails[x].Archived {
-				if emailCampaign.Subject == "" {
-					emailCampaign.Subject = "(no subject)"
-				}
+			emailCampaign.UniqueOpensPercentage = 100 * float32(float32(emailCampaign.UniqueOpens)/float32(deliveredNumber))
+			emailCampaign.UniqueClicksPercentage = 100 * float32(float32(emailCampaign.UniqueClicks)/float32(deliveredNumber))
+			emailCampaign.Show = true
+		}
+	}
+}
 
-				emailCampaign.Delivered += 1
-				emailCampaign.Opens += emails[x].Opened
-				emailCampaign.Clicks += emails[x].Clicked
+func SearchEmailCampaignStats(user models.User, campaign tabulaeModels.Campaign) (EmailCampaignResponse, error) {
+	emailCampaign := EmailCampaignResponse{}
+	emailCampaign.CampaignId = campaign.Id
+	emailCampaign.Name = campaign.Name
+	emailCampaign.Status = campaign.Status
+	emailCampaign.UserId = strconv.FormatInt(campaign.CreatedBy, 10)
+	emailCampaign.Subject = campaign.Subject
+	emailCampaign.BaseSubject = campaign.Subject
+	emailCampaign.IsScheduled = campaign.Status == tabulaeModels.CampaignStatusScheduled
+
+	emailCampaign.Date = campaign.Created.Format("2006-01-02")
+	if !campaign.SendAt.IsZero() {
+		emailCampaign.Date = campaign.SendAt.Format("2006-01-02")
+	}
 
-				if emails[x].Opened > 0 {
-					emailCampaign.UniqueOpens += 1
-				}
+	if emailCampaign.Subject == "" {
+		emailCampaign.Subject = "(no subject)"
+	}
 
-				if emails[x].Clicked > 0 {
-					emailCampaign.UniqueClicks += 1
-				}
+	limit := 750
+	emails, _, total, err := SearchEmailsByCampaignId(user, campaign.Id, 0, limit)
+	if err != nil {
+		log.Printf("%v", err)
+		return EmailCampaignResponse{}, err
+	}
 
-				if emails[x].Bounced {
-					emailCampaign.Bounces += 1
-				}
-			}
-		}
+	for x := 0; x < len(emails); x++ {
+		addEmailToCampaignResponse(&emailCampaign, emails[x])
+	}
 
-		emails = []tabulaeModels.Email{}
-
-		// If we have to loop through
-		if total > limit {
-			loops := int(math.Ceil(float64(total) / float64(limit)))
-			for x := 1; x < loops; x++ {
-				additionalEmails, _, _, err := SearchEmailsByDateAndSubject(r, user, emailCampaigns[i].Date, emailCampaigns[i].Subject, emailCampaigns[i].BaseSubject, limit*x, limit)
-				if err != nil {
-					log.Printf("%v", err)
-					continue
-				}
-
-				for y := 0; y < len(additionalEmails); y++ {
-					emailSubject := additionalEmails[y].Subject
-					if additionalEmails[y].BaseSubject != "" {
-						emailSubject = additionalEmails[y].BaseSubject
-					}
-					if emailSubject == emailCampaign.Subject && !additionalEmails[y].Archived {
-						if emailCampaign.Subject == "" {
-							emailCampaign.Subject = "(no subject)"
-						}
-
-						emailCampaign.Delivered += 1
-						emailCampaign.Opens += additionalEmails[y].Opened
-						emailCampaign.Clicks += additionalEmails[y].Clicked
-
-						if additionalEmails[y].Opened > 0 {
-							emailCampaign.UniqueOpens += 1
-						}
-
-						if additionalEmails[y].Clicked > 0 {
-							emailCampaign.UniqueClicks += 1
-						}
-
-						if additionalEmails[y].Bounced {
-							emailCampaign.Bounces += 1
-						}
-					}
-				}
-				additionalEmails = []tabulaeModels.Email{}
+	// If we have to loop through
+	if total > limit {
+		loops := int(math.Ceil(float64(total) / float64(limit)))
+		for x := 1; x < loops; x++ {
+			additionalEmails, _, _, err := SearchEmailsByCampaignId(user, campaign.Id, limit*x, limit)
+			if err != nil {
+				log.Printf("%v", err)
+				continue
 			}
-		}
 
-		if emailCampaign.Delivered > 0 {
-			deliveredNumber := emailCampaign.Delivered - emailCampaign.Bounces
-			log.Printf("%v", deliveredNumber)
-			if deliveredNumber > 0 {
-				// For some reason if more people opened it then the number of
-				// emails that were delivered then we set a ceiling of 100%
-				if emailCampaign.UniqueOpens > deliveredNumber {
-					emailCampaign.UniqueOpens = deliveredNumber
-				}
-
-				emailCampaign.UniqueOpensPercentage = 100 * float32(float32(emailCampaign.UniqueOpens)/float32(deliveredNumber))
-				emailCampaign.UniqueClicksPercentage = 100 * float32(float32(emailCampaign.UniqueClicks)/float32(deliveredNumber))
-				emailCampaign.Show = true
+			for y := 0; y < len(additionalEmails); y++ {
+				addEmailToCampaignResponse(&emailCampaign, additionalEmails[y])
 			}
 		}
-
-		emailCampaignsResponse = append(emailCampaignsResponse, emailCampaign)
 	}
 
-	return emailCampaignsResponse, len(emailCampaignsResponse), hits.Total, nil
+	calculateCampaignPercentages(&emailCampaign)
+	return emailCampaign, nil
 }
 
-func SearchEmailCampaignsByDate(r *http.Request, user models.User) (interface{}, int, int, error) {
-	offset := gcontext.Get(r, "offset").(int)
-	limit := gcontext.Get(r, "limit").(int)
-
-	elasticQuery := elastic.ElasticQueryWithSort{}
-	elasticQuery.Size = limit
-	elasticQuery.From = offset
-
-	elasticUserIdQuery := apiSearch.ElasticUserIdQuery{}
-	elasticUserIdQuery.Term.UserId = user.Id
-
-	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticUserIdQuery)
+// Campaigns are expected to be ordered by date. Stats are computed from the
+// emails that reference each campaign.
+func SearchEmailCampaignsByDate(r *http.Request, user models.User, campaigns []tabulaeModels.Campaign, total int) (interface{}, int, int, error) {
+	emailCampaignsResponse := []EmailCampaignResponse{}
+	for i := 0; i < len(campaigns); i++ {
+		emailCampaign, err := SearchEmailCampaignStats(user, campaigns[i])
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
 
-	elasticDateQuery := apiSearch.ElasticSortDataQuery{}
-	elasticDateQuery.Date.Order = "desc"
-	elasticDateQuery.Date.Mode = "avg"
-	elasticQuery.Sort = append(elasticQuery.Sort, elasticDateQuery)
+		emailCampaignsResponse = append(emailCampaignsResponse, emailCampaign)
+	}
 
-	return searchEmailCampaigns(r, elasticQuery, user)
+	return emailCampaignsResponse, len(emailCampaignsResponse), total, nil
 }
@@ -48,10 +48,4 @@ func InitializeElasticSearch() {
 	emailsElastic.Index = "emails2"
 	emailsElastic.Type = "email"
 	elasticEmails = &emailsElastic
-
-	emailsElasticCampaign := elastic.Elastic{}
-	emailsElasticCampaign.BaseURL = search.NewBaseURL
-	emailsElasticCampaign.Index = "emails"
-	emailsElasticCampaign.Type = "campaign1"
-	elasticEmailCampaign = &emailsElasticCampaign
 }
@@ -1,10 +1,12 @@
 package search
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	// "net/url"
-	// "strconv"
 
 	gcontext "github.com/gorilla/context"
 	elastic "github.com/news-ai/elastic-appengine"
@@ -15,6 +17,18 @@ import (
 	"github.com/news-ai/tabulae-v1/models"
 )
 
+type elasticBeatsTermsQuery struct {
+	Terms struct {
+		Beats []int64 `json:"data.Beats"`
+	} `json:"terms"`
+}
+
+type elasticEmployersTermsQuery struct {
+	Terms struct {
+		Employers []int64 `json:"data.Employers"`
+	} `json:"terms"`
+}
+
 var (
 	elasticContact *elastic.Elastic
 )
@@ -44,14 +58,7 @@ func searchContact(elasticQuery elastic.ElasticQuery) ([]models.Contact, int, er
 	return contacts, hits.Total, nil
 }
 
-func SearchContacts(r *http.Request, search string, userId int64) ([]models.Contact, int, error) {
-	if userId == 0 || search == "" {
-		return []models.Contact{}, 0, nil
-	}
-
-	offset := gcontext.Get(r, "offset").(int)
-	limit := gcontext.Get(r, "limit").(int)
-
+func contactsByAllQuery(search string, userId int64, offset int, limit int) elastic.ElasticQuery {
 	elasticQuery := elastic.ElasticQuery{}
 	elasticQuery.Size = limit
 	elasticQuery.From = offset
@@ -65,7 +72,101 @@ func SearchContacts(r *http.Request, search string, userId int64) ([]models.Cont
 	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
 	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticMatchQuery)
 
-	return searchContact(elasticQuery)
+	return elasticQuery
+}
+
+func contactsByTagQuery(tag string, userId int64, offset int, limit int) elastic.ElasticQuery {
+	elasticQuery := elastic.ElasticQuery{}
+	elasticQuery.Size = limit
+	elasticQuery.From = offset
+
+	elasticCreatedByQuery := apiSearch.ElasticCreatedByQuery{}
+	elasticCreatedByQuery.Term.CreatedBy = userId
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
+
+	elasticTagQuery := apiSearch.ElasticTagQuery{}
+	elasticTagQuery.Term.Tag = tag
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticTagQuery)
+
+	return elasticQuery
+}
+
+// Contacts working at any of the publications
+func contactsByPublicationIdQuery(publicationIds []int64, userId int64, offset int, limit int) elastic.ElasticQuery {
+	elasticQuery := elastic.ElasticQuery{}
+	elasticQuery.Size = limit
+	elasticQuery.From = offset
+
+	elasticCreatedByQuery := apiSearch.ElasticCreatedByQuery{}
+	elasticCreatedByQuery.Term.CreatedBy = userId
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
+
+	elasticEmployersQuery := elasticEmployersTermsQuery{}
+	elasticEmployersQuery.Terms.Employers = publicationIds
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticEmployersQuery)
+
+	return elasticQuery
+}
+
+// A publication stands for itself and its sections and editions
+func publicationIdsWithChildren(publicationId string) ([]int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(publicationId), 10, 64)
+	if err != nil {
+		return []int64{}, errors.New("Publication has to be given by its id")
+	}
+
+	publicationIds, err := models.PublicationWithDescendants(id)
+	if err != nil {
+		log.Printf("%v", err)
+		return []int64{}, err
+	}
+	return publicationIds, nil
+}
+
+// Contacts on any of the beats, optionally matching a full text query as well
+func contactsByBeatQuery(beatIds []int64, search string, userId int64, offset int, limit int) elastic.ElasticQuery {
+	elasticQuery := elastic.ElasticQuery{}
+	elasticQuery.Size = limit
+	elasticQuery.From = offset
+
+	elasticCreatedByQuery := apiSearch.ElasticCreatedByQuery{}
+	elasticCreatedByQuery.Term.CreatedBy = userId
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
+
+	elasticBeatsQuery := elasticBeatsTermsQuery{}
+	elasticBeatsQuery.Terms.Beats = beatIds
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticBeatsQuery)
+
+	if search != "" {
+		elasticMatchQuery := elastic.ElasticMatchQuery{}
+		elasticMatchQuery.Match.All = search
+		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticMatchQuery)
+	}
+
+	return elasticQuery
+}
+
+// Beats are given by id, slug or a path like "tech/ai", and include the
+// beats under them. Anything after the beat is full text, so "tech/ai london"
+// is the AI beat under Tech along with the text "london".
+func beatIdsAndText(query string) ([]int64, string, error) {
+	taxonomy, err := models.GetBeatTaxonomy()
+	if err != nil {
+		log.Printf("%v", err)
+		return []int64{}, "", err
+	}
+	return taxonomy.FindInQuery(query)
+}
+
+func SearchContacts(r *http.Request, search string, userId int64) ([]models.Contact, int, error) {
+	if userId == 0 || search == "" {
+		return []models.Contact{}, 0, nil
+	}
+
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	return searchContact(contactsByAllQuery(search, userId, offset, limit))
 }
 
 func SearchContactsByList(r *http.Request, search string, user apiModels.User, userId int64, listId int64) ([]models.Contact, int, error) {
@@ -110,19 +211,7 @@ func SearchContactsByTag(r *http.Request, tag string, userId int64) ([]models.Co
 	offset := gcontext.Get(r, "offset").(int)
 	limit := gcontext.Get(r, "limit").(int)
 
-	elasticQuery := elastic.ElasticQuery{}
-	elasticQuery.Size = limit
-	elasticQuery.From = offset
-
-	elasticCreatedByQuery := apiSearch.ElasticCreatedByQuery{}
-	elasticCreatedByQuery.Term.CreatedBy = userId
-	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
-
-	elasticTagQuery := apiSearch.ElasticTagQuery{}
-	elasticTagQuery.Term.Tag = tag
-	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticTagQuery)
-
-	return searchContact(elasticQuery)
+	return searchContact(contactsByTagQuery(tag, userId, offset, limit))
 }
 
 func SearchContactsByPublicationId(r *http.Request, publicationId string, userId int64) ([]models.Contact, int, error) {
@@ -130,22 +219,31 @@ func SearchContactsByPublicationId(r *http.Request, publicationId string, userId
 		return []models.Contact{}, 0, nil
 	}
 
+	publicationIds, err := publicationIdsWithChildren(publicationId)
+	if err != nil {
+		return []models.Contact{}, 0, err
+	}
+
 	offset := gcontext.Get(r, "offset").(int)
 	limit := gcontext.Get(r, "limit").(int)
 
-	elasticQuery := elastic.ElasticQuery{}
-	elasticQuery.Size = limit
-	elasticQuery.From = offset
+	return searchContact(contactsByPublicationIdQuery(publicationIds, userId, offset, limit))
+}
 
-	elasticCreatedByQuery := apiSearch.ElasticCreatedByQuery{}
-	elasticCreatedByQuery.Term.CreatedBy = userId
-	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
+func SearchContactsByBeat(r *http.Request, beat string, userId int64) ([]models.Contact, int, error) {
+	if beat == "" {
+		return []models.Contact{}, 0, nil
+	}
 
-	elasticEmployersQuery := apiSearch.ElasticEmployersQuery{}
-	elasticEmployersQuery.Term.Employers = publicationId
-	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticEmployersQuery)
+	beatIds, text, err := beatIdsAndText(beat)
+	if err != nil {
+		return []models.Contact{}, 0, err
+	}
 
-	return searchContact(elasticQuery)
+	offset := gcontext.Get(r, "offset").(int)
+	limit := gcontext.Get(r, "limit").(int)
+
+	return searchContact(contactsByBeatQuery(beatIds, text, userId, offset, limit))
 }
 
 func SearchContactsByFieldSelector(r *http.Request, fieldSelector string, query string, userId int64) ([]models.Contact, int, error) {
@@ -153,7 +251,43 @@ func SearchContactsByFieldSelector(r *http.Request, fieldSelector string, query
 		return SearchContactsByTag(r, query, userId)
 	} else if fieldSelector == "publication" {
 		return SearchContactsByPublicationId(r, query, userId)
+	} else if fieldSelector == "beat" {
+		return SearchContactsByBeat(r, query, userId)
 	}
 
 	return []models.Contact{}, 0, nil
 }
+
+// Saved searches use the same syntax as the contacts "q" parameter: either a
+// full text query or a "fieldselector:query" pair. "beat:tech london" gets
+// the London contacts on the Tech beat. They are not bound to the
+// request's pagination since smart lists need to page through every result.
+func SearchContactsBySavedSearch(query string, userId int64, offset int, limit int) ([]models.Contact, int, error) {
+	if userId == 0 || query == "" {
+		return []models.Contact{}, 0, nil
+	}
+
+	fieldSelector := strings.Split(query, ":")
+	if len(fieldSelector) != 2 {
+		return searchContact(contactsByAllQuery(query, userId, offset, limit))
+	}
+
+	switch fieldSelector[0] {
+	case "tag":
+		return searchContact(contactsByTagQuery(fieldSelector[1], userId, offset, limit))
+	case "publication":
+		publicationIds, err := publicationIdsWithChildren(fieldSelector[1])
+		if err != nil {
+			return []models.Contact{}, 0, err
+		}
+		return searchContact(contactsByPublicationIdQuery(publicationIds, userId, offset, limit))
+	case "beat":
+		beatIds, text, err := beatIdsAndText(fieldSelector[1])
+		if err != nil {
+			return []models.Contact{}, 0, err
+		}
+		return searchContact(contactsByBeatQuery(beatIds, text, userId, offset, limit))
+	}
+
+	return []models.Contact{}, 0, errors.New("Invalid saved search field selector")
+}
@@ -0,0 +1,290 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/news-ai/web/utilities"
+)
+
+// EmailQuery is the parsed form of the "q" parameter on the email search.
+//
+// A query is a list of terms separated by whitespace or commas. A term is
+// either free text or a "field:value" pair, and values can be quoted to keep
+// whitespace, commas and colons ("subject:\"Hello, world\"").
+//
+// Supported fields:
+//
+//	subject:     exact subject
+//	baseSubject: exact subject before templating
+//	to:          recipient email, can be repeated
+//	list:        media list id, can be repeated
+//	status:      opened, clicked, bounced, unopened or unclicked, can be repeated
+//	filter:      alias of status
+//	date:        YYYY-MM-DD, or a range YYYY-MM-DD..YYYY-MM-DD where either side
+//	             can be left open
+type EmailQuery struct {
+	Text        string
+	Subject     string
+	BaseSubject string
+	To          []string
+	ListIds     []int64
+	Statuses    []string
+	DateFrom    string
+	DateTo      string
+}
+
+type emailQueryToken struct {
+	Field    string
+	Value    string
+	Position int
+}
+
+var emailQueryFields = map[string]bool{
+	"subject":     true,
+	"basesubject": true,
+	"to":          true,
+	"list":        true,
+	"status":      true,
+	"filter":      true,
+	"date":        true,
+}
+
+var emailQueryStatuses = map[string]string{
+	"open":      "open",
+	"opened":    "open",
+	"click":     "click",
+	"clicked":   "click",
+	"bounce":    "bounce",
+	"bounced":   "bounce",
+	"unopen":    "unopen",
+	"unopened":  "unopen",
+	"unclick":   "unclick",
+	"unclicked": "unclick",
+}
+
+const emailQueryDateLayout = "2006-01-02"
+
+/*
+* Private methods
+ */
+
+func isEmailQuerySeparator(c rune) bool {
+	return c == ',' || unicode.IsSpace(c)
+}
+
+// Reads a possibly quoted value starting at position i. Returns the value and
+// the position right after it.
+func readEmailQueryValue(query []rune, i int) (string, int, error) {
+	if i >= len(query) || query[i] != '"' {
+		start := i
+		for i < len(query) && !isEmailQuerySeparator(query[i]) {
+			i++
+		}
+		return string(query[start:i]), i, nil
+	}
+
+	start := i
+	value := []rune{}
+	i++
+	for i < len(query) {
+		switch query[i] {
+		case '\\':
+			if i+1 >= len(query) {
+				return "", i, fmt.Errorf("Search query ends with an unfinished escape at position %d", i+1)
+			}
+			value = append(value, query[i+1])
+			i += 2
+		case '"':
+			return string(value), i + 1, nil
+		default:
+			value = append(value, query[i])
+			i++
+		}
+	}
+
+	return "", i, fmt.Errorf("Search query has an unterminated quote starting at position %d", start+1)
+}
+
+// Older clients send every quote escaped (subject:\"Hello, world\"). When a
+// query has no quote that isn't escaped, the escaped ones are read as quotes.
+func unescapeEmailQueryQuotes(query string) string {
+	if strings.Count(query, `"`) == 0 || strings.Count(query, `"`) != strings.Count(query, `\"`) {
+		return query
+	}
+	return strings.Replace(query, `\"`, `"`, -1)
+}
+
+func tokenizeEmailQuery(query string) ([]emailQueryToken, error) {
+	runes := []rune(unescapeEmailQueryQuotes(query))
+	tokens := []emailQueryToken{}
+
+	i := 0
+	for i < len(runes) {
+		if isEmailQuerySeparator(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+
+		// Quoted free text
+		if runes[i] == '"' {
+			value, next, err := readEmailQueryValue(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, emailQueryToken{Value: value, Position: start + 1})
+			i = next
+			continue
+		}
+
+		// Field name, if this term has one
+		j := i
+		for j < len(runes) && !isEmailQuerySeparator(runes[j]) && runes[j] != ':' && runes[j] != '"' {
+			j++
+		}
+
+		if j < len(runes) && runes[j] == ':' && j > i {
+			field := string(runes[i:j])
+			value, next, err := readEmailQueryValue(runes, j+1)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, emailQueryToken{Field: field, Value: value, Position: start + 1})
+			i = next
+			continue
+		}
+
+		value, next, err := readEmailQueryValue(runes, i)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, emailQueryToken{Value: value, Position: start + 1})
+		i = next
+	}
+
+	return tokens, nil
+}
+
+func parseEmailQueryDate(value string, position int) (string, error) {
+	_, err := time.Parse(emailQueryDateLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("Invalid date %q at position %d, expected YYYY-MM-DD", value, position)
+	}
+	return value, nil
+}
+
+/*
+* Public methods
+ */
+
+func ParseEmailQuery(query string) (EmailQuery, error) {
+	emailQuery := EmailQuery{}
+
+	tokens, err := tokenizeEmailQuery(query)
+	if err != nil {
+		return EmailQuery{}, err
+	}
+
+	text := []string{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		// Anything that isn't a known filter ("re:", "http://") is free text
+		if token.Field != "" && !emailQueryFields[strings.ToLower(token.Field)] {
+			token.Value = token.Field + ":" + token.Value
+			token.Field = ""
+		}
+
+		if token.Field == "" {
+			if token.Value != "" {
+				text = append(text, token.Value)
+			}
+			continue
+		}
+
+		value := strings.TrimSpace(token.Value)
+		if value == "" {
+			return EmailQuery{}, fmt.Errorf("Search filter %q at position %d needs a value", token.Field, token.Position)
+		}
+
+		switch strings.ToLower(token.Field) {
+		case "subject":
+			emailQuery.Subject = value
+		case "basesubject":
+			emailQuery.BaseSubject = value
+		case "to":
+			if !utilities.ValidateEmailFormat(value) {
+				return EmailQuery{}, fmt.Errorf("Invalid email address %q at position %d", value, token.Position)
+			}
+			emailQuery.To = append(emailQuery.To, value)
+		case "list":
+			listId, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || listId <= 0 {
+				return EmailQuery{}, fmt.Errorf("Invalid list id %q at position %d", value, token.Position)
+			}
+			emailQuery.ListIds = append(emailQuery.ListIds, listId)
+		case "status", "filter":
+			status, ok := emailQueryStatuses[strings.ToLower(value)]
+			if !ok {
+				return EmailQuery{}, fmt.Errorf("Invalid status %q at position %d, expected opened, clicked, bounced, unopened or unclicked", value, token.Position)
+			}
+			emailQuery.Statuses = append(emailQuery.Statuses, status)
+		case "date":
+			if emailQuery.DateFrom != "" || emailQuery.DateTo != "" {
+				return EmailQuery{}, fmt.Errorf("Only one date filter is allowed, found another at position %d", token.Position)
+			}
+
+			dateRange := strings.SplitN(value, "..", 2)
+			if len(dateRange) == 1 {
+				emailQuery.DateFrom, err = parseEmailQueryDate(value, token.Position)
+				if err != nil {
+					return EmailQuery{}, err
+				}
+				emailQuery.DateTo = emailQuery.DateFrom
+				continue
+			}
+
+			if dateRange[0] == "" && dateRange[1] == "" {
+				return EmailQuery{}, fmt.Errorf("Date range at position %d needs at least a start or an end", token.Position)
+			}
+
+			if dateRange[0] != "" {
+				emailQuery.DateFrom, err = parseEmailQueryDate(dateRange[0], token.Position)
+				if err != nil {
+					return EmailQuery{}, err
+				}
+			}
+
+			if dateRange[1] != "" {
+				emailQuery.DateTo, err = parseEmailQueryDate(dateRange[1], token.Position)
+				if err != nil {
+					return EmailQuery{}, err
+				}
+			}
+
+			// Dates are YYYY-MM-DD so they compare lexicographically
+			if emailQuery.DateFrom != "" && emailQuery.DateTo != "" && emailQuery.DateFrom > emailQuery.DateTo {
+				return EmailQuery{}, fmt.Errorf("Date range at position %d ends before it starts", token.Position)
+			}
+		}
+	}
+
+	emailQuery.Text = strings.Join(text, " ")
+
+	if emailQuery.Text == "" && !emailQuery.HasFields() {
+		return EmailQuery{}, errors.New("Search query is empty")
+	}
+
+	return emailQuery, nil
+}
+
+// Whether the query uses anything other than free text
+func (eq EmailQuery) HasFields() bool {
+	return eq.Subject != "" || eq.BaseSubject != "" || len(eq.To) > 0 || len(eq.ListIds) > 0 || len(eq.Statuses) > 0 || eq.DateFrom != "" || eq.DateTo != ""
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEmailQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  EmailQuery
+	}{
+		{"hello world", EmailQuery{Text: "hello world"}},
+		{`"hello, world"`, EmailQuery{Text: "hello, world"}},
+		{`subject:"Hello, world"`, EmailQuery{Subject: "Hello, world"}},
+		{`subject:\"Hello, world\"`, EmailQuery{Subject: "Hello, world"}},
+		{`date:\"2017-03-01\",subject:\"Launch\"`, EmailQuery{Subject: "Launch", DateFrom: "2017-03-01", DateTo: "2017-03-01"}},
+		{`subject:"say \"hi\""`, EmailQuery{Subject: `say "hi"`}},
+		{"baseSubject:Launch", EmailQuery{BaseSubject: "Launch"}},
+		{"to:a@example.com to:b@example.com", EmailQuery{To: []string{"a@example.com", "b@example.com"}}},
+		{"list:12,list:34", EmailQuery{ListIds: []int64{12, 34}}},
+		{"status:opened filter:bounced", EmailQuery{Statuses: []string{"open", "bounce"}}},
+		{"date:2017-01-01..2017-02-01", EmailQuery{DateFrom: "2017-01-01", DateTo: "2017-02-01"}},
+		{"date:2017-01-01..", EmailQuery{DateFrom: "2017-01-01"}},
+		{"date:..2017-02-01", EmailQuery{DateTo: "2017-02-01"}},
+		{"re: http://example.com", EmailQuery{Text: "re: http://example.com"}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseEmailQuery(test.query)
+		if err != nil {
+			t.Errorf("ParseEmailQuery(%q) returned error: %v", test.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseEmailQuery(%q) = %+v, want %+v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestParseEmailQueryErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ,  ",
+		`subject:"unterminated`,
+		`subject:"ends with \`,
+		"subject:",
+		"to:not-an-email",
+		"list:abc",
+		"list:0",
+		"status:read",
+		"date:2017-13-01",
+		"date:..",
+		"date:2017-02-01..2017-01-01",
+		"date:2017-01-01 date:2017-02-01",
+	}
+
+	for _, query := range tests {
+		_, err := ParseEmailQuery(query)
+		if err == nil {
+			t.Errorf("ParseEmailQuery(%q) returned no error", query)
+		}
+	}
+}
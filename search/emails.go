@@ -16,6 +16,34 @@ import (
 	"github.com/news-ai/web/utilities"
 )
 
+type elasticEmailToTermsQuery struct {
+	Terms struct {
+		To []string `json:"data.To"`
+	} `json:"terms"`
+}
+
+type elasticCampaignIdQuery struct {
+	Term struct {
+		CampaignId int64 `json:"data.CampaignId"`
+	} `json:"term"`
+}
+
+// Either end can be left out for open ended ranges
+type elasticCreatedRangeQuery struct {
+	Range struct {
+		DataCreated struct {
+			From string `json:"from,omitempty"`
+			To   string `json:"to,omitempty"`
+		} `json:"data.Created"`
+	} `json:"range"`
+}
+
+type elasticListIdTermsQuery struct {
+	Terms struct {
+		ListId []int64 `json:"data.ListId"`
+	} `json:"terms"`
+}
+
 var (
 	elasticEmailLog        *elastic.Elastic
 	elasticEmailTimeseries *elastic.Elastic
@@ -168,8 +196,8 @@ func SearchEmailsByQuery(r *http.Request, user apiModels.User, searchQuery strin
 	return searchEmailQuery(elasticQuery)
 }
 
-func SearchEmailsByQueryFields(r *http.Request, user apiModels.User, emailDate string, emailSubject string, emailBaseSubject string, filter string) ([]models.Email, int, int, error) {
-	if emailDate == "" && emailSubject == "" && emailBaseSubject == "" && filter == "" {
+func SearchEmailsByQueryFields(r *http.Request, user apiModels.User, emailQuery EmailQuery) ([]models.Email, int, int, error) {
+	if !emailQuery.HasFields() {
 		return nil, 0, 0, nil
 	}
 
@@ -197,45 +225,106 @@ func SearchEmailsByQueryFields(r *http.Request, user apiModels.User, emailDate s
 	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCancelQuery)
 	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticArchivedQuery)
 
-	if emailDate != "" {
-		elasticCreatedFilterQuery := apiSearch.ElasticCreatedRangeQuery{}
-		elasticCreatedFilterQuery.Range.DataCreated.From = emailDate + "T00:00:00"
-		elasticCreatedFilterQuery.Range.DataCreated.To = emailDate + "T23:59:59"
+	if emailQuery.DateFrom != "" || emailQuery.DateTo != "" {
+		elasticCreatedFilterQuery := elasticCreatedRangeQuery{}
+		if emailQuery.DateFrom != "" {
+			elasticCreatedFilterQuery.Range.DataCreated.From = emailQuery.DateFrom + "T00:00:00"
+		}
+		if emailQuery.DateTo != "" {
+			elasticCreatedFilterQuery.Range.DataCreated.To = emailQuery.DateTo + "T23:59:59"
+		}
 		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedFilterQuery)
 	}
 
-	if emailBaseSubject != "" {
+	if emailQuery.BaseSubject != "" {
 		elasticBaseSubjectQuery := apiSearch.ElasticBaseSubjectQuery{}
-		elasticBaseSubjectQuery.Term.BaseSubject = emailBaseSubject
+		elasticBaseSubjectQuery.Term.BaseSubject = emailQuery.BaseSubject
 		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticBaseSubjectQuery)
-	} else if emailSubject != "" {
+	} else if emailQuery.Subject != "" {
 		elasticSubjectQuery := apiSearch.ElasticSubjectQuery{}
-		elasticSubjectQuery.Term.Subject = emailSubject
+		elasticSubjectQuery.Term.Subject = emailQuery.Subject
 		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticSubjectQuery)
 	}
 
-	if filter == "open" {
-		elasticOpenedRangeQuery := apiSearch.ElasticOpenedRangeQuery{}
-		elasticOpenedRangeQuery.Range.DataOpened.GTE = 1
-		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticOpenedRangeQuery)
-	} else if filter == "click" {
-		elasticClickedRangeQuery := apiSearch.ElasticClickedRangeQuery{}
-		elasticClickedRangeQuery.Range.DataClicked.GTE = 1
-		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticClickedRangeQuery)
-	} else if filter == "bounce" {
-		elasticBounceQuery := apiSearch.ElasticBounceQuery{}
-		elasticBounceQuery.Term.BaseBounced = true
-		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticBounceQuery)
-	} else if filter == "unopen" {
-		elasticOpenedQuery := apiSearch.ElasticBaseOpenedQuery{}
-		elasticOpenedQuery.Term.Opened = 0
-		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticOpenedQuery)
-	} else if filter == "unclick" {
-		elasticClickedQuery := apiSearch.ElasticBaseClickedQuery{}
-		elasticClickedQuery.Term.Clicked = 0
-		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticClickedQuery)
+	// Repeated to: and list: filters match any of their values
+	if len(emailQuery.To) > 0 {
+		elasticEmailToQuery := elasticEmailToTermsQuery{}
+		elasticEmailToQuery.Terms.To = emailQuery.To
+		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticEmailToQuery)
+	}
+
+	if len(emailQuery.ListIds) > 0 {
+		elasticListIdQuery := elasticListIdTermsQuery{}
+		elasticListIdQuery.Terms.ListId = emailQuery.ListIds
+		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticListIdQuery)
 	}
 
+	// Repeated statuses all have to match
+	for i := 0; i < len(emailQuery.Statuses); i++ {
+		switch emailQuery.Statuses[i] {
+		case "open":
+			elasticOpenedRangeQuery := apiSearch.ElasticOpenedRangeQuery{}
+			elasticOpenedRangeQuery.Range.DataOpened.GTE = 1
+			elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticOpenedRangeQuery)
+		case "click":
+			elasticClickedRangeQuery := apiSearch.ElasticClickedRangeQuery{}
+			elasticClickedRangeQuery.Range.DataClicked.GTE = 1
+			elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticClickedRangeQuery)
+		case "bounce":
+			elasticBounceQuery := apiSearch.ElasticBounceQuery{}
+			elasticBounceQuery.Term.BaseBounced = true
+			elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticBounceQuery)
+		case "unopen":
+			elasticOpenedQuery := apiSearch.ElasticBaseOpenedQuery{}
+			elasticOpenedQuery.Term.Opened = 0
+			elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticOpenedQuery)
+		case "unclick":
+			elasticClickedQuery := apiSearch.ElasticBaseClickedQuery{}
+			elasticClickedQuery.Term.Clicked = 0
+			elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticClickedQuery)
+		}
+	}
+
+	if emailQuery.Text != "" {
+		elasticMatchQuery := elastic.ElasticMatchQuery{}
+		elasticMatchQuery.Match.All = emailQuery.Text
+		elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticMatchQuery)
+	}
+
+	elasticCreatedQuery := apiSearch.ElasticSortDataCreatedQuery{}
+	elasticCreatedQuery.DataCreated.Order = "desc"
+	elasticCreatedQuery.DataCreated.Mode = "avg"
+	elasticQuery.Sort = append(elasticQuery.Sort, elasticCreatedQuery)
+
+	return searchEmailQuery(elasticQuery)
+}
+
+func SearchEmailsByCampaignId(user apiModels.User, campaignId int64, from, limit int) ([]models.Email, int, int, error) {
+	if campaignId == 0 {
+		return nil, 0, 0, nil
+	}
+
+	elasticQuery := elastic.ElasticQueryWithSort{}
+	elasticQuery.Size = limit
+	elasticQuery.From = from
+
+	elasticCreatedByQuery := apiSearch.ElasticCreatedByQuery{}
+	elasticCreatedByQuery.Term.CreatedBy = user.Id
+
+	elasticCampaignIdQuery := elasticCampaignIdQuery{}
+	elasticCampaignIdQuery.Term.CampaignId = campaignId
+
+	elasticIsSentQuery := apiSearch.ElasticIsSentQuery{}
+	elasticIsSentQuery.Term.IsSent = true
+
+	elasticCancelQuery := apiSearch.ElasticCancelQuery{}
+	elasticCancelQuery.Term.Cancel = false
+
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCreatedByQuery)
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCampaignIdQuery)
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticIsSentQuery)
+	elasticQuery.Query.Bool.Must = append(elasticQuery.Query.Bool.Must, elasticCancelQuery)
+
 	elasticCreatedQuery := apiSearch.ElasticSortDataCreatedQuery{}
 	elasticCreatedQuery.DataCreated.Order = "desc"
 	elasticCreatedQuery.DataCreated.Mode = "avg"
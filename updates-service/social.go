@@ -1,19 +1,19 @@
 package updates
 
 import (
+	"errors"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"strings"
 
-	"google.golang.org/appengine"
-	"google.golang.org/appengine/log"
-
 	"github.com/julienschmidt/httprouter"
 	"github.com/pquerna/ffjson/ffjson"
 
-	apiControllers "github.com/news-ai/api/controllers"
+	apiControllers "github.com/news-ai/api-v1/controllers"
 
-	"github.com/news-ai/tabulae/controllers"
+	"github.com/news-ai/tabulae-v1/controllers"
+	"github.com/news-ai/tabulae-v1/models"
 )
 
 type Social struct {
@@ -28,20 +28,28 @@ type SocialToDetails struct {
 	FullName string `json:"fullname"`
 }
 
-func SocialUsernameToDetails(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	c := appengine.NewContext(r)
+// Contacts are stored with the username in lower case, in the column named
+// after the network
+func filterSocialContacts(r *http.Request, network string, username string) ([]models.Contact, error) {
+	switch network {
+	case "Twitter", "Instagram":
+		return controllers.FilterContacts(r, strings.ToLower(network), strings.ToLower(username))
+	}
+	return []models.Contact{}, errors.New("Unknown social network " + network)
+}
 
+func SocialUsernameToDetails(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	// User has to be logged in
-	user, err := apiControllers.GetCurrentUser(c, r)
+	user, err := apiControllers.GetCurrentUser(r)
 	if err != nil {
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", err)
 		w.WriteHeader(500)
 		return
 	}
 
 	// User has to be an admin
-	if !user.IsAdmin {
-		log.Errorf(c, "%v", "User that hit the social username invalid method is not an admin")
+	if !user.Data.IsAdmin {
+		log.Printf("%v", "User that hit the social username invalid method is not an admin")
 		w.WriteHeader(500)
 		return
 	}
@@ -51,15 +59,15 @@ func SocialUsernameToDetails(w http.ResponseWriter, r *http.Request, _ httproute
 	var socialData SocialToDetails
 	err = decoder.Decode(buf, &socialData)
 	if err != nil {
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", err)
 		w.WriteHeader(500)
 		return
 	}
 
-	contacts, err := controllers.FilterContacts(c, r, socialData.Network, socialData.Username)
+	contacts, err := filterSocialContacts(r, socialData.Network, socialData.Username)
 	if err != nil {
-		log.Errorf(c, "%v", socialData)
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", socialData)
+		log.Printf("%v", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -74,7 +82,11 @@ func SocialUsernameToDetails(w http.ResponseWriter, r *http.Request, _ httproute
 			} else {
 				contacts[i].FirstName = fullNameSplit[0]
 			}
-			controllers.Save(c, r, &contacts[i])
+
+			_, err = contacts[i].SaveWithHistory(r, user.Id, models.ContactChangeSourceEnrichment)
+			if err != nil {
+				log.Printf("%v", err)
+			}
 		}
 	}
 
@@ -84,19 +96,17 @@ func SocialUsernameToDetails(w http.ResponseWriter, r *http.Request, _ httproute
 }
 
 func SocialUsernameInvalid(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	c := appengine.NewContext(r)
-
 	// User has to be logged in
-	user, err := apiControllers.GetCurrentUser(c, r)
+	user, err := apiControllers.GetCurrentUser(r)
 	if err != nil {
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", err)
 		w.WriteHeader(500)
 		return
 	}
 
 	// User has to be an admin
-	if !user.IsAdmin {
-		log.Errorf(c, "%v", "User that hit the social username invalid method is not an admin")
+	if !user.Data.IsAdmin {
+		log.Printf("%v", "User that hit the social username invalid method is not an admin")
 		w.WriteHeader(500)
 		return
 	}
@@ -106,15 +116,15 @@ func SocialUsernameInvalid(w http.ResponseWriter, r *http.Request, _ httprouter.
 	var socialData Social
 	err = decoder.Decode(buf, &socialData)
 	if err != nil {
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", err)
 		w.WriteHeader(500)
 		return
 	}
 
-	contacts, err := controllers.FilterContacts(c, r, socialData.Network, socialData.Username)
+	contacts, err := filterSocialContacts(r, socialData.Network, socialData.Username)
 	if err != nil {
-		log.Errorf(c, "%v", socialData)
-		log.Errorf(c, "%v", err)
+		log.Printf("%v", socialData)
+		log.Printf("%v", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -136,7 +146,11 @@ func SocialUsernameInvalid(w http.ResponseWriter, r *http.Request, _ httprouter.
 				contacts[i].InstagramPrivate = true
 			}
 		}
-		controllers.Save(c, r, &contacts[i])
+
+		_, err = contacts[i].SaveWithHistory(r, user.Id, models.ContactChangeSourceSync)
+		if err != nil {
+			log.Printf("%v", err)
+		}
 	}
 
 	// If successful
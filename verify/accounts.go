@@ -0,0 +1,106 @@
+package verify
+
+import (
+	"strings"
+)
+
+// Mailboxes of a department or job rather than a person. Pitches to them
+// are rarely read by the journalist they are meant for.
+var roleAccounts = map[string]bool{
+	"abuse":       true,
+	"admin":       true,
+	"billing":     true,
+	"careers":     true,
+	"contact":     true,
+	"desk":        true,
+	"editor":      true,
+	"editorial":   true,
+	"editors":     true,
+	"enquiries":   true,
+	"feedback":    true,
+	"hello":       true,
+	"help":        true,
+	"hostmaster":  true,
+	"info":        true,
+	"inquiries":   true,
+	"jobs":        true,
+	"letters":     true,
+	"mail":        true,
+	"marketing":   true,
+	"media":       true,
+	"news":        true,
+	"newsdesk":    true,
+	"newsroom":    true,
+	"no-reply":    true,
+	"noreply":     true,
+	"office":      true,
+	"postmaster":  true,
+	"pr":          true,
+	"press":       true,
+	"sales":       true,
+	"subscribe":   true,
+	"support":     true,
+	"team":        true,
+	"tips":        true,
+	"unsubscribe": true,
+	"webmaster":   true,
+}
+
+// Domains that hand out throwaway addresses
+var disposableDomains = map[string]bool{
+	"10minutemail.com":       true,
+	"burnermail.io":          true,
+	"discard.email":          true,
+	"dispostable.com":        true,
+	"emailondeck.com":        true,
+	"fakeinbox.com":          true,
+	"getairmail.com":         true,
+	"getnada.com":            true,
+	"guerrillamail.com":      true,
+	"guerrillamail.net":      true,
+	"guerrillamailblock.com": true,
+	"mailcatch.com":          true,
+	"maildrop.cc":            true,
+	"mailinator.com":         true,
+	"mailnesia.com":          true,
+	"mintemail.com":          true,
+	"mohmal.com":             true,
+	"sharklasers.com":        true,
+	"spamgourmet.com":        true,
+	"temp-mail.org":          true,
+	"tempmail.net":           true,
+	"tempmailo.com":          true,
+	"tempr.email":            true,
+	"throwawaymail.com":      true,
+	"trashmail.com":          true,
+	"yopmail.com":            true,
+}
+
+/*
+* Private methods
+ */
+
+// "press+uk" is the press mailbox
+func isRoleAccount(local string) bool {
+	local = strings.ToLower(local)
+	if i := strings.Index(local, "+"); i != -1 {
+		local = local[:i]
+	}
+	return roleAccounts[local]
+}
+
+// Subdomains of a disposable domain are disposable too
+func isDisposableDomain(domain string) bool {
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+
+		i := strings.Index(domain, ".")
+		if i == -1 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package verify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/smtp"
+	"net/textproto"
+)
+
+// Mail servers are tried in order until one answers
+const maxProbedHosts = 2
+
+/*
+* Private methods
+ */
+
+// A mailbox nobody would have, to find servers that accept any address
+func randomMailbox() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "tabulae-" + hex.EncodeToString(b)
+}
+
+func replyCode(err error) int {
+	if protoErr, ok := err.(*textproto.Error); ok {
+		return protoErr.Code
+	}
+	return 0
+}
+
+// Asks a mail server whether it takes mail for the address, quitting before
+// any mail is sent. Returns the reply code to RCPT and whether the server
+// also takes mail for a made up mailbox. The error is set when the server
+// could not be asked about the address, including when it turned us away
+// before RCPT.
+func (v *Verifier) rcpt(ctx context.Context, host string, email string, domain string) (int, bool, error) {
+	address := host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		address = net.JoinHostPort(host, "25")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	dialer := net.Dialer{}
+	if resolver, ok := v.Resolver.(*net.Resolver); ok {
+		dialer.Resolver = resolver
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	serverName, _, err := net.SplitHostPort(address)
+	if err != nil {
+		serverName = host
+	}
+
+	client, err := smtp.NewClient(conn, serverName)
+	if err != nil {
+		return 0, false, err
+	}
+	defer client.Close()
+
+	err = client.Hello(v.HeloName)
+	if err != nil {
+		return 0, false, err
+	}
+
+	err = client.Mail(v.From)
+	if err != nil {
+		return 0, false, err
+	}
+
+	err = client.Rcpt(email)
+	if err != nil {
+		if code := replyCode(err); code != 0 {
+			client.Quit()
+			return code, false, nil
+		}
+		return 0, false, err
+	}
+
+	// Some servers turn away the sender of too many unknown recipients, so
+	// this is only asked once the real address was accepted
+	catchAll := client.Rcpt(randomMailbox()+"@"+domain) == nil
+
+	client.Quit()
+	return 250, catchAll, nil
+}
+
+// Fills in the result from the mail servers. The status is left blank when
+// the address is accepted, so the other checks can decide it. Only a
+// rejected RCPT makes the address invalid, a server that won't talk to us
+// says nothing about the mailbox.
+func (v *Verifier) probe(ctx context.Context, result *Result, hosts []string, domain string) {
+	if len(hosts) > maxProbedHosts {
+		hosts = hosts[:maxProbedHosts]
+	}
+
+	for _, host := range hosts {
+		code, catchAll, err := v.rcpt(ctx, host, result.Email, domain)
+		if err != nil {
+			if code := replyCode(err); code != 0 && result.SMTPCode == 0 {
+				result.MailHost = host
+				result.SMTPCode = code
+			}
+			continue
+		}
+
+		result.MailHost = host
+		result.SMTPCode = code
+		result.CatchAll = catchAll
+
+		switch {
+		case code >= 200 && code < 300 && catchAll:
+			result.Status, result.Reason = StatusRisky, "Mail server accepts any address, so the mailbox could not be checked"
+		case code >= 200 && code < 300:
+		case code >= 500:
+			result.Status, result.Reason = StatusInvalid, "Mail server rejected the address"
+		default:
+			result.Status, result.Reason = StatusUnknown, "Mail server asked to try again later"
+		}
+		return
+	}
+
+	if result.SMTPCode != 0 {
+		result.Status, result.Reason = StatusUnknown, "Mail server refused to be asked about the address"
+		return
+	}
+	result.Status, result.Reason = StatusUnknown, "Could not reach the mail server"
+}
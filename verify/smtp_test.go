@@ -0,0 +1,186 @@
+package verify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// Replies a fake mail server gives at each step. Mailboxes are accepted
+// when they are in mailboxes, or always when acceptAll is set.
+type fakeSMTPServer struct {
+	greeting  string
+	helo      string
+	mail      string
+	mailboxes map[string]bool
+	acceptAll bool
+	rcptCode  string
+}
+
+func (fs fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply(fs.greeting)
+	if !strings.HasPrefix(fs.greeting, "2") {
+		return
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch {
+		case command == "EHLO" || command == "HELO":
+			reply(fs.helo)
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			reply(fs.mail)
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			mailbox := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if fs.acceptAll || fs.mailboxes[mailbox] {
+				reply("250 2.1.5 OK")
+			} else {
+				reply(fs.rcptCode)
+			}
+		case command == "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// Starts a mail server on a free local port and returns its address
+func startFakeSMTPServer(t *testing.T, fs fakeSMTPServer) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fs.serve(conn)
+		}
+	}()
+
+	return listener.Addr().String(), func() {
+		listener.Close()
+	}
+}
+
+func TestVerifyWithProbe(t *testing.T) {
+	mailboxes := map[string]bool{"jane@example.com": true, "press@example.com": true}
+	tests := []struct {
+		name     string
+		server   fakeSMTPServer
+		email    string
+		status   string
+		smtpCode int
+		catchAll bool
+	}{
+		{
+			name:     "mailbox exists",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "250 mx", mail: "250 OK", mailboxes: mailboxes, rcptCode: "550 5.1.1 No such user"},
+			email:    "jane@example.com",
+			status:   StatusValid,
+			smtpCode: 250,
+		},
+		{
+			name:     "role mailbox exists",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "250 mx", mail: "250 OK", mailboxes: mailboxes, rcptCode: "550 5.1.1 No such user"},
+			email:    "press@example.com",
+			status:   StatusRisky,
+			smtpCode: 250,
+		},
+		{
+			name:     "mailbox rejected",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "250 mx", mail: "250 OK", mailboxes: mailboxes, rcptCode: "550 5.1.1 No such user"},
+			email:    "john@example.com",
+			status:   StatusInvalid,
+			smtpCode: 550,
+		},
+		{
+			name:     "mailbox deferred",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "250 mx", mail: "250 OK", mailboxes: mailboxes, rcptCode: "451 4.7.1 Try again later"},
+			email:    "john@example.com",
+			status:   StatusUnknown,
+			smtpCode: 451,
+		},
+		{
+			name:     "catch all",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "250 mx", mail: "250 OK", acceptAll: true},
+			email:    "jane@example.com",
+			status:   StatusRisky,
+			smtpCode: 250,
+			catchAll: true,
+		},
+		{
+			name:     "greeting refused",
+			server:   fakeSMTPServer{greeting: "554 5.7.1 No service for you"},
+			email:    "jane@example.com",
+			status:   StatusUnknown,
+			smtpCode: 554,
+		},
+		{
+			name:     "helo refused",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "550 5.7.1 Go away", mail: "250 OK", mailboxes: mailboxes},
+			email:    "jane@example.com",
+			status:   StatusUnknown,
+			smtpCode: 550,
+		},
+		{
+			name:     "sender refused",
+			server:   fakeSMTPServer{greeting: "220 mx ESMTP", helo: "250 mx", mail: "550 5.7.1 Sender blocked", mailboxes: mailboxes},
+			email:    "jane@example.com",
+			status:   StatusUnknown,
+			smtpCode: 550,
+		},
+	}
+
+	for _, test := range tests {
+		address, stop := startFakeSMTPServer(t, test.server)
+
+		verifier := newTestVerifier(testResolver)
+		verifier.SMTPProbe = true
+		verifier.MailHost = address
+
+		result := verifier.Verify(context.Background(), test.email)
+		stop()
+
+		if result.Status != test.status || result.SMTPCode != test.smtpCode || result.CatchAll != test.catchAll {
+			t.Errorf("%s: Verify(%q) = %q (%s), code %d, catch all %v, want %q, code %d, catch all %v", test.name, test.email, result.Status, result.Reason, result.SMTPCode, result.CatchAll, test.status, test.smtpCode, test.catchAll)
+		}
+		if result.MailHost != address {
+			t.Errorf("%s: mail host = %q, want %q", test.name, result.MailHost, address)
+		}
+	}
+}
+
+func TestVerifyWithUnreachableMailServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	verifier := newTestVerifier(testResolver)
+	verifier.SMTPProbe = true
+	verifier.MailHost = address
+
+	result := verifier.Verify(context.Background(), "jane@example.com")
+	if result.Status != StatusUnknown || result.SMTPCode != 0 || result.MailHost != "" {
+		t.Errorf("Verify with no mail server = %q (%s), code %d, host %q, want %q with no code or host", result.Status, result.Reason, result.SMTPCode, result.MailHost, StatusUnknown)
+	}
+}
@@ -0,0 +1,232 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+// Outcome of verifying an address
+const (
+	StatusValid   = "valid"
+	StatusInvalid = "invalid"
+
+	// Mail is accepted, but by a role or disposable address or by a server
+	// that takes any address
+	StatusRisky = "risky"
+
+	// The checks could not be finished, like when the mail server can't be
+	// reached
+	StatusUnknown = "unknown"
+)
+
+type Result struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+
+	Syntax     bool `json:"syntax"`
+	MX         bool `json:"mx"`
+	Role       bool `json:"role"`
+	Disposable bool `json:"disposable"`
+
+	// Only known when the SMTP probe ran
+	CatchAll bool   `json:"catchall"`
+	MailHost string `json:"mailhost"`
+	SMTPCode int    `json:"smtpcode"`
+}
+
+// The lookups a Verifier makes, *net.Resolver answers them from DNS
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Verifier checks addresses without sending anything to them. It is set up
+// from the environment:
+//
+//	TABULAE_VERIFY_RESOLVER:  DNS server as host:port, instead of the system one
+//	TABULAE_VERIFY_SMTP:      "true" to ask the mail server about the mailbox
+//	TABULAE_VERIFY_MAIL_HOST: mail server as host:port to probe instead of the
+//	                          domain's MX, for testing locally
+//	TABULAE_VERIFY_HELO:      name to greet mail servers with
+//	TABULAE_VERIFY_FROM:      sender given to mail servers
+type Verifier struct {
+	Resolver Resolver
+
+	SMTPProbe bool
+	MailHost  string
+	HeloName  string
+	From      string
+
+	// Limit on each DNS lookup and SMTP conversation
+	Timeout time.Duration
+}
+
+var (
+	errDomainNotFound = errors.New("Email domain does not exist")
+	errDomainNoMail   = errors.New("Email domain does not accept mail")
+	errLookupFailed   = errors.New("Could not look up the email domain")
+)
+
+/*
+* Private methods
+ */
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// A resolver that sends every query to one DNS server
+func newResolver(address string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// Splits an address into its mailbox and lowercased domain, making sure it is
+// a bare address that could be delivered to
+func parseAddress(email string) (string, string, error) {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return "", "", errors.New("Not a valid email address")
+	}
+
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	if len(local) > 64 || len(email) > 254 {
+		return "", "", errors.New("Email address is too long")
+	}
+
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, "[") {
+		return "", "", errors.New("Not a valid email domain")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", "", errors.New("Not a valid email domain")
+		}
+	}
+
+	return local, domain, nil
+}
+
+// Mail servers of a domain, most preferred first. A domain without MX
+// records gets its mail at its own address.
+func (v *Verifier) mailServers(ctx context.Context, domain string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.Timeout)
+	defer cancel()
+
+	mxs, err := v.Resolver.LookupMX(ctx, domain)
+	if err == nil && len(mxs) > 0 {
+		// A single "." is a null MX: the domain takes no mail
+		if len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "") {
+			return []string{}, errDomainNoMail
+		}
+
+		hosts := []string{}
+		for i := 0; i < len(mxs); i++ {
+			hosts = append(hosts, strings.TrimSuffix(mxs[i].Host, "."))
+		}
+		return hosts, nil
+	}
+
+	if dnsErr, ok := err.(*net.DNSError); ok && !dnsErr.IsNotFound {
+		return []string{}, errLookupFailed
+	}
+
+	addresses, err := v.Resolver.LookupHost(ctx, domain)
+	if err != nil || len(addresses) == 0 {
+		if dnsErr, ok := err.(*net.DNSError); ok && !dnsErr.IsNotFound {
+			return []string{}, errLookupFailed
+		}
+		return []string{}, errDomainNotFound
+	}
+	return []string{domain}, nil
+}
+
+/*
+* Public methods
+ */
+
+func NewVerifier() *Verifier {
+	verifier := Verifier{
+		Resolver:  net.DefaultResolver,
+		SMTPProbe: getEnv("TABULAE_VERIFY_SMTP", "false") == "true",
+		MailHost:  getEnv("TABULAE_VERIFY_MAIL_HOST", ""),
+		HeloName:  getEnv("TABULAE_VERIFY_HELO", "newsai.co"),
+		From:      getEnv("TABULAE_VERIFY_FROM", "verify@newsai.co"),
+		Timeout:   10 * time.Second,
+	}
+
+	if resolver := getEnv("TABULAE_VERIFY_RESOLVER", ""); resolver != "" {
+		verifier.Resolver = newResolver(resolver)
+	}
+
+	return &verifier
+}
+
+// Checks the syntax of the address, that its domain takes mail and whether
+// it is a role or disposable address. With SMTPProbe the mail server is asked
+// whether the mailbox exists, stopping before anything is sent.
+func (v *Verifier) Verify(ctx context.Context, email string) Result {
+	email = strings.TrimSpace(email)
+	result := Result{Email: email}
+
+	local, domain, err := parseAddress(email)
+	if err != nil {
+		result.Status, result.Reason = StatusInvalid, err.Error()
+		return result
+	}
+	result.Syntax = true
+	result.Role = isRoleAccount(local)
+	result.Disposable = isDisposableDomain(domain)
+
+	hosts, err := v.mailServers(ctx, domain)
+	if err == errLookupFailed {
+		result.Status, result.Reason = StatusUnknown, err.Error()
+		return result
+	}
+	if err != nil {
+		result.Status, result.Reason = StatusInvalid, err.Error()
+		return result
+	}
+	result.MX = true
+
+	if result.Disposable {
+		result.Status, result.Reason = StatusRisky, "Disposable email address"
+		return result
+	}
+
+	if v.SMTPProbe {
+		if v.MailHost != "" {
+			hosts = []string{v.MailHost}
+		}
+
+		v.probe(ctx, &result, hosts, domain)
+		if result.Status != "" {
+			return result
+		}
+	}
+
+	if result.Role {
+		result.Status, result.Reason = StatusRisky, "Role address, likely read by more than one person"
+		return result
+	}
+
+	result.Status, result.Reason = StatusValid, "Email domain accepts mail"
+	if v.SMTPProbe {
+		result.Reason = "Mail server accepts the address"
+	}
+	return result
+}
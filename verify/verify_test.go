@@ -0,0 +1,142 @@
+package verify
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// Answers lookups from maps. Names in neither map don't exist, and every
+// lookup fails when broken is set.
+type fakeResolver struct {
+	mx     map[string][]*net.MX
+	hosts  map[string][]string
+	broken bool
+}
+
+func (fr fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if fr.broken {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	if mxs, ok := fr.mx[name]; ok {
+		return mxs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (fr fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if fr.broken {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+	if addresses, ok := fr.hosts[host]; ok {
+		return addresses, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+var testResolver = fakeResolver{
+	mx: map[string][]*net.MX{
+		"example.com":    {{Host: "mx1.example.com.", Pref: 10}, {Host: "mx2.example.com.", Pref: 20}},
+		"nomail.com":     {{Host: ".", Pref: 0}},
+		"mailinator.com": {{Host: "mail.mailinator.com.", Pref: 10}},
+	},
+	hosts: map[string][]string{
+		"nomx.org": {"192.0.2.1"},
+	},
+}
+
+func newTestVerifier(resolver Resolver) *Verifier {
+	return &Verifier{
+		Resolver: resolver,
+		HeloName: "test.local",
+		From:     "verify@test.local",
+		Timeout:  2 * time.Second,
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		email  string
+		local  string
+		domain string
+		valid  bool
+	}{
+		{"jane@Example.COM", "jane", "example.com", true},
+		{"jane.doe+press@news.example.co.uk", "jane.doe+press", "news.example.co.uk", true},
+		{"Jane Doe <jane@example.com>", "", "", false},
+		{"jane@localhost", "", "", false},
+		{"jane@[192.0.2.1]", "", "", false},
+		{"jane@-example.com", "", "", false},
+		{"jane@example..com", "", "", false},
+		{"jane", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, test := range tests {
+		local, domain, err := parseAddress(test.email)
+		if (err == nil) != test.valid || local != test.local || domain != test.domain {
+			t.Errorf("parseAddress(%q) = %q, %q, %v, want %q, %q, valid %v", test.email, local, domain, err, test.local, test.domain, test.valid)
+		}
+	}
+}
+
+func TestMailServers(t *testing.T) {
+	tests := []struct {
+		domain string
+		hosts  []string
+		err    error
+	}{
+		{"example.com", []string{"mx1.example.com", "mx2.example.com"}, nil},
+		{"nomx.org", []string{"nomx.org"}, nil},
+		{"nomail.com", []string{}, errDomainNoMail},
+		{"missing.com", []string{}, errDomainNotFound},
+	}
+
+	verifier := newTestVerifier(testResolver)
+	for _, test := range tests {
+		hosts, err := verifier.mailServers(context.Background(), test.domain)
+		if err != test.err || len(hosts) != len(test.hosts) {
+			t.Errorf("mailServers(%q) = %v, %v, want %v, %v", test.domain, hosts, err, test.hosts, test.err)
+			continue
+		}
+		for i := 0; i < len(hosts); i++ {
+			if hosts[i] != test.hosts[i] {
+				t.Errorf("mailServers(%q) = %v, want %v", test.domain, hosts, test.hosts)
+				break
+			}
+		}
+	}
+
+	verifier = newTestVerifier(fakeResolver{broken: true})
+	if _, err := verifier.mailServers(context.Background(), "example.com"); err != errLookupFailed {
+		t.Errorf("mailServers with a failing resolver returned %v, want %v", err, errLookupFailed)
+	}
+}
+
+func TestVerifyWithoutProbe(t *testing.T) {
+	tests := []struct {
+		email  string
+		status string
+	}{
+		{"jane@example.com", StatusValid},
+		{"press@example.com", StatusRisky},
+		{"jane@mailinator.com", StatusRisky},
+		{"jane@nomail.com", StatusInvalid},
+		{"jane@missing.com", StatusInvalid},
+		{"not an address", StatusInvalid},
+	}
+
+	verifier := newTestVerifier(testResolver)
+	for _, test := range tests {
+		result := verifier.Verify(context.Background(), test.email)
+		if result.Status != test.status {
+			t.Errorf("Verify(%q) = %q (%s), want %q", test.email, result.Status, result.Reason, test.status)
+		}
+	}
+
+	verifier = newTestVerifier(fakeResolver{broken: true})
+	if result := verifier.Verify(context.Background(), "jane@example.com"); result.Status != StatusUnknown {
+		t.Errorf("Verify with a failing resolver = %q, want %q", result.Status, StatusUnknown)
+	}
+}